@@ -2,12 +2,18 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	neturl "net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/quic-s/quics/pkg/app"
+	"github.com/quic-s/quics/pkg/config"
 	"github.com/quic-s/quics/pkg/types"
 	"github.com/quic-s/quics/pkg/utils"
 	"github.com/spf13/cobra"
@@ -36,6 +42,7 @@ import (
 * `qis show file --all`: Show all files information
 * `qis show history --id <file-history-key>`: Show history information
 * `qis show history --all`: Show all history information
+* `qis show <client|dir|file|history> [--limit <count>] [--after <cursor>] [--filter <key>=<value>]... [--sort <key|time>]`: Show page of information
 *
 * `qis remove`: Initialize quic-s server (needed options)
 * `qis remove client --id <client-UUID>`: Initialize client
@@ -46,6 +53,44 @@ import (
 * `qis remove file --all`: Initialize all files
 *
 * `qis download file --path --version --target`: Download certain file
+*
+* `qis status`: Show running status of quic-s server
+*
+* `qis user add --name <name> --pw <password> --role <admin|member>`: Add user account
+* `qis user list`: Show all user accounts
+* `qis user disable --name <name>`: Disable user account
+*
+* `qis member invite --path <root-directory> --member <user:name|client:UUID> --role <owner|writer|reader>`: Invite member to root directory
+* `qis member revoke --path <root-directory> --member <user:name|client:UUID>`: Revoke member from root directory
+*
+* `qis client revoke --id <client-UUID>`: Revoke certificate of client
+*
+* `qis lockout list`: Show failed authentication attempts and lockouts
+* `qis lockout clear --id <ip:address|uuid:UUID>`: Clear lockout
+* `qis lockout clear --all`: Clear all lockouts
+*
+* `qis cert show`: Show TLS certificate of server
+* `qis cert renew`: Renew generated TLS certificate of server
+*
+* `qis token create --name <name> --scope <read|admin> [--pw <password>] [--save]`: Create API token
+* `qis token list`: Show all API tokens and endpoints called with them
+* `qis token revoke --id <token-ID>`: Revoke API token
+*
+* `qis key create --name <user-name>`: Create S3 access key of user account
+* `qis key list`: Show all S3 access keys
+* `qis key revoke --id <access-key-ID>`: Revoke S3 access key
+*
+* `qis audit show [--since <RFC3339-time|duration>] [--actor <UUID>] [--path <path>]`: Show audit log
+* `qis audit verify`: Verify hash chain of audit log
+*
+* `qis share drop --path <folder> --max-count <count> [--max-size <bytes>] [--expire <duration>] [--pw <password>] [--note <note>]`: Create upload-only link
+* `qis share list [--owner <owner>] [--path <path>]`: Show sharing links and their downloads
+* `qis share revoke [--id <link-ID>] [--owner <owner>] [--path <path>] [--root <root-directory>]`: Revoke sharing links matching all options
+*
+* `qis conflict list [--root <root-directory>]`: Show open conflicts of all root directories
+* `qis conflict show --path <file-path>`: Show candidates of conflicted file
+* `qis conflict download --path <file-path> --side <server|client-UUID> --target <destination>`: Download candidate of conflicted file
+* `qis conflict resolve --path <file-path> --side <server|client-UUID>`: Resolve conflict with candidate
  */
 
 /**
@@ -70,6 +115,33 @@ import (
 * `--port`: Port option
 *
 * `--password`: Password option
+*
+* `--name`: User name option
+* `--role`: User role option
+*
+* `--member`: Root directory member option
+*
+* `--token`: API token option
+* `--scope`: API token scope option
+* `--save`: Save API token option
+*
+* `--since`: Audit log since option
+* `--actor`: Audit log actor option
+*
+* `--max-size`: Max size of file option
+* `--max-count`: Max count option
+* `--expire`: Expiry duration option
+* `--note`: Note option
+*
+* `--owner`: Owner of sharing link option
+* `--root`: Root directory option
+*
+* `--side`: Candidate of conflict option
+*
+* `--limit`: Max number of records in page option
+* `--after`: Cursor of page option
+* `--filter`: Filter of records option (root, prefix, uuid, since, conflicted)
+* `--sort`: Sort order of records option (key or time, descending with "-" prefix)
  */
 
 const (
@@ -82,9 +154,30 @@ const (
 	ShowCommand     = "show"
 	RemoveCommand   = "remove"
 	DownloadCommand = "download"
-
-	SetCommand   = "set"
-	ResetCommand = "reset"
+	StatusCommand   = "status"
+	UserCommand     = "user"
+	MemberCommand   = "member"
+	LockoutCommand  = "lockout"
+	CertCommand     = "cert"
+	TokenCommand    = "token"
+	KeyCommand      = "key"
+	AuditCommand    = "audit"
+	ShareCommand    = "share"
+	ConflictCommand = "conflict"
+
+	SetCommand     = "set"
+	ResetCommand   = "reset"
+	AddCommand     = "add"
+	ListCommand    = "list"
+	DisableCommand = "disable"
+	InviteCommand  = "invite"
+	RevokeCommand  = "revoke"
+	ClearCommand   = "clear"
+	RenewCommand   = "renew"
+	CreateCommand  = "create"
+	VerifyCommand  = "verify"
+	DropCommand    = "drop"
+	ResolveCommand = "resolve"
 
 	ClientCommand  = "client"
 	DirCommand     = "dir"
@@ -124,6 +217,63 @@ const (
 
 	// --pw (not exist short option)
 	PasswordOption = "pw"
+
+	// --name (not exist short option)
+	NameOption = "name"
+
+	// --role (not exist short option)
+	RoleOption = "role"
+
+	// --member (not exist short option)
+	MemberOption = "member"
+
+	// --token (not exist short option)
+	TokenOption = "token"
+
+	// --scope (not exist short option)
+	ScopeOption = "scope"
+
+	// --save (not exist short option)
+	SaveOption = "save"
+
+	// --since (not exist short option)
+	SinceOption = "since"
+
+	// --actor (not exist short option)
+	ActorOption = "actor"
+
+	// --max-size (not exist short option)
+	MaxSizeOption = "max-size"
+
+	// --max-count (not exist short option)
+	MaxCountOption = "max-count"
+
+	// --expire (not exist short option)
+	ExpireOption = "expire"
+
+	// --note (not exist short option)
+	NoteOption = "note"
+
+	// --owner (not exist short option)
+	OwnerOption = "owner"
+
+	// --root (not exist short option)
+	RootOption = "root"
+
+	// --side (not exist short option)
+	SideOption = "side"
+
+	// --limit (not exist short option)
+	LimitOption = "limit"
+
+	// --after (not exist short option)
+	AfterOption = "after"
+
+	// --filter (not exist short option)
+	FilterOption = "filter"
+
+	// --sort (not exist short option)
+	SortOption = "sort"
 )
 
 var (
@@ -136,6 +286,26 @@ var (
 	port     string = ""
 	port3    string = ""
 	password string = ""
+	name     string = ""
+	role     string = ""
+	member   string = ""
+	token    string = ""
+	scope    string = ""
+	save     bool   = false
+	since    string = ""
+	actor    string = ""
+	maxSize  uint64 = 0
+	maxCount uint64 = 0
+	expire   string = ""
+	note     string = ""
+	owner    string = ""
+	root     string = ""
+	side     string = ""
+
+	limit     int      = 0
+	after     string   = ""
+	filters   []string = []string{}
+	sortOrder string   = ""
 )
 
 var rootCmd = &cobra.Command{
@@ -144,24 +314,60 @@ var rootCmd = &cobra.Command{
 }
 
 var (
-	startServerCmd   *cobra.Command
-	stopServerCmd    *cobra.Command
-	listenCmd        *cobra.Command
-	runCmd           *cobra.Command
-	passwordCmd      *cobra.Command
-	passwordSetCmd   *cobra.Command
-	passwordResetCmd *cobra.Command
-	showCmd          *cobra.Command
-	showClientCmd    *cobra.Command
-	showDirCmd       *cobra.Command
-	showFileCmd      *cobra.Command
-	showHistoryCmd   *cobra.Command
-	removeCmd        *cobra.Command
-	removeClientCmd  *cobra.Command
-	removeDirCmd     *cobra.Command
-	removeFileCmd    *cobra.Command
-	downloadCmd      *cobra.Command
-	downloadFileCmd  *cobra.Command
+	startServerCmd      *cobra.Command
+	stopServerCmd       *cobra.Command
+	listenCmd           *cobra.Command
+	runCmd              *cobra.Command
+	passwordCmd         *cobra.Command
+	passwordSetCmd      *cobra.Command
+	passwordResetCmd    *cobra.Command
+	showCmd             *cobra.Command
+	showClientCmd       *cobra.Command
+	showDirCmd          *cobra.Command
+	showFileCmd         *cobra.Command
+	showHistoryCmd      *cobra.Command
+	removeCmd           *cobra.Command
+	removeClientCmd     *cobra.Command
+	removeDirCmd        *cobra.Command
+	removeFileCmd       *cobra.Command
+	downloadCmd         *cobra.Command
+	downloadFileCmd     *cobra.Command
+	statusCmd           *cobra.Command
+	userCmd             *cobra.Command
+	userAddCmd          *cobra.Command
+	userListCmd         *cobra.Command
+	userDisableCmd      *cobra.Command
+	memberCmd           *cobra.Command
+	memberInviteCmd     *cobra.Command
+	memberRevokeCmd     *cobra.Command
+	clientCmd           *cobra.Command
+	clientRevokeCmd     *cobra.Command
+	lockoutCmd          *cobra.Command
+	lockoutListCmd      *cobra.Command
+	lockoutClearCmd     *cobra.Command
+	certCmd             *cobra.Command
+	certShowCmd         *cobra.Command
+	certRenewCmd        *cobra.Command
+	tokenCmd            *cobra.Command
+	tokenCreateCmd      *cobra.Command
+	tokenListCmd        *cobra.Command
+	tokenRevokeCmd      *cobra.Command
+	keyCmd              *cobra.Command
+	keyCreateCmd        *cobra.Command
+	keyListCmd          *cobra.Command
+	keyRevokeCmd        *cobra.Command
+	auditCmd            *cobra.Command
+	auditShowCmd        *cobra.Command
+	auditVerifyCmd      *cobra.Command
+	shareCmd            *cobra.Command
+	shareDropCmd        *cobra.Command
+	shareListCmd        *cobra.Command
+	shareRevokeCmd      *cobra.Command
+	conflictCmd         *cobra.Command
+	conflictListCmd     *cobra.Command
+	conflictShowCmd     *cobra.Command
+	conflictDownloadCmd *cobra.Command
+	conflictResolveCmd  *cobra.Command
 )
 
 // Run initializes and executes commands using cobra library
@@ -185,6 +391,42 @@ func Run() int {
 	removeFileCmd = initRemoveFileCmd()
 	downloadCmd = initDownloadCmd()
 	downloadFileCmd = initDownloadFileCmd()
+	statusCmd = initStatusCmd()
+	userCmd = initUserCmd()
+	userAddCmd = initUserAddCmd()
+	userListCmd = initUserListCmd()
+	userDisableCmd = initUserDisableCmd()
+	memberCmd = initMemberCmd()
+	memberInviteCmd = initMemberInviteCmd()
+	memberRevokeCmd = initMemberRevokeCmd()
+	clientCmd = initClientCmd()
+	clientRevokeCmd = initClientRevokeCmd()
+	lockoutCmd = initLockoutCmd()
+	lockoutListCmd = initLockoutListCmd()
+	lockoutClearCmd = initLockoutClearCmd()
+	certCmd = initCertCmd()
+	certShowCmd = initCertShowCmd()
+	certRenewCmd = initCertRenewCmd()
+	tokenCmd = initTokenCmd()
+	tokenCreateCmd = initTokenCreateCmd()
+	tokenListCmd = initTokenListCmd()
+	tokenRevokeCmd = initTokenRevokeCmd()
+	keyCmd = initKeyCmd()
+	keyCreateCmd = initKeyCreateCmd()
+	keyListCmd = initKeyListCmd()
+	keyRevokeCmd = initKeyRevokeCmd()
+	auditCmd = initAuditCmd()
+	auditShowCmd = initAuditShowCmd()
+	auditVerifyCmd = initAuditVerifyCmd()
+	shareCmd = initShareCmd()
+	shareDropCmd = initShareDropCmd()
+	shareListCmd = initShareListCmd()
+	shareRevokeCmd = initShareRevokeCmd()
+	conflictCmd = initConflictCmd()
+	conflictListCmd = initConflictListCmd()
+	conflictShowCmd = initConflictShowCmd()
+	conflictDownloadCmd = initConflictDownloadCmd()
+	conflictResolveCmd = initConflictResolveCmd()
 
 	// set flags (= options)
 	// qis start --addr <server-ip> --port <http-port> --port3 <http3-port>
@@ -200,15 +442,19 @@ func Run() int {
 	// qis show client --id, qis show client --all
 	showClientCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Show all status")
 	showClientCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Show status by ID")
+	addPageFlags(showClientCmd)
 	// qis show dir --id, qis show dir --all
 	showDirCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Show all status")
 	showDirCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Show status by ID")
+	addPageFlags(showDirCmd)
 	// qis show file --id, qis show file --all
 	showFileCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Show all status")
 	showFileCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Show status by ID")
+	addPageFlags(showFileCmd)
 	// qis show history --id, qis show history --all
 	showHistoryCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Show all status")
 	showHistoryCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Show status by ID")
+	addPageFlags(showHistoryCmd)
 	// qis remove client --id, qis remove client --all
 	removeClientCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Initialize all data")
 	removeClientCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Initialize by ID")
@@ -222,6 +468,67 @@ func Run() int {
 	downloadFileCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Download a file by path")
 	downloadFileCmd.Flags().Uint64VarP(&version, VersionOption, VersionShortCommand, 0, "Download a file by version")
 	downloadFileCmd.Flags().StringVarP(&target, TargetOption, TargetShortCommand, "", "Download location")
+	// qis status --token <API-token>
+	statusCmd.Flags().StringVarP(&token, TokenOption, "", "", "API token (default: API_TOKEN in qis.env)")
+	// qis user add --name <name> --pw <password> --role <admin|member>
+	userAddCmd.Flags().StringVarP(&name, NameOption, "", "", "Name of user account")
+	userAddCmd.Flags().StringVarP(&password, PasswordOption, "", "", "Password of user account")
+	userAddCmd.Flags().StringVarP(&role, RoleOption, "", "member", "Role of user account (admin or member)")
+	// qis user disable --name <name>
+	userDisableCmd.Flags().StringVarP(&name, NameOption, "", "", "Name of user account")
+	// qis member invite --path <root-directory> --member <member> --role <role>
+	memberInviteCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Root directory path")
+	memberInviteCmd.Flags().StringVarP(&member, MemberOption, "", "", "Member to invite (user:<name> or client:<UUID>)")
+	memberInviteCmd.Flags().StringVarP(&role, RoleOption, "", types.RootRoleWriter, "Role of member (owner, writer or reader)")
+	// qis member revoke --path <root-directory> --member <member>
+	memberRevokeCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Root directory path")
+	memberRevokeCmd.Flags().StringVarP(&member, MemberOption, "", "", "Member to revoke (user:<name> or client:<UUID>)")
+	// qis client revoke --id <client-UUID>
+	clientRevokeCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Revoke certificate of client by UUID")
+	// qis lockout clear --id <key> | --all
+	lockoutClearCmd.Flags().BoolVarP(&all, AllOption, AllShortOption, false, "Clear all lockouts")
+	lockoutClearCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Clear lockout by key (ip:<address>, uuid:<UUID> or user:<name>)")
+	// qis token create --name <name> --scope <read|admin> --pw <password> --save
+	tokenCreateCmd.Flags().StringVarP(&name, NameOption, "", "", "Name of API token")
+	tokenCreateCmd.Flags().StringVarP(&scope, ScopeOption, "", types.TokenScopeRead, "Scope of API token (read or admin)")
+	tokenCreateCmd.Flags().StringVarP(&password, PasswordOption, "", "", "Server password or password of admin user (default: password in qis.env)")
+	tokenCreateCmd.Flags().BoolVarP(&save, SaveOption, "", false, "Save API token to qis.env for qis commands")
+	// qis token revoke --id <token-ID>
+	tokenRevokeCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Revoke API token by ID")
+	// qis key create --name <user-name>
+	keyCreateCmd.Flags().StringVarP(&name, NameOption, "", "", "Name of user account which owns the access key")
+	// qis key revoke --id <access-key-ID>
+	keyRevokeCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Revoke access key by access key ID")
+	// qis audit show --since <RFC3339-time|duration> --actor <UUID> --path <path>
+	auditShowCmd.Flags().StringVarP(&since, SinceOption, "", "", "Show audit events since RFC3339 time or duration before now (e.g., 24h)")
+	auditShowCmd.Flags().StringVarP(&actor, ActorOption, "", "", "Show audit events of actor (e.g., client UUID, token:<ID>)")
+	auditShowCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Show audit events under path")
+	// qis share drop --path <folder> --max-count <count> --max-size <bytes> --expire <duration> --pw <password> --note <note>
+	shareDropCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Folder of root directory to receive files (e.g., /rootDir/inbox)")
+	shareDropCmd.Flags().Uint64VarP(&maxCount, MaxCountOption, "", 0, "Max number of files which can be uploaded")
+	shareDropCmd.Flags().Uint64VarP(&maxSize, MaxSizeOption, "", 0, "Max size of each file in bytes (default: not limited)")
+	shareDropCmd.Flags().StringVarP(&expire, ExpireOption, "", "", "Link expires after duration (e.g., 72h, default: not expired)")
+	shareDropCmd.Flags().StringVarP(&password, PasswordOption, "", "", "Upload password of link")
+	shareDropCmd.Flags().StringVarP(&note, NoteOption, "", "", "Note shown to uploaders")
+	// qis share list [--owner <owner>] [--path <path>]
+	shareListCmd.Flags().StringVarP(&owner, OwnerOption, "", "", "Show links of owner (client UUID or user)")
+	shareListCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Show links of path or paths under it")
+	// qis share revoke [--id <link-ID>] [--owner <owner>] [--path <path>] [--root <root-directory>]
+	shareRevokeCmd.Flags().StringVarP(&id, IDOption, IDShortCommand, "", "Revoke link by ID")
+	shareRevokeCmd.Flags().StringVarP(&owner, OwnerOption, "", "", "Revoke links of owner (client UUID or user)")
+	shareRevokeCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Revoke links of path or paths under it")
+	shareRevokeCmd.Flags().StringVarP(&root, RootOption, "", "", "Revoke links in root directory")
+	// qis conflict list [--root <root-directory>]
+	conflictListCmd.Flags().StringVarP(&root, RootOption, "", "", "Show conflicts of root directory")
+	// qis conflict show --path <file-path>
+	conflictShowCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Path of conflicted file")
+	// qis conflict download --path <file-path> --side <server|client-UUID> --target <destination>
+	conflictDownloadCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Path of conflicted file")
+	conflictDownloadCmd.Flags().StringVarP(&side, SideOption, "", "", "Candidate to download (server or client UUID)")
+	conflictDownloadCmd.Flags().StringVarP(&target, TargetOption, TargetShortCommand, "", "Download location")
+	// qis conflict resolve --path <file-path> --side <server|client-UUID>
+	conflictResolveCmd.Flags().StringVarP(&path, PathOption, PathShortCommand, "", "Path of conflicted file")
+	conflictResolveCmd.Flags().StringVarP(&side, SideOption, "", "", "Candidate to keep (server or client UUID)")
 
 	// add command to root command
 	rootCmd.AddCommand(startServerCmd)
@@ -232,6 +539,17 @@ func Run() int {
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(downloadCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(memberCmd)
+	rootCmd.AddCommand(clientCmd)
+	rootCmd.AddCommand(lockoutCmd)
+	rootCmd.AddCommand(certCmd)
+	rootCmd.AddCommand(tokenCmd)
+	rootCmd.AddCommand(keyCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(shareCmd)
+	rootCmd.AddCommand(conflictCmd)
 
 	// add command to password command
 	passwordCmd.AddCommand(passwordSetCmd)
@@ -251,6 +569,51 @@ func Run() int {
 	// add command to download command
 	downloadCmd.AddCommand(downloadFileCmd)
 
+	// add command to user command
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userListCmd)
+	userCmd.AddCommand(userDisableCmd)
+
+	// add command to member command
+	memberCmd.AddCommand(memberInviteCmd)
+	memberCmd.AddCommand(memberRevokeCmd)
+
+	// add command to client command
+	clientCmd.AddCommand(clientRevokeCmd)
+
+	// add command to lockout command
+	lockoutCmd.AddCommand(lockoutListCmd)
+	lockoutCmd.AddCommand(lockoutClearCmd)
+
+	// add command to cert command
+	certCmd.AddCommand(certShowCmd)
+	certCmd.AddCommand(certRenewCmd)
+
+	// add command to token command
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	// add command to key command
+	keyCmd.AddCommand(keyCreateCmd)
+	keyCmd.AddCommand(keyListCmd)
+	keyCmd.AddCommand(keyRevokeCmd)
+
+	// add command to audit command
+	auditCmd.AddCommand(auditShowCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+
+	// add command to share command
+	shareCmd.AddCommand(shareDropCmd)
+	shareCmd.AddCommand(shareListCmd)
+	shareCmd.AddCommand(shareRevokeCmd)
+
+	// add command to conflict command
+	conflictCmd.AddCommand(conflictListCmd)
+	conflictCmd.AddCommand(conflictShowCmd)
+	conflictCmd.AddCommand(conflictDownloadCmd)
+	conflictCmd.AddCommand(conflictResolveCmd)
+
 	// execute command
 	if err := rootCmd.Execute(); err != nil {
 		return 1
@@ -439,32 +802,23 @@ func initShowClientCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			validateOptionByCommand(showClientCmd)
 
-			url := "/api/v1/server/logs/clients?uuid=" + id
-
-			restClient := NewRestClient()
-
-			response, err := restClient.GetRequest(url) // /clients
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			err = restClient.Close()
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			clients := []types.Client{}
-			utils.UnmarshalRequestBody(response.Bytes(), clients)
-
-			for _, client := range clients {
+			return showPages("/api/v2/clients", "uuid", func(client *types.Client) {
+				state := "offline"
+				if client.Presence.Online {
+					state = "online"
+				}
+				connectedAt, lastSeen := "-", "-"
+				if !client.Presence.ConnectedAt.IsZero() {
+					connectedAt = client.Presence.ConnectedAt.Format(time.RFC3339)
+				}
+				if !client.Presence.LastSeen.IsZero() {
+					lastSeen = client.Presence.LastSeen.Format(time.RFC3339)
+				}
+				fmt.Printf("*   UUID: %s   |   State: %s   |   Connected At: %s   |   Last Seen: %s   |   Address: %s   |   Version: %s   *\n", client.UUID, state, connectedAt, lastSeen, client.Presence.Address, client.Presence.Version)
 				for _, root := range client.Root {
-					fmt.Printf("*   UUID: %s   |   ID: %d   |   IP: %s   |   Root Directoreis: %s   *\n", client.UUID, client.Id, client.Ip, root)
+					fmt.Printf("*   UUID: %s   |   ID: %d   |   IP: %s   |   User: %s   |   Certificate: %s (revoked: %t)   |   Root Directoreis: %s   *\n", client.UUID, client.Id, client.Ip, client.OwnerUser, client.CertFingerprint, client.CertRevoked, root.AfterPath)
 				}
-			}
-
-			return nil
+			})
 		},
 	}
 }
@@ -476,31 +830,14 @@ func initShowDirCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			validateOptionByCommand(showDirCmd)
 
-			url := "/api/v1/server/logs/directories?afterPath=" + path
-
-			restClient := NewRestClient()
-
-			response, err := restClient.GetRequest(url) // /directories
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			err = restClient.Close()
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			dirs := []types.RootDirectory{}
-			utils.UnmarshalRequestBody(response.Bytes(), dirs)
-			for _, dir := range dirs {
+			return showPages("/api/v2/directories", "path", func(dir *types.RootDirectory) {
 				for _, UUID := range dir.UUIDs {
-					fmt.Printf("*   Root Directory: %s   |   Owner: %s   |   Password: %s   |   UUID: %s   *\n", dir.AfterPath, dir.Owner, dir.Password, UUID)
+					fmt.Printf("*   Root Directory: %s   |   Owner: %s   |   UUID: %s   *\n", dir.AfterPath, dir.Owner, UUID)
 				}
-			}
-
-			return nil
+				for aclMember, aclRole := range dir.ACL {
+					fmt.Printf("*   Root Directory: %s   |   Member: %s   |   Role: %s   *\n", dir.AfterPath, aclMember, aclRole)
+				}
+			})
 		},
 	}
 }
@@ -512,30 +849,9 @@ func initShowFileCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			validateOptionByCommand(showFileCmd)
 
-			url := "/api/v1/server/logs/files?afterPath=" + path
-
-			restClient := NewRestClient()
-
-			response, err := restClient.GetRequest(url) // /files
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			err = restClient.Close()
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			files := []types.File{}
-			utils.UnmarshalRequestBody(response.Bytes(), files)
-
-			for _, file := range files {
+			return showPages("/api/v2/files", "path", func(file *types.File) {
 				fmt.Printf("*   File: %s   |   Root Directory: %s   |   LatestHash: %s   |   LatestSyncTimestamp: %d   |   ContentsExisted: %t   |   Metadata: %s   *\n", file.AfterPath, file.RootDirKey, file.LatestHash, file.LatestSyncTimestamp, file.ContentsExisted, file.Metadata.ModTime)
-			}
-
-			return nil
+			})
 		},
 	}
 }
@@ -547,30 +863,9 @@ func initShowHistoryCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			validateOptionByCommand(showHistoryCmd)
 
-			url := "/api/v1/server/logs/histories?afterPath=" + path
-
-			restClient := NewRestClient()
-
-			response, err := restClient.GetRequest(url) // /history
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			err = restClient.Close()
-			if err != nil {
-				log.Println("quics err: ", err)
-				return err
-			}
-
-			histories := []types.FileHistory{}
-			utils.UnmarshalRequestBody(response.Bytes(), histories)
-
-			for _, history := range histories {
+			return showPages("/api/v2/histories", "path", func(history *types.FileHistory) {
 				fmt.Printf("*   Path: %s   |   Date: %s   |   UUID: %s   |   Timestamp: %d   |   Hash: %s   |*\n", history.BeforePath+history.AfterPath, history.Date, history.UUID, history.Timestamp, history.Hash)
-			}
-
-			return nil
+			})
 		},
 	}
 }
@@ -719,14 +1014,1273 @@ func initDownloadFileCmd() *cobra.Command {
 	}
 }
 
-// ********************************************************************************
-//                                  Private Logic
-// ********************************************************************************
+func initStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   StatusCommand,
+		Short: "show running status of quic-s server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/server/status"
 
-func validateOptionByCommand(command *cobra.Command) {
-	if !all && id == "" {
-		log.Println("quics: ", "Please enter only one option")
-		command.Help()
-		return
+			restClient := NewRestClient()
+			if token != "" {
+				restClient.SetToken(token)
+			}
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			status := &types.ServerStatus{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), status)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			fmt.Printf("*   Version: %s   |   Uptime: %s   |   Connected Clients: %d   |   Pending Syncs: %d   *\n", status.Version, status.Uptime, len(status.ConnectedClients), status.PendingSyncNum)
+			for _, uuid := range status.ConnectedClients {
+				fmt.Printf("*   Connected Client: %s   *\n", uuid)
+			}
+			for _, result := range status.LastFullScans {
+				fmt.Printf("*   FullScan: %s   |   End: %s   |   Scanned: %d   |   MustSync: %d   |   ForceSync: %d   |   NeedContent: %d   |   Error: %s   *\n", result.UUID, result.EndTime.Format(time.RFC3339), result.ScannedFiles, result.MustSyncFiles, result.ForceSyncFiles, result.NeedContents, result.Error)
+			}
+
+			return nil
+		},
+	}
+}
+
+func initUserCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   UserCommand,
+		Short: "manage user accounts",
+	}
+}
+
+func initUserAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   AddCommand,
+		Short: "add user account",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if name == "" || password == "" {
+				log.Println("quics: ", "Please enter both name and password")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/users/add"
+
+			user := &types.UserReq{
+				Name:     name,
+				Password: password,
+				Role:     role,
+			}
+
+			body, err := json.Marshal(user)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			_, err = restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initUserListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show all user accounts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/users"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			users := []types.User{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &users)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, user := range users {
+				fmt.Printf("*   Name: %s   |   Role: %s   |   Disabled: %t   *\n", user.Name, user.Role, user.Disabled)
+			}
+
+			return nil
+		},
+	}
+}
+
+func initUserDisableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   DisableCommand,
+		Short: "disable user account",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if name == "" {
+				log.Println("quics: ", "Please enter name")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/users/disable?name=" + name
+
+			restClient := NewRestClient()
+
+			_, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initMemberCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   MemberCommand,
+		Short: "manage members of root directory",
 	}
 }
+
+func initMemberInviteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   InviteCommand,
+		Short: "invite member to root directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" || member == "" {
+				log.Println("quics: ", "Please enter both path and member")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/server/members/invite"
+
+			rootMember := &types.RootMemberReq{
+				AfterPath: path,
+				Member:    member,
+				Role:      role,
+			}
+
+			body, err := json.Marshal(rootMember)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			_, err = restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initMemberRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RevokeCommand,
+		Short: "revoke member from root directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" || member == "" {
+				log.Println("quics: ", "Please enter both path and member")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/server/members/revoke"
+
+			rootMember := &types.RootMemberReq{
+				AfterPath: path,
+				Member:    member,
+			}
+
+			body, err := json.Marshal(rootMember)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			_, err = restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initClientCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ClientCommand,
+		Short: "manage client devices",
+	}
+}
+
+func initClientRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RevokeCommand,
+		Short: "revoke certificate of client",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" {
+				log.Println("quics: ", "Please enter client UUID")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/server/clients/revoke?uuid=" + neturl.QueryEscape(id)
+
+			restClient := NewRestClient()
+
+			_, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initLockoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   LockoutCommand,
+		Short: "manage lockouts of failed authentication",
+	}
+}
+
+func initLockoutListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show failed authentication attempts and lockouts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/server/lockouts"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			lockouts := []types.Lockout{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &lockouts)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, lockout := range lockouts {
+				fmt.Printf("*   Key: %s   |   Failures: %d   |   Last Failure: %s   |   Locked Until: %s   *\n", lockout.Key, lockout.Failures, lockout.LastFailure.Format(time.RFC3339), lockout.LockedUntil.Format(time.RFC3339))
+			}
+
+			return nil
+		},
+	}
+}
+
+func initLockoutClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ClearCommand,
+		Short: "clear lockout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validateOptionByCommand(lockoutClearCmd)
+
+			url := "/api/v1/server/lockouts/clear?key=" + neturl.QueryEscape(id)
+			if all {
+				url = "/api/v1/server/lockouts/clear?all=true"
+			}
+
+			restClient := NewRestClient()
+
+			_, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initCertCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   CertCommand,
+		Short: "manage TLS certificate of server",
+	}
+}
+
+func initCertShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ShowCommand,
+		Short: "show TLS certificate of server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/server/cert"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			certificateInfo := &types.CertificateInfo{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), certificateInfo)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			printCertificateInfo(certificateInfo)
+
+			return nil
+		},
+	}
+}
+
+func initCertRenewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RenewCommand,
+		Short: "renew generated TLS certificate of server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/server/cert/renew"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			certificateInfo := &types.CertificateInfo{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), certificateInfo)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			printCertificateInfo(certificateInfo)
+
+			return nil
+		},
+	}
+}
+
+func initTokenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   TokenCommand,
+		Short: "manage API tokens of rest api",
+	}
+}
+
+func initTokenCreateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   CreateCommand,
+		Short: "create API token",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if name == "" {
+				log.Println("quics: ", "Please enter name")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/tokens/create"
+
+			request := &types.APITokenReq{
+				Name:  name,
+				Scope: scope,
+			}
+
+			body, err := json.Marshal(request)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			// token is created with password, so that the first token can be created
+			restClient := NewRestClient()
+			restClient.SetToken("")
+			if password != "" {
+				restClient.SetPassword(password)
+			}
+
+			response, err := restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			apiToken := &types.APITokenRes{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), apiToken)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			fmt.Printf("*   ID: %s   |   Name: %s   |   Scope: %s   *\n", apiToken.ID, apiToken.Name, apiToken.Scope)
+			fmt.Println("*   Token: ", apiToken.Token)
+			fmt.Println("*   The token is not shown again.")
+
+			if save {
+				err = config.WriteViperEnvVariables("API_TOKEN", apiToken.Token)
+				if err != nil {
+					log.Println("quics err: ", err)
+					return err
+				}
+				fmt.Println("*   The token is saved to qis.env.")
+			}
+
+			return nil
+		},
+	}
+}
+
+func initTokenListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show all API tokens and endpoints called with them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/tokens"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			apiTokens := []types.APIToken{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &apiTokens)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, apiToken := range apiTokens {
+				fmt.Printf("*   ID: %s   |   Name: %s   |   Scope: %s   |   Created By: %s   |   Created At: %s   |   Revoked: %t   *\n", apiToken.ID, apiToken.Name, apiToken.Scope, apiToken.CreatedBy, apiToken.CreatedAt.Format(time.RFC3339), apiToken.Revoked)
+				for endpoint, usage := range apiToken.Usage {
+					fmt.Printf("*       %s   |   Count: %d   |   Last Called At: %s\n", endpoint, usage.Count, usage.LastCalledAt.Format(time.RFC3339))
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func initTokenRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RevokeCommand,
+		Short: "revoke API token",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" {
+				log.Println("quics: ", "Please enter token ID")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/tokens/revoke?id=" + neturl.QueryEscape(id)
+
+			restClient := NewRestClient()
+
+			_, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initKeyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   KeyCommand,
+		Short: "manage S3 access keys of user accounts",
+	}
+}
+
+func initKeyCreateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   CreateCommand,
+		Short: "create S3 access key of user account",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if name == "" {
+				log.Println("quics: ", "Please enter name")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/users/keys/create?name=" + neturl.QueryEscape(name)
+
+			restClient := NewRestClient()
+
+			response, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			accessKey := &types.AccessKeyRes{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), accessKey)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			fmt.Printf("*   Access Key ID: %s   |   User: %s   *\n", accessKey.AccessKeyID, accessKey.User)
+			fmt.Println("*   Secret Access Key: ", accessKey.SecretAccessKey)
+			fmt.Println("*   The secret access key is not shown again.")
+
+			return nil
+		},
+	}
+}
+
+func initKeyListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show all S3 access keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/users/keys"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			accessKeys := []types.AccessKey{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &accessKeys)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, accessKey := range accessKeys {
+				lastUsedAt := "-"
+				if !accessKey.LastUsedAt.IsZero() {
+					lastUsedAt = accessKey.LastUsedAt.Format(time.RFC3339)
+				}
+				fmt.Printf("*   Access Key ID: %s   |   User: %s   |   Created At: %s   |   Last Used At: %s   |   Revoked: %t   *\n", accessKey.ID, accessKey.User, accessKey.CreatedAt.Format(time.RFC3339), lastUsedAt, accessKey.Revoked)
+			}
+
+			return nil
+		},
+	}
+}
+
+func initKeyRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RevokeCommand,
+		Short: "revoke S3 access key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" {
+				log.Println("quics: ", "Please enter access key ID")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/users/keys/revoke?id=" + neturl.QueryEscape(id)
+
+			restClient := NewRestClient()
+
+			_, err := restClient.PostRequest(url, "application/json", nil)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initAuditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   AuditCommand,
+		Short: "show and verify audit log of administrative and sync actions",
+	}
+}
+
+func initAuditShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ShowCommand,
+		Short: "show audit log",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := neturl.Values{}
+			if since != "" {
+				query.Set("since", since)
+			}
+			if actor != "" {
+				query.Set("actor", actor)
+			}
+			if path != "" {
+				query.Set("path", path)
+			}
+			url := "/api/v1/audit?" + query.Encode()
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			auditEvents := []types.AuditEvent{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &auditEvents)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, auditEvent := range auditEvents {
+				fmt.Printf("*   %d   |   %s   |   %s   |   Actor: %s   |   IP: %s   |   Path: %s   |   %s   *\n", auditEvent.Seq, auditEvent.Timestamp.Format(time.RFC3339), auditEvent.Action, auditEvent.Actor, auditEvent.IP, auditEvent.Path, auditEvent.Detail)
+			}
+
+			return nil
+		},
+	}
+}
+
+func initAuditVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   VerifyCommand,
+		Short: "verify hash chain of audit log",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/audit/verify"
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			verifyRes := &types.AuditVerifyRes{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), verifyRes)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			if !verifyRes.Valid {
+				fmt.Printf("*   Audit log is tampered at event %d: %s   *\n", verifyRes.BrokenSeq, verifyRes.Message)
+				return errors.New("audit log is tampered")
+			}
+			fmt.Printf("*   Audit log is valid (%d events)   *\n", verifyRes.Events)
+
+			return nil
+		},
+	}
+}
+
+func initShareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ShareCommand,
+		Short: "manage sharing links",
+	}
+}
+
+func initShareDropCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   DropCommand,
+		Short: "create upload-only link which accepts files into folder of root directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" || maxCount == 0 {
+				log.Println("quics: ", "Please enter both path and max count")
+				cmd.Help()
+				return nil
+			}
+
+			expireSec := uint64(0)
+			if expire != "" {
+				duration, err := time.ParseDuration(expire)
+				if err != nil || duration <= 0 {
+					log.Println("quics: ", "Please enter expire as duration (e.g., 72h)")
+					return err
+				}
+				expireSec = uint64(duration.Seconds())
+			}
+
+			url := "/api/v1/shares/drop"
+
+			dropReq := &types.DropReq{
+				AfterPath: path,
+				MaxSize:   maxSize,
+				MaxCnt:    maxCount,
+				ExpireSec: expireSec,
+				Password:  password,
+				Note:      note,
+			}
+
+			body, err := json.Marshal(dropReq)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			response, err := restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			shareRes := &types.ShareRes{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), shareRes)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			fmt.Printf("*   ID: %s   |   Link: %s   *\n", shareRes.ID, shareRes.Link)
+
+			return nil
+		},
+	}
+}
+
+func initShareListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show sharing links and their downloads",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := neturl.Values{}
+			if owner != "" {
+				query.Set("owner", owner)
+			}
+			if path != "" {
+				query.Set("path", path)
+			}
+			url := "/api/v1/shares?" + query.Encode()
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			links := []types.ShareInfo{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &links)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, link := range links {
+				kind := "file"
+				if link.Drop {
+					kind = "drop"
+				} else if link.IsDir {
+					kind = "dir"
+				}
+				expiresAt := "never"
+				if !link.ExpiresAt.IsZero() {
+					expiresAt = link.ExpiresAt.Format(time.RFC3339)
+				}
+				lastUsedAt := "never"
+				if !link.LastUsedAt.IsZero() {
+					lastUsedAt = link.LastUsedAt.Format(time.RFC3339)
+				}
+				fmt.Printf("*   ID: %s   |   Path: %s   |   Type: %s   |   Owner: %s   |   Count: %d/%d   |   Password: %t   |   Expires At: %s   |   Last Used At: %s   *\n", link.ID, link.AfterPath, kind, link.Owner, link.Count, link.MaxCount, link.HasPassword, expiresAt, lastUsedAt)
+				for _, usage := range link.Usages {
+					fmt.Printf("*       %s   |   IP: %s   |   User Agent: %s   |   Path: %s\n", usage.Time.Format(time.RFC3339), usage.IP, usage.UserAgent, usage.Path)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func initShareRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   RevokeCommand,
+		Short: "revoke sharing links matching all of the options",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" && owner == "" && path == "" && root == "" {
+				log.Println("quics: ", "Please enter at least one of ID, owner, path and root directory")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/shares/revoke"
+
+			revokeShareReq := &types.RevokeShareReq{
+				Owner:     owner,
+				AfterPath: path,
+				RootDir:   root,
+			}
+			if id != "" {
+				revokeShareReq.IDs = []string{id}
+			}
+
+			body, err := json.Marshal(revokeShareReq)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			response, err := restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			revokeShareRes := &types.RevokeShareRes{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), revokeShareRes)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, revokedID := range revokeShareRes.IDs {
+				fmt.Printf("*   Revoked: %s   *\n", revokedID)
+			}
+			fmt.Printf("*   %d links are revoked   *\n", len(revokeShareRes.IDs))
+
+			return nil
+		},
+	}
+}
+
+func initConflictCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ConflictCommand,
+		Short: "inspect and resolve conflicted files",
+	}
+}
+
+func initConflictListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ListCommand,
+		Short: "show open conflicts of all root directories",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := "/api/v1/conflicts?root=" + neturl.QueryEscape(root)
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			conflicts := []types.ConflictInfo{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), &conflicts)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			for _, conflict := range conflicts {
+				fmt.Printf("*   Path: %s   |   Root Directory: %s   |   Latest Version: %d   |   Candidates: %d   *\n", conflict.AfterPath, conflict.RootDir, conflict.LatestSyncTimestamp, len(conflict.Candidates))
+			}
+
+			return nil
+		},
+	}
+}
+
+func initConflictShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ShowCommand,
+		Short: "show candidates of conflicted file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" {
+				log.Println("quics: ", "Please enter path")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/conflicts/show?path=" + neturl.QueryEscape(path)
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			conflict := &types.ConflictInfo{}
+			err = utils.UnmarshalRequestBody(response.Bytes(), conflict)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			fmt.Printf("*   Path: %s   |   Root Directory: %s   |   Latest Version: %d   |   Latest Hash: %s   *\n", conflict.AfterPath, conflict.RootDir, conflict.LatestSyncTimestamp, conflict.LatestHash)
+			for _, candidate := range conflict.Candidates {
+				fmt.Printf("*       Side: %s   |   Version: %d   |   Hash: %s   |   Size: %d   |   Modified At: %s   |   Uploaded: %t\n", candidate.Side, candidate.Timestamp, candidate.Hash, candidate.Size, candidate.ModTime.Format(time.RFC3339), candidate.Uploaded)
+			}
+
+			return nil
+		},
+	}
+}
+
+func initConflictDownloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   DownloadCommand,
+		Short: "download candidate of conflicted file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" || side == "" || target == "" {
+				log.Println("quics: ", "Please enter path, side and target")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/conflicts/download?path=" + neturl.QueryEscape(path) + "&side=" + neturl.QueryEscape(side)
+
+			restClient := NewRestClient()
+
+			response, err := restClient.GetRequest(url)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = os.WriteFile(target, response.Bytes(), 0644)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+func initConflictResolveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   ResolveCommand,
+		Short: "resolve conflict with candidate, clients receive it with FORCESYNC",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if path == "" || side == "" {
+				log.Println("quics: ", "Please enter both path and side")
+				cmd.Help()
+				return nil
+			}
+
+			url := "/api/v1/conflicts/resolve"
+
+			body, err := json.Marshal(&types.ConflictResolveReq{
+				AfterPath: path,
+				Side:      side,
+			})
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			restClient := NewRestClient()
+
+			_, err = restClient.PostRequest(url, "application/json", body)
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			err = restClient.Close()
+			if err != nil {
+				log.Println("quics err: ", err)
+				return err
+			}
+
+			return nil
+		},
+	}
+}
+
+// ********************************************************************************
+//                                  Private Logic
+// ********************************************************************************
+
+func validateOptionByCommand(command *cobra.Command) {
+	// page options of show commands also select records
+	paged := command.Flags().Changed(LimitOption) || command.Flags().Changed(AfterOption) || command.Flags().Changed(FilterOption)
+	if !all && id == "" && !paged {
+		log.Println("quics: ", "Please enter only one option")
+		command.Help()
+		return
+	}
+}
+
+// addPageFlags adds page, filter and sort options to show command
+func addPageFlags(command *cobra.Command) {
+	command.Flags().IntVarP(&limit, LimitOption, "", 0, "Max number of records in page (default: all records with --all, otherwise 100)")
+	command.Flags().StringVarP(&after, AfterOption, "", "", "Show page after the cursor which is shown as Next")
+	command.Flags().StringArrayVarP(&filters, FilterOption, "", []string{}, "Filter records with <root|prefix|uuid|since|conflicted>=<value> (e.g., root=/rootDir, since=24h)")
+	command.Flags().StringVarP(&sortOrder, SortOption, "", "", "Sort records by key or time (files and histories), descending with \"-\" prefix")
+}
+
+// showPages requests page of records from /api/v2 and prints each record.
+// With --id the record of idKey is shown, and with --all pages are followed to the end unless --limit is given.
+func showPages[T any](url string, idKey string, printRecord func(record *T)) error {
+	query := neturl.Values{}
+	if id != "" {
+		query.Set(idKey, id)
+	} else {
+		if limit > 0 {
+			query.Set(LimitOption, strconv.Itoa(limit))
+		}
+		if after != "" {
+			query.Set(AfterOption, after)
+		}
+		if sortOrder != "" {
+			query.Set(SortOption, sortOrder)
+		}
+		for _, filter := range filters {
+			key, value, ok := strings.Cut(filter, "=")
+			switch {
+			case !ok:
+				return errors.New("filter must be <key>=<value>: " + filter)
+			case key == "root" || key == "prefix" || key == "uuid" || key == "since" || key == "conflicted":
+				query.Set(key, value)
+			default:
+				return errors.New("filter must be one of root, prefix, uuid, since and conflicted: " + key)
+			}
+		}
+	}
+
+	restClient := NewRestClient()
+	defer restClient.Close()
+
+	for {
+		response, err := restClient.GetRequest(url + "?" + query.Encode())
+		if err != nil {
+			log.Println("quics err: ", err)
+			return err
+		}
+
+		page := &types.Page[T]{}
+		err = utils.UnmarshalRequestBody(response.Bytes(), page)
+		if err != nil {
+			log.Println("quics err: ", err)
+			return err
+		}
+		for i := range page.Items {
+			printRecord(&page.Items[i])
+		}
+
+		if page.Next == "" {
+			return nil
+		}
+		if !all || limit > 0 {
+			fmt.Printf("*   Next: %s   *\n", page.Next)
+			return nil
+		}
+		query.Set(AfterOption, page.Next)
+	}
+}
+
+func printCertificateInfo(certificateInfo *types.CertificateInfo) {
+	source := "provided"
+	if certificateInfo.Generated {
+		source = "generated"
+	}
+
+	fmt.Println("*   Subject: ", certificateInfo.Subject)
+	fmt.Println("*   Issuer: ", certificateInfo.Issuer)
+	fmt.Println("*   DNS Names: ", strings.Join(certificateInfo.DNSNames, ", "))
+	fmt.Println("*   IP Addresses: ", strings.Join(certificateInfo.IPAddresses, ", "))
+	fmt.Println("*   Not Before: ", certificateInfo.NotBefore.Format(time.RFC3339))
+	fmt.Println("*   Not After: ", certificateInfo.NotAfter.Format(time.RFC3339))
+	fmt.Println("*   Fingerprint (SHA-256): ", certificateInfo.Fingerprint)
+	fmt.Println("*   Certificate: ", certificateInfo.CertPath, " (", source, ")")
+	fmt.Println("*   Key: ", certificateInfo.KeyPath)
+}
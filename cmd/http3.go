@@ -3,9 +3,11 @@ package main
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/quic-go/quic-go"
 	http3 "github.com/quic-go/quic-go/http3"
@@ -13,6 +15,8 @@ import (
 )
 
 type RestClient struct {
+	password     string
+	token        string
 	qconf        *quic.Config
 	roundTripper *http3.RoundTripper
 	hclient      *http.Client
@@ -24,7 +28,9 @@ func NewRestClient() *RestClient {
 	}
 
 	restClient := &RestClient{
-		qconf: quicConfig,
+		password: config.GetViperEnvVariables("PASSWORD"),
+		token:    config.GetViperEnvVariables("API_TOKEN"),
+		qconf:    quicConfig,
 	}
 
 	restClient.roundTripper = &http3.RoundTripper{
@@ -44,11 +50,19 @@ func NewRestClient() *RestClient {
 func (r *RestClient) GetRequest(path string) (*bytes.Buffer, error) {
 	url := "https://" + config.GetRestServerH3Address() + path
 
-	rsp, err := r.hclient.Get(url)
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
 	}
+	r.setAuthorization(req)
+
+	rsp, err := r.hclient.Do(req)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+	defer rsp.Body.Close()
 
 	body := &bytes.Buffer{}
 	_, err = io.Copy(body, rsp.Body)
@@ -57,6 +71,12 @@ func (r *RestClient) GetRequest(path string) (*bytes.Buffer, error) {
 		return nil, err
 	}
 
+	if rsp.StatusCode >= http.StatusBadRequest {
+		err = errors.New(rsp.Status + ": " + strings.TrimSpace(body.String()))
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+
 	return body, nil
 }
 
@@ -64,26 +84,57 @@ func (r *RestClient) PostRequest(path string, contentType string, content []byte
 	url := "https://" + config.GetRestServerH3Address() + path
 
 	contentReader := bytes.NewReader(content)
-	rsp, err := r.hclient.Post(url, contentType, contentReader)
+	req, err := http.NewRequest("POST", url, contentReader)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	r.setAuthorization(req)
+
+	rsp, err := r.hclient.Do(req)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
 	}
 
-	buf := make([]byte, rsp.ContentLength)
-	_, err = rsp.Body.Read(buf)
-	if err != nil && err != io.EOF {
+	defer rsp.Body.Close()
+
+	body := &bytes.Buffer{}
+	_, err = io.Copy(body, rsp.Body)
+	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
 	}
 
-	if string(buf) != "" {
-		log.Println("quis: ", string(buf))
-	} else {
-		log.Println("quis: ", "Success")
+	if rsp.StatusCode >= http.StatusBadRequest {
+		err = errors.New(rsp.Status + ": " + strings.TrimSpace(body.String()))
+		log.Println("quics err: ", err)
+		return nil, err
 	}
+	log.Println("quis: ", "Success")
+
+	return body, nil
+}
+
+// SetPassword sets server password which is sent with requests when API token is not set
+func (r *RestClient) SetPassword(password string) {
+	r.password = password
+}
 
-	return nil, nil
+// SetToken sets API token which is sent with requests as bearer token
+func (r *RestClient) SetToken(token string) {
+	r.token = token
+}
+
+// setAuthorization sets API token (API_TOKEN in qis.env) as bearer token,
+// or server password as basic auth if API token is not set
+func (r *RestClient) setAuthorization(req *http.Request) {
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+		return
+	}
+	req.SetBasicAuth("admin", r.password)
 }
 
 func (r *RestClient) Close() error {
@@ -1,7 +1,7 @@
 package app
 
 import (
-	"crypto/tls"
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -10,24 +10,38 @@ import (
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-s/quics/pkg/ca"
 	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/server"
-	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/core/token"
+	"github.com/quic-s/quics/pkg/core/user"
 	"github.com/quic-s/quics/pkg/fs"
+	"github.com/quic-s/quics/pkg/metrics"
 	quicshttp "github.com/quic-s/quics/pkg/network/http"
+	quicss3 "github.com/quic-s/quics/pkg/network/s3"
+	quicssftp "github.com/quic-s/quics/pkg/network/sftp"
+	quicswebdav "github.com/quic-s/quics/pkg/network/webdav"
 	"github.com/quic-s/quics/pkg/repository/badger"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type App struct {
-	certFileDir   string
-	keyFileDir    string
-	serverService server.Service
-	entryServer   *http.Server
-	restServer    *http3.Server
+	identity        *ca.Identity
+	serverService   server.Service
+	shutdownTrace   func(context.Context) error
+	shutdownMetrics func(context.Context) error
+	entryServer     *http.Server
+	restServer      *http3.Server
+	sftpServer      *quicssftp.Server
+	s3Handler       *quicss3.Handler
+	s3Server        *http.Server
 }
 
 // New initialize program
@@ -38,6 +52,18 @@ func New(ip string, port string, port3 string) (*App, error) {
 		return nil, err
 	}
 
+	shutdownTrace, err := tracing.Init()
+	if err != nil {
+		err = errors.New("[App.New] initializing tracing: " + err.Error())
+		return nil, err
+	}
+
+	shutdownMetrics, err := metrics.Init()
+	if err != nil {
+		err = errors.New("[App.New] initializing metrics: " + err.Error())
+		return nil, err
+	}
+
 	repo, err := badger.NewBadgerRepository()
 	if err != nil {
 		err = errors.New("[App.New] initializing badger repository: " + err.Error())
@@ -45,60 +71,103 @@ func New(ip string, port string, port3 string) (*App, error) {
 	}
 
 	serverRepository := repo.NewServerRepository()
-	historyRepository := repo.NewHistoryRepository()
-	syncRepository := repo.NewSyncRepository()
-	sharingRepository := repo.NewSharingRepository()
+	userRepository := repo.NewUserRepository()
+	tokenRepository := repo.NewTokenRepository()
+	auditRepository := repo.NewAuditRepository()
 
 	syncDirAdapter := fs.NewSyncDir(utils.GetQuicsSyncDirPath())
 
-	serverService, err := server.NewService(repo, serverRepository, syncDirAdapter)
+	// load TLS identity shared by rest server and quics-protocol server
+	authority, err := ca.Load()
+	if err != nil {
+		err = errors.New("[App.New] loading certificate authority: " + err.Error())
+		return nil, err
+	}
+	identity, err := ca.LoadIdentity(authority)
+	if err != nil {
+		err = errors.New("[App.New] loading TLS identity: " + err.Error())
+		return nil, err
+	}
+	err = identity.Watch()
+	if err != nil {
+		err = errors.New("[App.New] watching TLS identity: " + err.Error())
+		return nil, err
+	}
+
+	auditService := audit.NewService(auditRepository)
+
+	serverService, err := server.NewService(repo, serverRepository, syncDirAdapter, authority, identity, auditService)
 	if err != nil {
 		err = errors.New("[App.New] initializing server service: " + err.Error())
 		return nil, err
 	}
 
-	sharingService := sharing.NewService(historyRepository, syncRepository, sharingRepository, syncDirAdapter)
+	sharingService := serverService.GetSharingService()
+	userService := user.NewService(userRepository)
+	tokenService := token.NewService(tokenRepository)
 
-	serverHandler := quicshttp.NewServerHandler(serverService)
-	sharingHandler := quicshttp.NewSharingHandler(sharingService)
+	auth := quicshttp.NewAuthenticator(serverService, userService, tokenService, auditService)
+	serverHandler := quicshttp.NewServerHandler(serverService, auth)
+	sharingHandler := quicshttp.NewSharingHandler(sharingService, auth)
+	userHandler := quicshttp.NewUserHandler(serverService, userService, auth)
+	tokenHandler := quicshttp.NewTokenHandler(tokenService, auth)
+	auditHandler := quicshttp.NewAuditHandler(auditService, auth)
+	syncHandler := quicshttp.NewSyncHandler(serverService.GetSyncService(), auth)
+	apiHandler := quicshttp.NewAPIHandler(serverService, userService, tokenService, auditService, auth)
+	dashboardHandler := quicshttp.NewDashboardHandler()
+	webdavHandler := quicswebdav.NewHandler(serverService.GetSyncService(), userService, serverService.GetLockoutService(), utils.GetQuicsSyncDirPath())
 
 	mux := http.NewServeMux()
 	serverHandler.SetupRoutes(mux)
 	sharingHandler.SetupRoutes(mux)
+	userHandler.SetupRoutes(mux)
+	tokenHandler.SetupRoutes(mux)
+	auditHandler.SetupRoutes(mux)
+	syncHandler.SetupRoutes(mux)
+	apiHandler.SetupRoutes(mux)
+	dashboardHandler.SetupRoutes(mux)
+	webdavHandler.SetupRoutes(mux)
+	handler := otelhttp.NewHandler(mux, "quics.rest")
 
 	restServer := &http3.Server{
 		Addr:       "0.0.0.0:" + config.GetViperEnvVariables("REST_SERVER_H3_PORT"),
+		TLSConfig:  http3.ConfigureTLSConfig(identity.TLSConfig()),
 		QuicConfig: &quic.Config{},
-		Handler:    mux,
+		Handler:    handler,
 	}
 
-	// get directory path for certification
-	quicsDir := utils.GetQuicsDirPath()
-	certFileDir := filepath.Join(quicsDir, config.GetViperEnvVariables("QUICS_CERT_NAME"))
-	keyFileDir := filepath.Join(quicsDir, config.GetViperEnvVariables("QUICS_KEY_NAME"))
-
-	// load the certificate and the key from the files
-	_, err = tls.LoadX509KeyPair(certFileDir, keyFileDir)
+	sftpHostKeyPath := filepath.Join(utils.GetQuicsDirPath(), getEnvOrDefault("SFTP_HOST_KEY_NAME", config.DefaultSFTPHostKeyName))
+	sftpServer, err := quicssftp.NewServer(serverService.GetSyncService(), userService, serverService.GetLockoutService(), utils.GetQuicsSyncDirPath(), sftpHostKeyPath)
 	if err != nil {
-		err = config.CreateSecurityFiles()
-		if err != nil {
-			err = errors.New("[App.New] creating security files: " + err.Error())
-			return nil, err
-		}
+		err = errors.New("[App.New] initializing sftp server: " + err.Error())
+		return nil, err
+	}
+
+	// S3 API is served on its own port, because S3 clients address buckets at the root path
+	s3Handler := quicss3.NewHandler(serverService.GetSyncService(), serverService.GetHistoryService(), userService, syncDirAdapter, filepath.Join(utils.GetQuicsDirPath(), "s3-uploads"))
+	s3Server := &http.Server{
+		Addr:      "0.0.0.0:" + getEnvOrDefault("S3_PORT", config.DefaultS3Port),
+		TLSConfig: identity.TLSConfig(),
+		Handler:   otelhttp.NewHandler(s3Handler, "quics.s3"),
 	}
 
 	// set legacy http for first connection
 	entryServer := &http.Server{
-		Addr:    "0.0.0.0:" + config.GetViperEnvVariables("REST_SERVER_PORT"),
-		Handler: mux,
+		Addr:      "0.0.0.0:" + config.GetViperEnvVariables("REST_SERVER_PORT"),
+		TLSConfig: identity.TLSConfig(),
+		Handler:   handler,
 	}
 
 	return &App{
-		certFileDir:   certFileDir,
-		keyFileDir:    keyFileDir,
-		serverService: serverService,
-		entryServer:   entryServer,
-		restServer:    restServer,
+		identity:        identity,
+		serverService:   serverService,
+		shutdownTrace:   shutdownTrace,
+		shutdownMetrics: shutdownMetrics,
+		entryServer:     entryServer,
+		restServer:      restServer,
+		sftpServer:      sftpServer,
+		s3Handler:       s3Handler,
+		s3Server:        s3Server,
 	}, nil
 }
 
@@ -107,13 +176,13 @@ func (a *App) StartRestServer() error {
 	fmt.Println("                   Start Rest Server                        ")
 	fmt.Println("************************************************************")
 	go func() {
-		err := a.entryServer.ListenAndServeTLS(a.certFileDir, a.keyFileDir)
+		err := a.entryServer.ListenAndServeTLS("", "")
 		if err != nil {
 			err = errors.New("[App.Start] starting rest server: " + err.Error())
 			log.Fatalln("quics err: ", err)
 		}
 	}()
-	err := a.restServer.ListenAndServeTLS(a.certFileDir, a.keyFileDir)
+	err := a.restServer.ListenAndServe()
 	if err != nil {
 		err = errors.New("[App.Start] starting rest server: " + err.Error())
 		log.Fatalln("quics err: ", err)
@@ -123,8 +192,48 @@ func (a *App) StartRestServer() error {
 	return nil
 }
 
+func (a *App) StartSFTPServer() error {
+	fmt.Println("************************************************************")
+	fmt.Println("                   Start SFTP Server                        ")
+	fmt.Println("************************************************************")
+	err := a.sftpServer.ListenAndServe("0.0.0.0:" + getEnvOrDefault("SFTP_PORT", config.DefaultSFTPPort))
+	if err != nil {
+		err = errors.New("[App.StartSFTPServer] starting sftp server: " + err.Error())
+		log.Fatalln("quics err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (a *App) StartS3Server() error {
+	fmt.Println("************************************************************")
+	fmt.Println("                   Start S3 Server                          ")
+	fmt.Println("************************************************************")
+
+	// multipart uploads which are not completed nor aborted are removed after a week
+	go func() {
+		for {
+			err := a.s3Handler.CleanupUploads(7 * 24 * time.Hour)
+			if err != nil {
+				log.Println("quics err: ", err)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	err := a.s3Server.ListenAndServeTLS("", "")
+	if err != nil && err != http.ErrServerClosed {
+		err = errors.New("[App.StartS3Server] starting s3 server: " + err.Error())
+		log.Fatalln("quics err: ", err)
+		return err
+	}
+	return nil
+}
+
 func (a *App) Run() error {
 	go a.StartRestServer()
+	go a.StartSFTPServer()
+	go a.StartS3Server()
 	err := a.serverService.ListenProtocol()
 	if err != nil {
 		err = errors.New("[App.Run] listening protocol: " + err.Error())
@@ -149,6 +258,30 @@ func (a *App) Close() error {
 	<-interruptCh
 	a.serverService.StopServer()
 
+	err := a.sftpServer.Close()
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+	err = a.s3Server.Close()
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+
+	err = a.identity.Close()
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+
+	// flush remaining spans before exit
+	err = a.shutdownTrace(context.Background())
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+	err = a.shutdownMetrics(context.Background())
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+
 	fmt.Println("************************************************************")
 	fmt.Println("                           Close                            ")
 	fmt.Println("************************************************************")
@@ -160,3 +293,12 @@ func (a *App) Close() error {
 func (a *App) Stop() error {
 	return nil
 }
+
+// getEnvOrDefault returns default value for env variables which are added after qis.env is created
+func getEnvOrDefault(key string, defaultValue string) string {
+	value := config.GetViperEnvVariables(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,233 @@
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+const (
+	caValidity     = 10 * 365 * 24 * time.Hour
+	clientValidity = 5 * 365 * 24 * time.Hour
+)
+
+// CA is the certificate authority of quics server.
+// It issues the certificate of quics-protocol server and client devices.
+type CA struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// Load loads CA certificate and key in .quics directory,
+// they are created when they do not exist
+func Load() (*CA, error) {
+	certPath, keyPath := getPaths()
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		return create(certPath, keyPath)
+	}
+	if certErr != nil {
+		return nil, errors.New("[ca.Load] read CA certificate: " + certErr.Error())
+	}
+	if keyErr != nil {
+		return nil, errors.New("[ca.Load] read CA key: " + keyErr.Error())
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("[ca.Load] CA certificate is not PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, errors.New("[ca.Load] parse CA certificate: " + err.Error())
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("[ca.Load] CA key is not PEM")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, errors.New("[ca.Load] parse CA key: " + err.Error())
+	}
+
+	return &CA{
+		cert:    cert,
+		certPEM: certPEM,
+		key:     key,
+	}, nil
+}
+
+// CertPEM returns CA certificate in PEM format, clients pin it to verify server
+func (ca *CA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// CertPool returns cert pool which only has CA certificate
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// IssueClientCertificate issues certificate of client device, UUID is used as common name.
+// If csrPEM is empty, key pair is generated by server and returned with certificate.
+func (ca *CA) IssueClientCertificate(uuid string, csrPEM []byte) (certPEM []byte, keyPEM []byte, fingerprint string, err error) {
+	var publicKey any
+	if len(csrPEM) > 0 {
+		block, _ := pem.Decode(csrPEM)
+		if block == nil {
+			return nil, nil, "", errors.New("[CA.IssueClientCertificate] certificate request is not PEM")
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, nil, "", errors.New("[CA.IssueClientCertificate] parse certificate request: " + err.Error())
+		}
+		if err := csr.CheckSignature(); err != nil {
+			return nil, nil, "", errors.New("[CA.IssueClientCertificate] check certificate request: " + err.Error())
+		}
+		publicKey = csr.PublicKey
+	} else {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, "", errors.New("[CA.IssueClientCertificate] generate key: " + err.Error())
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, "", errors.New("[CA.IssueClientCertificate] marshal key: " + err.Error())
+		}
+		keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+		publicKey = &key.PublicKey
+	}
+
+	certDER, err := ca.issue(publicKey, uuid, clientValidity, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, nil)
+	if err != nil {
+		return nil, nil, "", errors.New("[CA.IssueClientCertificate] " + err.Error())
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	return certPEM, keyPEM, Fingerprint(certDER), nil
+}
+
+// Fingerprint returns SHA-256 fingerprint of DER encoded certificate
+func Fingerprint(certDER []byte) string {
+	sum := sha256.Sum256(certDER)
+	return hex.EncodeToString(sum[:])
+}
+
+// issue issues certificate of publicKey signed by CA, hosts (DNS names or IP addresses) are set to SANs
+func (ca *CA) issue(publicKey any, commonName string, validity time.Duration, extKeyUsage []x509.ExtKeyUsage, hosts []string) ([]byte, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.ExtKeyUsage = extKeyUsage
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !ip.IsUnspecified() {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, publicKey, ca.key)
+	if err != nil {
+		return nil, errors.New("create certificate: " + err.Error())
+	}
+	return certDER, nil
+}
+
+// create creates new CA and writes it to the files
+func create(certPath string, keyPath string) (*CA, error) {
+	log.Println("quics: create certificate authority")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.New("[ca.create] generate key: " + err.Error())
+	}
+
+	template, err := newTemplate("quics-ca", caValidity)
+	if err != nil {
+		return nil, errors.New("[ca.create] " + err.Error())
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errors.New("[ca.create] create certificate: " + err.Error())
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, errors.New("[ca.create] parse certificate: " + err.Error())
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.New("[ca.create] marshal key: " + err.Error())
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return nil, errors.New("[ca.create] write CA key: " + err.Error())
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return nil, errors.New("[ca.create] write CA certificate: " + err.Error())
+	}
+
+	return &CA{
+		cert:    cert,
+		certPEM: certPEM,
+		key:     key,
+	}, nil
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.New("generate serial number: " + err.Error())
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"quics"},
+			CommonName:   commonName,
+		},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{},
+	}, nil
+}
+
+func getPaths() (string, string) {
+	quicsDir := utils.GetQuicsDirPath()
+	certPath := filepath.Join(quicsDir, getEnvOrDefault("QUICS_CA_CERT_NAME", config.DefaultQuicsCACertName))
+	keyPath := filepath.Join(quicsDir, getEnvOrDefault("QUICS_CA_KEY_NAME", config.DefaultQuicsCAKeyName))
+	return certPath, keyPath
+}
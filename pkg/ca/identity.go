@@ -0,0 +1,278 @@
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"golang.org/x/exp/slices"
+)
+
+// generated certificate is renewed when it expires within this period
+const renewBefore = 30 * 24 * time.Hour
+
+// Identity is the TLS identity shared by REST server and quics-protocol server.
+// Certificate is either provided by operator (TLS_CERT_PATH, TLS_KEY_PATH)
+// or generated with ECDSA key and issued by server CA.
+type Identity struct {
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	leaf      *x509.Certificate
+	certPath  string
+	keyPath   string
+	generated bool
+	authority *CA
+	watcher   *fsnotify.Watcher
+}
+
+// LoadIdentity loads TLS identity, generated certificate is (re)issued when it does not exist,
+// is not issued by authority or expires soon
+func LoadIdentity(authority *CA) (*Identity, error) {
+	identity := &Identity{
+		authority: authority,
+	}
+
+	identity.certPath = config.GetViperEnvVariables("TLS_CERT_PATH")
+	identity.keyPath = config.GetViperEnvVariables("TLS_KEY_PATH")
+	if identity.certPath == "" || identity.keyPath == "" {
+		quicsDir := utils.GetQuicsDirPath()
+		identity.certPath = filepath.Join(quicsDir, getEnvOrDefault("QUICS_CERT_NAME", config.DefaultQuicsCertName))
+		identity.keyPath = filepath.Join(quicsDir, getEnvOrDefault("QUICS_KEY_NAME", config.DefaultQuicsKeyName))
+		identity.generated = true
+	}
+
+	err := identity.reload()
+	if identity.generated && (err != nil || !identity.isValid()) {
+		return identity, identity.Renew()
+	}
+	if err != nil {
+		return nil, errors.New("[ca.LoadIdentity] " + err.Error())
+	}
+	if time.Until(identity.leaf.NotAfter) < renewBefore {
+		log.Println("quics: TLS certificate expires at ", identity.leaf.NotAfter)
+	}
+
+	return identity, nil
+}
+
+// GetCertificate returns current certificate, it is used in tls.Config
+func (i *Identity) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.cert, nil
+}
+
+// TLSConfig returns tls config which serves current certificate
+func (i *Identity) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: i.GetCertificate,
+	}
+}
+
+// Renew issues new certificate with server CA and writes it to the files.
+// Operator-provided certificate can not be renewed.
+func (i *Identity) Renew() error {
+	if !i.generated {
+		return errors.New("[Identity.Renew] TLS certificate is provided by operator (TLS_CERT_PATH)")
+	}
+	log.Println("quics: issue TLS certificate for ", getHosts())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.New("[Identity.Renew] generate key: " + err.Error())
+	}
+
+	certDER, err := i.authority.issue(&key.PublicKey, "quics-server", getValidity(), []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, getHosts())
+	if err != nil {
+		return errors.New("[Identity.Renew] " + err.Error())
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.New("[Identity.Renew] marshal key: " + err.Error())
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	certPEM = append(certPEM, i.authority.CertPEM()...)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(i.keyPath, keyPEM, 0600); err != nil {
+		return errors.New("[Identity.Renew] write key: " + err.Error())
+	}
+	if err := os.WriteFile(i.certPath, certPEM, 0644); err != nil {
+		return errors.New("[Identity.Renew] write certificate: " + err.Error())
+	}
+
+	return i.reload()
+}
+
+// Watch reloads certificate when the certificate or key file is changed
+func (i *Identity) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.New("[Identity.Watch] create watcher: " + err.Error())
+	}
+
+	// directories are watched because files can be replaced (e.g., renamed by cert-manager)
+	dirs := map[string]bool{filepath.Dir(i.certPath): true, filepath.Dir(i.keyPath): true}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return errors.New("[Identity.Watch] watch " + dir + ": " + err.Error())
+		}
+	}
+	i.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Name != i.certPath && event.Name != i.keyPath {
+					continue
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+
+				// key and certificate may not be written together, keep current one until both are valid
+				if err := i.reload(); err != nil {
+					log.Println("quics: TLS certificate is not reloaded: ", err)
+					continue
+				}
+				log.Println("quics: TLS certificate is reloaded")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Println("quics err: ", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops watching certificate files
+func (i *Identity) Close() error {
+	if i.watcher == nil {
+		return nil
+	}
+	return i.watcher.Close()
+}
+
+// Info returns information of current certificate
+func (i *Identity) Info() *types.CertificateInfo {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ipAddresses := []string{}
+	for _, ip := range i.leaf.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+
+	return &types.CertificateInfo{
+		Subject:     i.leaf.Subject.String(),
+		Issuer:      i.leaf.Issuer.String(),
+		DNSNames:    i.leaf.DNSNames,
+		IPAddresses: ipAddresses,
+		NotBefore:   i.leaf.NotBefore,
+		NotAfter:    i.leaf.NotAfter,
+		Fingerprint: Fingerprint(i.leaf.Raw),
+		CertPath:    i.certPath,
+		KeyPath:     i.keyPath,
+		Generated:   i.generated,
+	}
+}
+
+// reload loads certificate and key from the files
+func (i *Identity) reload() error {
+	cert, err := tls.LoadX509KeyPair(i.certPath, i.keyPath)
+	if err != nil {
+		return errors.New("load key pair: " + err.Error())
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return errors.New("parse certificate: " + err.Error())
+	}
+	cert.Leaf = leaf
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.cert = &cert
+	i.leaf = leaf
+
+	return nil
+}
+
+// isValid checks generated certificate is issued by server CA for configured hosts and does not expire soon
+func (i *Identity) isValid() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if time.Until(i.leaf.NotAfter) < renewBefore {
+		return false
+	}
+	_, err := i.leaf.Verify(x509.VerifyOptions{Roots: i.authority.CertPool()})
+	if err != nil {
+		return false
+	}
+	for _, host := range getHosts() {
+		if i.leaf.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getHosts returns host names (SANs) of generated certificate
+func getHosts() []string {
+	hosts := []string{}
+	for _, host := range strings.Split(getEnvOrDefault("TLS_HOSTS", config.DefaultTLSHosts), ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	restServerAddr := config.GetViperEnvVariables("REST_SERVER_ADDR")
+	if restServerAddr != "" && !slices.Contains(hosts, restServerAddr) {
+		hosts = append(hosts, restServerAddr)
+	}
+	return hosts
+}
+
+func getValidity() time.Duration {
+	days, err := strconv.Atoi(getEnvOrDefault("TLS_VALIDITY_DAYS", config.DefaultTLSValidityDays))
+	if err != nil || days <= 0 {
+		days, _ = strconv.Atoi(config.DefaultTLSValidityDays)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	value := config.GetViperEnvVariables(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
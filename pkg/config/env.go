@@ -17,10 +17,36 @@ const (
 
 	DefaultQuicsPort = "6122"
 
+	DefaultSFTPPort        = "6123"
+	DefaultSFTPHostKeyName = "ssh-host-key-quics.pem"
+
+	DefaultS3Port = "6124"
+
 	DefaultPassword = "quics"
 
 	DefaultQuicsCertName = "cert-quics.pem"
 	DefaultQuicsKeyName  = "key-quics.pem"
+
+	DefaultTLSHosts        = "localhost,127.0.0.1"
+	DefaultTLSValidityDays = "365"
+
+	DefaultQuicsCACertName = "ca-quics.pem"
+	DefaultQuicsCAKeyName  = "ca-key-quics.pem"
+
+	DefaultTracingExporter     = "none"
+	DefaultTracingOTLPEndpoint = "localhost:4318"
+	DefaultTracingFileName     = "traces.json"
+
+	DefaultMetricsExporter     = "none"
+	DefaultMetricsOTLPEndpoint = "localhost:4318"
+	DefaultMetricsFileName     = "metrics.json"
+
+	DefaultReadyMinFreeDiskMB = "100"
+
+	DefaultLockoutThreshold = "5"
+	DefaultLockoutDuration  = "15m"
+
+	DefaultHeartbeatTimeout = "5m"
 )
 
 func init() {
@@ -67,6 +93,21 @@ func init() {
 		} else {
 			sourceViper.Set("QUICS_PORT", DefaultQuicsPort)
 		}
+		if sftpPort := os.Getenv("SFTP_PORT"); sftpPort != "" {
+			sourceViper.Set("SFTP_PORT", sftpPort)
+		} else {
+			sourceViper.Set("SFTP_PORT", DefaultSFTPPort)
+		}
+		if sftpHostKeyName := os.Getenv("SFTP_HOST_KEY_NAME"); sftpHostKeyName != "" {
+			sourceViper.Set("SFTP_HOST_KEY_NAME", sftpHostKeyName)
+		} else {
+			sourceViper.Set("SFTP_HOST_KEY_NAME", DefaultSFTPHostKeyName)
+		}
+		if s3Port := os.Getenv("S3_PORT"); s3Port != "" {
+			sourceViper.Set("S3_PORT", s3Port)
+		} else {
+			sourceViper.Set("S3_PORT", DefaultS3Port)
+		}
 		if password := os.Getenv("PASSWORD"); password != "" {
 			sourceViper.Set("PASSWORD", password)
 		} else {
@@ -82,6 +123,83 @@ func init() {
 		} else {
 			sourceViper.Set("QUICS_KEY_NAME", DefaultQuicsKeyName)
 		}
+		if tlsCertPath := os.Getenv("TLS_CERT_PATH"); tlsCertPath != "" {
+			sourceViper.Set("TLS_CERT_PATH", tlsCertPath)
+		}
+		if tlsKeyPath := os.Getenv("TLS_KEY_PATH"); tlsKeyPath != "" {
+			sourceViper.Set("TLS_KEY_PATH", tlsKeyPath)
+		}
+		if tlsHosts := os.Getenv("TLS_HOSTS"); tlsHosts != "" {
+			sourceViper.Set("TLS_HOSTS", tlsHosts)
+		} else {
+			sourceViper.Set("TLS_HOSTS", DefaultTLSHosts)
+		}
+		if tlsValidityDays := os.Getenv("TLS_VALIDITY_DAYS"); tlsValidityDays != "" {
+			sourceViper.Set("TLS_VALIDITY_DAYS", tlsValidityDays)
+		} else {
+			sourceViper.Set("TLS_VALIDITY_DAYS", DefaultTLSValidityDays)
+		}
+		if quicsCACertName := os.Getenv("QUICS_CA_CERT_NAME"); quicsCACertName != "" {
+			sourceViper.Set("QUICS_CA_CERT_NAME", quicsCACertName)
+		} else {
+			sourceViper.Set("QUICS_CA_CERT_NAME", DefaultQuicsCACertName)
+		}
+		if quicsCAKeyName := os.Getenv("QUICS_CA_KEY_NAME"); quicsCAKeyName != "" {
+			sourceViper.Set("QUICS_CA_KEY_NAME", quicsCAKeyName)
+		} else {
+			sourceViper.Set("QUICS_CA_KEY_NAME", DefaultQuicsCAKeyName)
+		}
+		if tracingExporter := os.Getenv("TRACING_EXPORTER"); tracingExporter != "" {
+			sourceViper.Set("TRACING_EXPORTER", tracingExporter)
+		} else {
+			sourceViper.Set("TRACING_EXPORTER", DefaultTracingExporter)
+		}
+		if tracingOTLPEndpoint := os.Getenv("TRACING_OTLP_ENDPOINT"); tracingOTLPEndpoint != "" {
+			sourceViper.Set("TRACING_OTLP_ENDPOINT", tracingOTLPEndpoint)
+		} else {
+			sourceViper.Set("TRACING_OTLP_ENDPOINT", DefaultTracingOTLPEndpoint)
+		}
+		if tracingFilePath := os.Getenv("TRACING_FILE_PATH"); tracingFilePath != "" {
+			sourceViper.Set("TRACING_FILE_PATH", tracingFilePath)
+		} else {
+			sourceViper.Set("TRACING_FILE_PATH", filepath.Join(utils.GetQuicsDirPath(), DefaultTracingFileName))
+		}
+		if metricsExporter := os.Getenv("METRICS_EXPORTER"); metricsExporter != "" {
+			sourceViper.Set("METRICS_EXPORTER", metricsExporter)
+		} else {
+			sourceViper.Set("METRICS_EXPORTER", DefaultMetricsExporter)
+		}
+		if metricsOTLPEndpoint := os.Getenv("METRICS_OTLP_ENDPOINT"); metricsOTLPEndpoint != "" {
+			sourceViper.Set("METRICS_OTLP_ENDPOINT", metricsOTLPEndpoint)
+		} else {
+			sourceViper.Set("METRICS_OTLP_ENDPOINT", DefaultMetricsOTLPEndpoint)
+		}
+		if metricsFilePath := os.Getenv("METRICS_FILE_PATH"); metricsFilePath != "" {
+			sourceViper.Set("METRICS_FILE_PATH", metricsFilePath)
+		} else {
+			sourceViper.Set("METRICS_FILE_PATH", filepath.Join(utils.GetQuicsDirPath(), DefaultMetricsFileName))
+		}
+		if readyMinFreeDiskMB := os.Getenv("READY_MIN_FREE_DISK_MB"); readyMinFreeDiskMB != "" {
+			sourceViper.Set("READY_MIN_FREE_DISK_MB", readyMinFreeDiskMB)
+		} else {
+			sourceViper.Set("READY_MIN_FREE_DISK_MB", DefaultReadyMinFreeDiskMB)
+		}
+		if lockoutThreshold := os.Getenv("LOCKOUT_THRESHOLD"); lockoutThreshold != "" {
+			sourceViper.Set("LOCKOUT_THRESHOLD", lockoutThreshold)
+		} else {
+			sourceViper.Set("LOCKOUT_THRESHOLD", DefaultLockoutThreshold)
+		}
+		if lockoutDuration := os.Getenv("LOCKOUT_DURATION"); lockoutDuration != "" {
+			sourceViper.Set("LOCKOUT_DURATION", lockoutDuration)
+		} else {
+			sourceViper.Set("LOCKOUT_DURATION", DefaultLockoutDuration)
+		}
+
+		if heartbeatTimeout := os.Getenv("HEARTBEAT_TIMEOUT"); heartbeatTimeout != "" {
+			sourceViper.Set("HEARTBEAT_TIMEOUT", heartbeatTimeout)
+		} else {
+			sourceViper.Set("HEARTBEAT_TIMEOUT", DefaultHeartbeatTimeout)
+		}
 
 		if err := sourceViper.WriteConfigAs(envPath); err != nil {
 			log.Fatalln("quics err: ", err)
@@ -2,6 +2,10 @@ package config
 
 import "errors"
 
+// Version is the version of quics server
+// it can be overridden with -ldflags "-X github.com/quic-s/quics/pkg/config.Version=..."
+var Version = "0.1.0"
+
 func GetRestServerAddress() string {
 	serverIP := GetViperEnvVariables("REST_SERVER_ADDR") + ":"
 	serverPort := GetViperEnvVariables("REST_SERVER_PORT")
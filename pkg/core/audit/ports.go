@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type Repository interface {
+	SaveEvent(ctx context.Context, auditEvent *types.AuditEvent) error
+	GetLastEvent(ctx context.Context) (*types.AuditEvent, error)
+	GetAllEvents(ctx context.Context) ([]types.AuditEvent, error)
+	ErrKeyNotFound() error
+}
+
+type Service interface {
+	Record(ctx context.Context, action string, actor string, path string, detail string)
+	GetEvents(ctx context.Context, since time.Time, actor string, path string) ([]types.AuditEvent, error)
+	Verify(ctx context.Context) (*types.AuditVerifyRes, error)
+	Subscribe(ctx context.Context) <-chan types.AuditEvent
+}
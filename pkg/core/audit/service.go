@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type sourceIPKey struct{}
+
+// WithSourceIP returns context which has IP address of requester, it is recorded with audit events
+func WithSourceIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, sourceIPKey{}, ip)
+}
+
+// SourceIP returns IP address of requester in context, empty if it is not set
+func SourceIP(ctx context.Context) string {
+	ip, _ := ctx.Value(sourceIPKey{}).(string)
+	return ip
+}
+
+// subscriberBufferSize is the number of events which can wait for slow subscriber
+const subscriberBufferSize = 64
+
+type AuditService struct {
+	mu              sync.Mutex
+	auditRepository Repository
+	last            *types.AuditEvent
+	subscribers     map[chan types.AuditEvent]struct{}
+}
+
+func NewService(auditRepository Repository) Service {
+	return &AuditService{
+		auditRepository: auditRepository,
+		subscribers:     map[chan types.AuditEvent]struct{}{},
+	}
+}
+
+// Record appends audit event which is chained to the previous event.
+// Failure of audit log does not fail the action, it is only logged.
+func (as *AuditService) Record(ctx context.Context, action string, actor string, path string, detail string) {
+	ctx, span := tracing.Start(ctx, "AuditService.Record")
+	defer span.End()
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.last == nil {
+		last, err := as.auditRepository.GetLastEvent(ctx)
+		if err != nil && err != as.auditRepository.ErrKeyNotFound() {
+			log.Println("quics err: [AuditService.Record] get last event: ", err)
+			return
+		}
+		if last == nil {
+			last = &types.AuditEvent{}
+		}
+		as.last = last
+	}
+
+	auditEvent := &types.AuditEvent{
+		Seq:       as.last.Seq + 1,
+		Timestamp: time.Now(),
+		Action:    action,
+		Actor:     actor,
+		IP:        SourceIP(ctx),
+		Path:      path,
+		Detail:    detail,
+		PrevHash:  as.last.Hash,
+	}
+	auditEvent.Hash = hashEvent(auditEvent)
+
+	err := as.auditRepository.SaveEvent(ctx, auditEvent)
+	if err != nil {
+		log.Println("quics err: [AuditService.Record] save event: ", err)
+		return
+	}
+	as.last = auditEvent
+
+	for subscriber := range as.subscribers {
+		select {
+		case subscriber <- *auditEvent:
+		default:
+			// recording is not blocked by slow subscriber
+		}
+	}
+}
+
+// Subscribe returns channel which receives events recorded after it is called, it is closed when ctx is done.
+// Events are dropped for subscriber which does not receive them in time.
+func (as *AuditService) Subscribe(ctx context.Context) <-chan types.AuditEvent {
+	subscriber := make(chan types.AuditEvent, subscriberBufferSize)
+
+	as.mu.Lock()
+	as.subscribers[subscriber] = struct{}{}
+	as.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		as.mu.Lock()
+		delete(as.subscribers, subscriber)
+		close(subscriber)
+		as.mu.Unlock()
+	}()
+
+	return subscriber
+}
+
+// GetEvents gets audit events after since, filtered by actor and path prefix if they are not empty
+func (as *AuditService) GetEvents(ctx context.Context, since time.Time, actor string, path string) ([]types.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetEvents")
+	defer span.End()
+
+	auditEvents, err := as.auditRepository.GetAllEvents(ctx)
+	if err != nil {
+		return nil, errors.New("[AuditService.GetEvents] get all events: " + err.Error())
+	}
+
+	result := []types.AuditEvent{}
+	for _, auditEvent := range auditEvents {
+		if auditEvent.Timestamp.Before(since) {
+			continue
+		}
+		if actor != "" && auditEvent.Actor != actor {
+			continue
+		}
+		if path != "" && !strings.HasPrefix(auditEvent.Path, path) {
+			continue
+		}
+		result = append(result, auditEvent)
+	}
+
+	return result, nil
+}
+
+// Verify checks sequence and hash chain of all audit events
+func (as *AuditService) Verify(ctx context.Context) (*types.AuditVerifyRes, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Verify")
+	defer span.End()
+
+	auditEvents, err := as.auditRepository.GetAllEvents(ctx)
+	if err != nil {
+		return nil, errors.New("[AuditService.Verify] get all events: " + err.Error())
+	}
+
+	prev := types.AuditEvent{}
+	for _, auditEvent := range auditEvents {
+		message := ""
+		switch {
+		case auditEvent.Seq != prev.Seq+1:
+			message = "event " + strconv.FormatUint(prev.Seq+1, 10) + " is missing"
+		case auditEvent.PrevHash != prev.Hash:
+			message = "previous hash does not match"
+		case auditEvent.Hash != hashEvent(&auditEvent):
+			message = "event is modified"
+		}
+		if message != "" {
+			return &types.AuditVerifyRes{
+				Valid:     false,
+				Events:    uint64(len(auditEvents)),
+				BrokenSeq: auditEvent.Seq,
+				Message:   message,
+			}, nil
+		}
+		prev = auditEvent
+	}
+
+	return &types.AuditVerifyRes{
+		Valid:  true,
+		Events: uint64(len(auditEvents)),
+	}, nil
+}
+
+// hashEvent returns SHA-256 hash of event fields and the hash of previous event
+func hashEvent(auditEvent *types.AuditEvent) string {
+	fields := []string{
+		strconv.FormatUint(auditEvent.Seq, 10),
+		auditEvent.Timestamp.UTC().Format(time.RFC3339Nano),
+		auditEvent.Action,
+		auditEvent.Actor,
+		auditEvent.IP,
+		auditEvent.Path,
+		auditEvent.Detail,
+		auditEvent.PrevHash,
+	}
+	// fields are encoded as JSON array so that separators in fields can not make another event with same hash
+	buf, _ := json.Marshal(fields)
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
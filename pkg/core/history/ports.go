@@ -1,13 +1,19 @@
 package history
 
-import "github.com/quic-s/quics/pkg/types"
+import (
+	"context"
+
+	"github.com/quic-s/quics/pkg/types"
+)
 
 type Repository interface {
-	SaveNewFileHistory(afterPath string, fileHistory *types.FileHistory) error
-	GetFileHistory(afterPath string, timestamp uint64) (*types.FileHistory, error)
-	GetFileHistoriesForClient(afterPath string, cntFromHead uint64) ([]types.FileHistory, error)
+	SaveNewFileHistory(ctx context.Context, afterPath string, fileHistory *types.FileHistory) error
+	GetFileHistory(ctx context.Context, afterPath string, timestamp uint64) (*types.FileHistory, error)
+	GetFileHistoriesForClient(ctx context.Context, afterPath string, cntFromHead uint64) ([]types.FileHistory, error)
+	ErrKeyNotFound() error
 }
 
 type Service interface {
-	ShowHistory(request *types.ShowHistoryReq) (*types.ShowHistoryRes, error)
+	ShowHistory(ctx context.Context, request *types.ShowHistoryReq) (*types.ShowHistoryRes, error)
+	GetFileHistories(ctx context.Context, afterPath string) ([]types.FileHistory, error)
 }
@@ -1,8 +1,11 @@
 package history
 
 import (
+	"context"
 	"errors"
+	"sort"
 
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -16,8 +19,11 @@ func NewService(historyRepository Repository) *HistoryService {
 	}
 }
 
-func (hs *HistoryService) ShowHistory(request *types.ShowHistoryReq) (*types.ShowHistoryRes, error) {
-	histories, err := hs.historyRepository.GetFileHistoriesForClient(request.AfterPath, request.CntFromHead)
+func (hs *HistoryService) ShowHistory(ctx context.Context, request *types.ShowHistoryReq) (*types.ShowHistoryRes, error) {
+	ctx, span := tracing.Start(ctx, "HistoryService.ShowHistory")
+	defer span.End()
+
+	histories, err := hs.historyRepository.GetFileHistoriesForClient(ctx, request.AfterPath, request.CntFromHead)
 	if err != nil {
 		err = errors.New("[HistoryService.ShowHistory] get file histories for client: " + err.Error())
 		return nil, err
@@ -35,3 +41,29 @@ func (hs *HistoryService) ShowHistory(request *types.ShowHistoryReq) (*types.Sho
 		History: histories,
 	}, nil
 }
+
+// GetFileHistories returns all versions of file from the oldest one
+func (hs *HistoryService) GetFileHistories(ctx context.Context, afterPath string) ([]types.FileHistory, error) {
+	ctx, span := tracing.Start(ctx, "HistoryService.GetFileHistories")
+	defer span.End()
+
+	histories, err := hs.historyRepository.GetFileHistoriesForClient(ctx, afterPath, 0)
+	if err != nil {
+		err = errors.New("[HistoryService.GetFileHistories] get file histories for client: " + err.Error())
+		return nil, err
+	}
+
+	// histories are stored by the key of timestamp string, so that they are sorted by number here,
+	// and histories of other file whose path starts with "<afterPath>_" are removed
+	fileHistories := []types.FileHistory{}
+	for _, history := range histories {
+		if history.AfterPath == afterPath {
+			fileHistories = append(fileHistories, history)
+		}
+	}
+	sort.Slice(fileHistories, func(i, j int) bool {
+		return fileHistories[i].Timestamp < fileHistories[j].Timestamp
+	})
+
+	return fileHistories, nil
+}
@@ -0,0 +1,27 @@
+package lockout
+
+import (
+	"context"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type Repository interface {
+	SaveLockout(ctx context.Context, lockout *types.Lockout) error
+	GetLockout(ctx context.Context, key string) (*types.Lockout, error)
+	GetAllLockouts(ctx context.Context) ([]types.Lockout, error)
+	DeleteLockout(ctx context.Context, key string) error
+	ErrKeyNotFound() error
+}
+
+type Service interface {
+	Check(ctx context.Context, ip string, uuid string) error
+	RecordFailure(ctx context.Context, transactionName string, ip string, uuid string)
+	RecordSuccess(ctx context.Context, uuid string)
+	CheckUser(ctx context.Context, ip string, name string) error
+	RecordUserFailure(ctx context.Context, protocol string, ip string, name string)
+	RecordUserSuccess(ctx context.Context, name string)
+	GetAllLockouts(ctx context.Context) ([]types.Lockout, error)
+	ClearLockout(ctx context.Context, key string) error
+	ClearAllLockouts(ctx context.Context) error
+}
@@ -0,0 +1,270 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/metrics"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	// delay after the first failed attempt, it is doubled on each failure until threshold
+	baseDelay = time.Second
+	// longest lockout
+	maxLockout = 24 * time.Hour
+	// failed attempts are forgotten after this period without failure
+	resetPeriod = 24 * time.Hour
+)
+
+type LockoutService struct {
+	mu                sync.Mutex
+	lockoutRepository Repository
+}
+
+func NewService(lockoutRepository Repository) Service {
+	return &LockoutService{
+		lockoutRepository: lockoutRepository,
+	}
+}
+
+// Check returns error if source IP or client UUID is locked
+func (ls *LockoutService) Check(ctx context.Context, ip string, uuid string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.Check")
+	defer span.End()
+
+	return ls.check(ctx, lockoutKeys(ip, uuid))
+}
+
+// CheckUser returns error if source IP or login name (user account or root directory of password login) is locked
+func (ls *LockoutService) CheckUser(ctx context.Context, ip string, name string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.CheckUser")
+	defer span.End()
+
+	return ls.check(ctx, userLockoutKeys(ip, name))
+}
+
+func (ls *LockoutService) check(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		lockout, err := ls.lockoutRepository.GetLockout(ctx, key)
+		if err == ls.lockoutRepository.ErrKeyNotFound() {
+			continue
+		} else if err != nil {
+			return errors.New("[LockoutService.Check] get lockout: " + err.Error())
+		}
+
+		if time.Now().Before(lockout.LockedUntil) {
+			return errors.New("[LockoutService.Check] " + key + " is locked until " + lockout.LockedUntil.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts failed attempt of source IP and client UUID.
+// Each failure delays the next attempt exponentially, and the key is locked out
+// for LOCKOUT_DURATION (doubled on each further failure) after LOCKOUT_THRESHOLD failures.
+func (ls *LockoutService) RecordFailure(ctx context.Context, transactionName string, ip string, uuid string) {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordFailure")
+	defer span.End()
+
+	ls.recordFailure(ctx, transactionName, lockoutKeys(ip, uuid))
+}
+
+// RecordUserFailure counts failed password login of source IP and login name in the same way as RecordFailure,
+// protocol (e.g., webdav, sftp or rest) is used as transaction name of metrics
+func (ls *LockoutService) RecordUserFailure(ctx context.Context, protocol string, ip string, name string) {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordUserFailure")
+	defer span.End()
+
+	ls.recordFailure(ctx, protocol, userLockoutKeys(ip, name))
+}
+
+func (ls *LockoutService) recordFailure(ctx context.Context, transactionName string, keys []string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	metrics.AddAuthFailure(ctx, transactionName)
+
+	threshold := getThreshold()
+	duration := getDuration()
+	now := time.Now()
+	for _, key := range keys {
+		lockout, err := ls.lockoutRepository.GetLockout(ctx, key)
+		if err != nil {
+			lockout = &types.Lockout{
+				Key: key,
+			}
+		}
+		if now.Sub(lockout.LastFailure) > resetPeriod {
+			lockout.Failures = 0
+		}
+
+		lockout.Failures++
+		lockout.LastFailure = now
+
+		var delay time.Duration
+		if lockout.Failures < threshold {
+			delay = backoff(baseDelay, lockout.Failures-1)
+		} else {
+			delay = backoff(duration, lockout.Failures-threshold)
+
+			log.Println("quics: [", transactionName, "] ", key, " is locked out after ", lockout.Failures, " failed attempts")
+			metrics.AddLockout(ctx, transactionName, lockoutKind(key))
+		}
+		lockout.LockedUntil = now.Add(delay)
+
+		err = ls.lockoutRepository.SaveLockout(ctx, lockout)
+		if err != nil {
+			log.Println("quics err: ", err)
+		}
+	}
+}
+
+// RecordSuccess clears failed attempts of client UUID.
+// Failed attempts of source IP are kept, because it can be shared with others.
+func (ls *LockoutService) RecordSuccess(ctx context.Context, uuid string) {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordSuccess")
+	defer span.End()
+
+	if uuid == "" {
+		return
+	}
+	ls.deleteLockout(ctx, types.LockoutKindUUID+":"+uuid)
+}
+
+// RecordUserSuccess clears failed attempts of login name, failed attempts of source IP are kept as RecordSuccess does
+func (ls *LockoutService) RecordUserSuccess(ctx context.Context, name string) {
+	ctx, span := tracing.Start(ctx, "LockoutService.RecordUserSuccess")
+	defer span.End()
+
+	if name == "" {
+		return
+	}
+	ls.deleteLockout(ctx, types.LockoutKindUser+":"+name)
+}
+
+func (ls *LockoutService) deleteLockout(ctx context.Context, key string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	err := ls.lockoutRepository.DeleteLockout(ctx, key)
+	if err != nil && err != ls.lockoutRepository.ErrKeyNotFound() {
+		log.Println("quics err: ", err)
+	}
+}
+
+// GetAllLockouts gets failed attempts of all source IPs, client UUIDs and login names
+func (ls *LockoutService) GetAllLockouts(ctx context.Context) ([]types.Lockout, error) {
+	ctx, span := tracing.Start(ctx, "LockoutService.GetAllLockouts")
+	defer span.End()
+
+	lockouts, err := ls.lockoutRepository.GetAllLockouts(ctx)
+	if err != nil {
+		return nil, errors.New("[LockoutService.GetAllLockouts] get all lockouts: " + err.Error())
+	}
+
+	return lockouts, nil
+}
+
+// ClearLockout clears failed attempts of lockout key
+func (ls *LockoutService) ClearLockout(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.ClearLockout")
+	defer span.End()
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	log.Println("quics: clear lockout (key: ", key, ")")
+
+	err := ls.lockoutRepository.DeleteLockout(ctx, key)
+	if err != nil {
+		return errors.New("[LockoutService.ClearLockout] delete lockout: " + err.Error())
+	}
+
+	return nil
+}
+
+// ClearAllLockouts clears failed attempts of all lockout keys
+func (ls *LockoutService) ClearAllLockouts(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "LockoutService.ClearAllLockouts")
+	defer span.End()
+
+	lockouts, err := ls.GetAllLockouts(ctx)
+	if err != nil {
+		return errors.New("[LockoutService.ClearAllLockouts] " + err.Error())
+	}
+
+	for _, lockout := range lockouts {
+		err := ls.ClearLockout(ctx, lockout.Key)
+		if err != nil {
+			return errors.New("[LockoutService.ClearAllLockouts] " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// lockoutKeys returns lockout keys of source IP and client UUID
+func lockoutKeys(ip string, uuid string) []string {
+	keys := []string{}
+	if ip != "" {
+		keys = append(keys, types.LockoutKindIP+":"+ip)
+	}
+	if uuid != "" {
+		keys = append(keys, types.LockoutKindUUID+":"+uuid)
+	}
+	return keys
+}
+
+// userLockoutKeys returns lockout keys of source IP and login name, name is kept apart from client UUID
+func userLockoutKeys(ip string, name string) []string {
+	keys := lockoutKeys(ip, "")
+	if name != "" {
+		keys = append(keys, types.LockoutKindUser+":"+name)
+	}
+	return keys
+}
+
+func lockoutKind(key string) string {
+	if strings.HasPrefix(key, types.LockoutKindIP+":") {
+		return types.LockoutKindIP
+	}
+	if strings.HasPrefix(key, types.LockoutKindUser+":") {
+		return types.LockoutKindUser
+	}
+	return types.LockoutKindUUID
+}
+
+// backoff returns delay doubled n times, it is not longer than maxLockout
+func backoff(delay time.Duration, n uint) time.Duration {
+	backoff := float64(delay) * math.Pow(2, float64(n))
+	if backoff > float64(maxLockout) {
+		return maxLockout
+	}
+	return time.Duration(backoff)
+}
+
+func getThreshold() uint {
+	threshold, err := strconv.ParseUint(config.GetViperEnvVariables("LOCKOUT_THRESHOLD"), 10, 32)
+	if err != nil || threshold == 0 {
+		threshold, _ = strconv.ParseUint(config.DefaultLockoutThreshold, 10, 32)
+	}
+	return uint(threshold)
+}
+
+func getDuration() time.Duration {
+	duration, err := time.ParseDuration(config.GetViperEnvVariables("LOCKOUT_DURATION"))
+	if err != nil || duration <= 0 {
+		duration, _ = time.ParseDuration(config.DefaultLockoutDuration)
+	}
+	return duration
+}
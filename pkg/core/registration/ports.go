@@ -1,24 +1,42 @@
 package registration
 
 import (
+	"context"
+
 	qp "github.com/quic-s/quics-protocol"
 	"github.com/quic-s/quics/pkg/types"
 )
 
 type Repository interface {
-	SaveClient(uuid string, client *types.Client) error
-	GetClientByUUID(uuid string) (*types.Client, error)
-	GetAllClients() ([]types.Client, error)
-	DeleteClient(uuid string) error
-	GetSequence(key []byte, increment uint64) (uint64, error)
+	SaveClient(ctx context.Context, uuid string, client *types.Client) error
+	GetClientByUUID(ctx context.Context, uuid string) (*types.Client, error)
+	GetAllClients(ctx context.Context) ([]types.Client, error)
+	DeleteClient(ctx context.Context, uuid string) error
+	GetSequence(ctx context.Context, key []byte, increment uint64) (uint64, error)
 	ErrKeyNotFound() error
 }
 
+// ServerRepository is used to get hashed server password
+type ServerRepository interface {
+	GetPassword(ctx context.Context) (*types.Server, error)
+}
+
+// CertificateAuthority issues client certificate at registration
+type CertificateAuthority interface {
+	IssueClientCertificate(uuid string, csrPEM []byte) ([]byte, []byte, string, error)
+	CertPEM() []byte
+}
+
 type Service interface {
-	RegisterClient(request *types.ClientRegisterReq, conn *qp.Connection) (*types.ClientRegisterRes, error)
+	RegisterClient(ctx context.Context, request *types.ClientRegisterReq, conn *qp.Connection) (*types.ClientRegisterRes, error)
+	VerifyClientCertificate(ctx context.Context, uuid string, fingerprint string) error
+	RevokeClient(ctx context.Context, uuid string) error
+	DisconnectUserClients(ctx context.Context, name string) error
+	SavePresence(ctx context.Context, uuid string, presence types.ClientPresence) error
 }
 
 type NetworkAdapter interface {
-	UpdateClientConnection(uuid string, conn *qp.Connection) error
+	UpdateClientConnection(uuid string, conn *qp.Connection, version string) error
 	DeleteConnection(uuid string) error
+	CloseConnection(uuid string, message string) error
 }
@@ -1,35 +1,68 @@
 package registration
 
 import (
+	"context"
 	"errors"
 	"log"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/ca"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
 )
 
 type RegistrationService struct {
-	password               string
+	serverRepository       ServerRepository
 	registrationRepository Repository
+	userRepository         user.Repository
+	lockoutService         lockout.Service
+	auditService           audit.Service
+	certificateAuthority   CertificateAuthority
 	networkAdapter         NetworkAdapter
 }
 
 // NewRegistrationService creates new registration service
-func NewService(password string, registrationRepository Repository, networkAdapter NetworkAdapter) Service {
+func NewService(serverRepository ServerRepository, registrationRepository Repository, userRepository user.Repository, lockoutService lockout.Service, auditService audit.Service, certificateAuthority CertificateAuthority, networkAdapter NetworkAdapter) Service {
 	return &RegistrationService{
-		password:               password,
+		serverRepository:       serverRepository,
 		registrationRepository: registrationRepository,
+		userRepository:         userRepository,
+		lockoutService:         lockoutService,
+		auditService:           auditService,
+		certificateAuthority:   certificateAuthority,
 		networkAdapter:         networkAdapter,
 	}
 }
 
 // CreateNewClient creates new client entity
-func (rs *RegistrationService) RegisterClient(request *types.ClientRegisterReq, conn *qp.Connection) (*types.ClientRegisterRes, error) {
-	log.Println("quics: RegisterClient: ", request)
-	if request.ClientPassword != rs.password {
-		return nil, errors.New("[RegistrationService.RegitserClient] password is not correct")
+func (rs *RegistrationService) RegisterClient(ctx context.Context, request *types.ClientRegisterReq, conn *qp.Connection) (*types.ClientRegisterRes, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.RegisterClient")
+	defer span.End()
+
+	log.Println("quics: RegisterClient: ", request.UUID, " (user: ", request.Username, ")")
+	ip := ""
+	if conn != nil && conn.Conn != nil {
+		ip = utils.GetIPFromAddr(conn.Conn.RemoteAddr())
+	}
+	err := rs.lockoutService.Check(ctx, ip, request.UUID)
+	if err != nil {
+		err = errors.New("[RegistrationService.RegitserClient] " + err.Error())
+		return nil, err
+	}
+
+	ownerUser, err := rs.authenticateClient(ctx, request)
+	if err != nil {
+		rs.lockoutService.RecordFailure(ctx, types.REGISTERCLIENT, ip, request.UUID)
+		err = errors.New("[RegistrationService.RegitserClient] authenticate client: " + err.Error())
+		return nil, err
 	}
-	client, err := rs.registrationRepository.GetClientByUUID(request.UUID)
+	rs.lockoutService.RecordSuccess(ctx, request.UUID)
+
+	client, err := rs.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil && err != rs.registrationRepository.ErrKeyNotFound() {
 		err = errors.New("[RegistrationService.RegitserClient] get client by uuid: " + err.Error())
 		return nil, err
@@ -37,18 +70,56 @@ func (rs *RegistrationService) RegisterClient(request *types.ClientRegisterReq,
 
 	// if client is already existed, just update connection
 	if client != nil && request.UUID == client.UUID {
-		err = rs.networkAdapter.UpdateClientConnection(request.UUID, conn)
+		if client.OwnerUser != "" && client.OwnerUser != ownerUser {
+			return nil, errors.New("[RegistrationService.RegitserClient] client is registered under another user")
+		}
+
+		// client which has received certificate must connect with it
+		if client.CertFingerprint != "" {
+			if client.CertRevoked {
+				return nil, errors.New("[RegistrationService.RegitserClient] client certificate is revoked")
+			}
+			if getPeerFingerprint(conn) != client.CertFingerprint {
+				return nil, errors.New("[RegistrationService.RegitserClient] client certificate is required")
+			}
+		}
+
+		response := &types.ClientRegisterRes{
+			UUID:   request.UUID,
+			CACert: rs.certificateAuthority.CertPEM(),
+		}
+
+		// client registered before certificate is issued receives it now
+		if client.CertFingerprint == "" {
+			err = rs.issueCertificate(client, request.CSR, response)
+			if err != nil {
+				err = errors.New("[RegistrationService.RegitserClient] " + err.Error())
+				return nil, err
+			}
+		}
+
+		// client registered with shared password is moved under the user account
+		if client.OwnerUser == "" && ownerUser != "" {
+			client.OwnerUser = ownerUser
+		}
+
+		err = rs.registrationRepository.SaveClient(ctx, request.UUID, client)
+		if err != nil {
+			err = errors.New("[RegistrationService.RegitserClient] save client to repository: " + err.Error())
+			return nil, err
+		}
+
+		err = rs.networkAdapter.UpdateClientConnection(request.UUID, conn, request.Version)
 		if err != nil {
 			err = errors.New("[RegistrationService.RegitserClient] update client connection: " + err.Error())
 			return nil, err
 		}
-		return &types.ClientRegisterRes{
-			UUID: request.UUID,
-		}, nil
+		rs.auditService.Record(ctx, types.AuditRegisterClient, request.UUID, "", "reconnect (user: "+client.OwnerUser+")")
+		return response, nil
 	}
 
 	// create new id using badger sequence
-	newId, err := rs.registrationRepository.GetSequence([]byte("client"), 1)
+	newId, err := rs.registrationRepository.GetSequence(ctx, []byte("client"), 1)
 	if err != nil {
 		err = errors.New("[RegistrationService.RegitserClient] get sequence: " + err.Error())
 		return nil, err
@@ -56,41 +127,165 @@ func (rs *RegistrationService) RegisterClient(request *types.ClientRegisterReq,
 
 	// initialize client information
 	client = &types.Client{
-		Id:   newId,
-		UUID: request.UUID,
+		Id:        newId,
+		UUID:      request.UUID,
+		OwnerUser: ownerUser,
+	}
+
+	response := &types.ClientRegisterRes{
+		UUID:   request.UUID,
+		CACert: rs.certificateAuthority.CertPEM(),
+	}
+	err = rs.issueCertificate(client, request.CSR, response)
+	if err != nil {
+		err = errors.New("[RegistrationService.RegitserClient] " + err.Error())
+		return nil, err
 	}
 
 	// Save client to badger database
-	err = rs.registrationRepository.SaveClient(request.UUID, client)
+	err = rs.registrationRepository.SaveClient(ctx, request.UUID, client)
 	if err != nil {
 		err = errors.New("[RegistrationService.RegitserClient] save client to repository: " + err.Error())
 		return nil, err
 	}
 
-	err = rs.networkAdapter.UpdateClientConnection(request.UUID, conn)
+	err = rs.networkAdapter.UpdateClientConnection(request.UUID, conn, request.Version)
 	if err != nil {
 		err = errors.New("[RegistrationService.RegitserClient] update client connection: " + err.Error())
 		return nil, err
 	}
+	rs.auditService.Record(ctx, types.AuditRegisterClient, request.UUID, "", "new client (user: "+ownerUser+")")
 
-	return &types.ClientRegisterRes{
-		UUID: request.UUID,
-	}, nil
+	return response, nil
+}
+
+// VerifyClientCertificate checks certificate of connection is issued to the client and not revoked
+func (rs *RegistrationService) VerifyClientCertificate(ctx context.Context, uuid string, fingerprint string) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.VerifyClientCertificate")
+	defer span.End()
+
+	client, err := rs.registrationRepository.GetClientByUUID(ctx, uuid)
+	if err != nil {
+		err = errors.New("[RegistrationService.VerifyClientCertificate] get client by uuid: " + err.Error())
+		return err
+	}
+
+	if client.CertFingerprint == "" || client.CertFingerprint != fingerprint {
+		return errors.New("[RegistrationService.VerifyClientCertificate] certificate is not issued to client (UUID: " + uuid + ")")
+	}
+	if client.CertRevoked {
+		return errors.New("[RegistrationService.VerifyClientCertificate] certificate of client is revoked (UUID: " + uuid + ")")
+	}
+	// devices of disabled user can not connect or sync anymore
+	if client.OwnerUser != "" {
+		owner, err := rs.userRepository.GetUserByName(ctx, client.OwnerUser)
+		if err != nil {
+			err = errors.New("[RegistrationService.VerifyClientCertificate] get owner user of client: " + err.Error())
+			return err
+		}
+		if owner.Disabled {
+			return errors.New("[RegistrationService.VerifyClientCertificate] owner user of client is disabled (UUID: " + uuid + ")")
+		}
+	}
+
+	return nil
+}
+
+// RevokeClient revokes certificate of client and closes its connection
+func (rs *RegistrationService) RevokeClient(ctx context.Context, uuid string) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.RevokeClient")
+	defer span.End()
+
+	log.Println("quics: revoke client certificate (UUID: ", uuid, ")")
+
+	client, err := rs.registrationRepository.GetClientByUUID(ctx, uuid)
+	if err != nil {
+		err = errors.New("[RegistrationService.RevokeClient] get client by uuid: " + err.Error())
+		return err
+	}
+	if client.CertFingerprint == "" {
+		return errors.New("[RegistrationService.RevokeClient] certificate is not issued to client (UUID: " + uuid + ")")
+	}
+
+	client.CertRevoked = true
+	err = rs.registrationRepository.SaveClient(ctx, uuid, client)
+	if err != nil {
+		err = errors.New("[RegistrationService.RevokeClient] save client to repository: " + err.Error())
+		return err
+	}
+
+	err = rs.networkAdapter.CloseConnection(uuid, "client certificate is revoked")
+	if err != nil {
+		log.Println("quics: ", err)
+	}
+
+	return nil
+}
+
+// DisconnectUserClients closes connections of clients registered under the user, which is disabled.
+// Clients can not connect again because their certificates are refused while the user is disabled.
+func (rs *RegistrationService) DisconnectUserClients(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.DisconnectUserClients")
+	defer span.End()
+
+	log.Println("quics: disconnect clients of user (name: ", name, ")")
+
+	clients, err := rs.registrationRepository.GetAllClients(ctx)
+	if err != nil {
+		err = errors.New("[RegistrationService.DisconnectUserClients] get all clients: " + err.Error())
+		return err
+	}
+
+	for _, client := range clients {
+		if client.OwnerUser != name {
+			continue
+		}
+		// client may be offline
+		err = rs.networkAdapter.CloseConnection(client.UUID, "user is disabled")
+		if err != nil {
+			log.Println("quics: ", err)
+		}
+	}
+
+	return nil
+}
+
+// SavePresence saves the last presence of client when its connection ends
+func (rs *RegistrationService) SavePresence(ctx context.Context, uuid string, presence types.ClientPresence) error {
+	ctx, span := tracing.Start(ctx, "RegistrationService.SavePresence")
+	defer span.End()
+
+	client, err := rs.registrationRepository.GetClientByUUID(ctx, uuid)
+	if err != nil {
+		err = errors.New("[RegistrationService.SavePresence] get client by uuid: " + err.Error())
+		return err
+	}
+
+	client.Presence = presence
+	err = rs.registrationRepository.SaveClient(ctx, uuid, client)
+	if err != nil {
+		err = errors.New("[RegistrationService.SavePresence] save client to repository: " + err.Error())
+		return err
+	}
+	return nil
 }
 
 // CreateNewClient creates new client entity
-func (rs *RegistrationService) DisconnectClient(request *types.DisconnectClientReq, conn *qp.Connection) (*types.DisconnectClientRes, error) {
+func (rs *RegistrationService) DisconnectClient(ctx context.Context, request *types.DisconnectClientReq, conn *qp.Connection) (*types.DisconnectClientRes, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.DisconnectClient")
+	defer span.End()
+
 	log.Println("quics: DisconnectClient: ", request)
-	// Save client to badger database
-	err := rs.registrationRepository.DeleteClient(request.UUID)
+	// connection is deleted first, so that the last presence is saved before client is deleted
+	err := rs.networkAdapter.DeleteConnection(request.UUID)
 	if err != nil {
-		err = errors.New("[RegistrationService.DisconnectClient] delete client from repository: " + err.Error())
+		err = errors.New("[RegistrationService.DisconnectClient] delete client connection: " + err.Error())
 		return nil, err
 	}
 
-	err = rs.networkAdapter.DeleteConnection(request.UUID)
+	err = rs.registrationRepository.DeleteClient(ctx, request.UUID)
 	if err != nil {
-		err = errors.New("[RegistrationService.DisconnectClient] delete client connection: " + err.Error())
+		err = errors.New("[RegistrationService.DisconnectClient] delete client from repository: " + err.Error())
 		return nil, err
 	}
 
@@ -98,3 +293,64 @@ func (rs *RegistrationService) DisconnectClient(request *types.DisconnectClientR
 		UUID: request.UUID,
 	}, nil
 }
+
+// authenticateClient checks credentials of registering client and returns the name of user account.
+// Shared server password is only accepted while there is no user account.
+func (rs *RegistrationService) authenticateClient(ctx context.Context, request *types.ClientRegisterReq) (string, error) {
+	if request.Username != "" {
+		user, err := rs.userRepository.GetUserByName(ctx, request.Username)
+		if err != nil {
+			return "", errors.New("user is not found")
+		}
+		if !utils.ComparePassword(user.PasswordHash, request.ClientPassword) {
+			return "", errors.New("password is not correct")
+		}
+		if user.Disabled {
+			return "", errors.New("user is disabled")
+		}
+		return user.Name, nil
+	}
+
+	users, err := rs.userRepository.GetAllUsers(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(users) > 0 {
+		return "", errors.New("user account is required")
+	}
+	server, err := rs.serverRepository.GetPassword(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !utils.ComparePassword(server.Password, request.ClientPassword) {
+		return "", errors.New("password is not correct")
+	}
+	return "", nil
+}
+
+// issueCertificate issues client certificate and sets it to client and response
+func (rs *RegistrationService) issueCertificate(client *types.Client, csrPEM []byte, response *types.ClientRegisterRes) error {
+	certPEM, keyPEM, fingerprint, err := rs.certificateAuthority.IssueClientCertificate(client.UUID, csrPEM)
+	if err != nil {
+		return errors.New("issue client certificate: " + err.Error())
+	}
+
+	client.CertFingerprint = fingerprint
+	client.CertRevoked = false
+	response.ClientCert = certPEM
+	response.ClientKey = keyPEM
+	return nil
+}
+
+// getPeerFingerprint returns fingerprint of client certificate of connection, or empty string without certificate
+func getPeerFingerprint(conn *qp.Connection) string {
+	if conn == nil || conn.Conn == nil {
+		return ""
+	}
+
+	peerCertificates := conn.Conn.ConnectionState().TLS.PeerCertificates
+	if len(peerCertificates) == 0 {
+		return ""
+	}
+	return ca.Fingerprint(peerCertificates[0].Raw)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+// ListClients returns page of clients, filtered by Root, Prefix (of UUID) and UUID
+func (ss *ServerService) ListClients(ctx context.Context, options *types.ListOptions) (*types.Page[types.Client], error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ListClients")
+	defer span.End()
+
+	err := validateListOptions(options, "clients", false)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := ss.serverRepository.GetClientPage(ctx, options)
+	if err != nil {
+		err = errors.New("[ServerService.ListClients] get client page: " + err.Error())
+		return nil, err
+	}
+	ss.setPresence(page.Items)
+	return page, nil
+}
+
+// ListDirs returns page of root directories, filtered by Root, Prefix (of path) and UUID of joined client
+func (ss *ServerService) ListDirs(ctx context.Context, options *types.ListOptions) (*types.Page[types.RootDirectory], error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ListDirs")
+	defer span.End()
+
+	err := validateListOptions(options, "root directories", false)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := ss.serverRepository.GetRootDirectoryPage(ctx, options)
+	if err != nil {
+		err = errors.New("[ServerService.ListDirs] get root directory page: " + err.Error())
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListFiles returns page of files, which can be filtered with all options and sorted by modified time
+func (ss *ServerService) ListFiles(ctx context.Context, options *types.ListOptions) (*types.Page[types.File], error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ListFiles")
+	defer span.End()
+
+	err := validateListOptions(options, "files", true)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := ss.serverRepository.GetFilePage(ctx, options)
+	if err != nil {
+		err = errors.New("[ServerService.ListFiles] get file page: " + err.Error())
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListHistories returns page of histories, which can be filtered with options except Conflicted and sorted by modified time
+func (ss *ServerService) ListHistories(ctx context.Context, options *types.ListOptions) (*types.Page[types.FileHistory], error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ListHistories")
+	defer span.End()
+
+	if options.Conflicted {
+		return nil, fmt.Errorf("%w: conflicted is not supported for histories", ErrInvalidListOptions)
+	}
+	err := validateListOptions(options, "histories", true)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := ss.serverRepository.GetHistoryPage(ctx, options)
+	if err != nil {
+		err = errors.New("[ServerService.ListHistories] get history page: " + err.Error())
+		return nil, err
+	}
+	return page, nil
+}
+
+// validateListOptions checks options are supported by records, timed is true if records have modified time
+func validateListOptions(options *types.ListOptions, records string, timed bool) error {
+	if options.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidListOptions)
+	}
+
+	sortBy := strings.TrimPrefix(options.Sort, "-")
+	switch {
+	case sortBy == "" || sortBy == types.SortByKey:
+	case sortBy == types.SortByTime && timed:
+		if options.After != "" {
+			if _, _, err := types.ParseTimeCursor(options.After); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidListOptions, err.Error())
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s can not be sorted by %s", ErrInvalidListOptions, records, options.Sort)
+	}
+
+	if !timed && !options.Since.IsZero() {
+		return fmt.Errorf("%w: since is not supported for %s", ErrInvalidListOptions, records)
+	}
+	if !timed && options.Conflicted {
+		return fmt.Errorf("%w: conflicted is not supported for %s", ErrInvalidListOptions, records)
+	}
+	return nil
+}
@@ -1,47 +1,79 @@
 package server
 
 import (
+	"context"
 	"io"
 
+	"github.com/quic-s/quics/pkg/core/history"
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/core/sync"
 	"github.com/quic-s/quics/pkg/types"
 )
 
 type Repository interface {
-	UpdatePassword(server *types.Server) error
-	DeletePassword() error
-	GetPassword() (*types.Server, error)
-	GetAllClients() ([]types.Client, error)
-	GetAllRootDirectories() ([]types.RootDirectory, error)
-	GetAllFiles() ([]types.File, error)
-	GetClientByUUID(uuid string) (*types.Client, error)
-	GetRootDirectoryByPath(afterPath string) (*types.RootDirectory, error)
-	GetFileByAfterPath(afterPath string) (*types.File, error)
-	DeleteAllClients() error
-	DeleteAllRootDirectories() error
-	DeleteAllFiles() error
-	DeleteClientByUUID(uuid string) error
-	DeleteRootDirectoryByAfterPath(afterPath string) error
-	DeleteFileByAfterPath(afterPath string) error
-	GetAllHistories() ([]types.FileHistory, error)
-	GetHistoryByAfterPath(afterPath string) (*types.FileHistory, error)
+	UpdatePassword(ctx context.Context, server *types.Server) error
+	DeletePassword(ctx context.Context) error
+	GetPassword(ctx context.Context) (*types.Server, error)
+	GetAllClients(ctx context.Context) ([]types.Client, error)
+	GetAllRootDirectories(ctx context.Context) ([]types.RootDirectory, error)
+	GetAllFiles(ctx context.Context) ([]types.File, error)
+	GetClientByUUID(ctx context.Context, uuid string) (*types.Client, error)
+	GetRootDirectoryByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error)
+	GetFileByAfterPath(ctx context.Context, afterPath string) (*types.File, error)
+	DeleteAllClients(ctx context.Context) error
+	DeleteAllRootDirectories(ctx context.Context) error
+	DeleteAllFiles(ctx context.Context) error
+	DeleteClientByUUID(ctx context.Context, uuid string) error
+	DeleteRootDirectoryByAfterPath(ctx context.Context, afterPath string) error
+	DeleteFileByAfterPath(ctx context.Context, afterPath string) error
+	GetAllHistories(ctx context.Context) ([]types.FileHistory, error)
+	GetHistoryByAfterPath(ctx context.Context, afterPath string) (*types.FileHistory, error)
+	GetClientPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.Client], error)
+	GetRootDirectoryPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.RootDirectory], error)
+	GetFilePage(ctx context.Context, options *types.ListOptions) (*types.Page[types.File], error)
+	GetHistoryPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.FileHistory], error)
+
+	ErrKeyNotFound() error
 }
 
 type Service interface {
 	StopServer() error
 	ListenProtocol() error
-	SetPassword(request *types.Server) error
-	ResetPassword() error
-	Ping(request *types.Ping) (*types.Ping, error)
-	ShowClient(uuid string) ([]types.Client, error)
-	ShowDir(afterPath string) ([]types.RootDirectory, error)
-	ShowFile(afterPath string) ([]types.File, error)
-	ShowHistory(afterPath string) ([]types.FileHistory, error)
-	RemoveClient(uuid string) error
-	RemoveDir(afterPath string) error
-	RemoveFile(afterPath string) error
-	DownloadFile(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	SetPassword(ctx context.Context, request *types.Server) error
+	ResetPassword(ctx context.Context) error
+	Ping(ctx context.Context, request *types.Ping) (*types.Ping, error)
+	ShowClient(ctx context.Context, uuid string) ([]types.Client, error)
+	ShowDir(ctx context.Context, afterPath string) ([]types.RootDirectory, error)
+	ShowFile(ctx context.Context, afterPath string) ([]types.File, error)
+	ShowHistory(ctx context.Context, afterPath string) ([]types.FileHistory, error)
+	ListClients(ctx context.Context, options *types.ListOptions) (*types.Page[types.Client], error)
+	ListDirs(ctx context.Context, options *types.ListOptions) (*types.Page[types.RootDirectory], error)
+	ListFiles(ctx context.Context, options *types.ListOptions) (*types.Page[types.File], error)
+	ListHistories(ctx context.Context, options *types.ListOptions) (*types.Page[types.FileHistory], error)
+	RemoveClient(ctx context.Context, uuid string) error
+	RemoveDir(ctx context.Context, afterPath string) error
+	RemoveFile(ctx context.Context, afterPath string) error
+	DownloadFile(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	VerifyPassword(ctx context.Context, password string) error
+	GetStatus(ctx context.Context) (*types.ServerStatus, error)
+	CheckReadiness(ctx context.Context) *types.Readiness
+	InviteMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error)
+	RevokeMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error)
+	RevokeClient(ctx context.Context, uuid string) error
+	DisconnectUser(ctx context.Context, name string) error
+	GetAllLockouts(ctx context.Context) ([]types.Lockout, error)
+	ClearLockout(ctx context.Context, key string) error
+	GetCertificateInfo(ctx context.Context) (*types.CertificateInfo, error)
+	RenewCertificate(ctx context.Context) (*types.CertificateInfo, error)
+	GetSharingService() sharing.Service
+	GetSyncService() sync.Service
+	GetHistoryService() history.Service
+	GetLockoutService() lockout.Service
 }
 
 type SyncDirAdapter interface {
-	GetFileFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	GetFileFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	CheckWritable(ctx context.Context) error
+	GetFreeSpace(ctx context.Context) (uint64, error)
 }
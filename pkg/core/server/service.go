@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,39 +9,51 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/quic-s/quics/pkg/ca"
 	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/history"
+	"github.com/quic-s/quics/pkg/core/lockout"
 	"github.com/quic-s/quics/pkg/core/registration"
 	"github.com/quic-s/quics/pkg/core/sharing"
 	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/fs"
 	"github.com/quic-s/quics/pkg/network/qp"
 	"github.com/quic-s/quics/pkg/network/qp/connection"
 	"github.com/quic-s/quics/pkg/repository/badger"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
 )
 
-type ServerService struct {
-	port     int
-	password string
-	repo     *badger.Badger
-	Proto    *qp.Protocol
+var (
+	ErrClientNotFound  = errors.New("client does not exist")
+	ErrRootDirNotFound = errors.New("root directory does not exist")
+	ErrFileNotFound    = errors.New("file does not exist")
+	ErrHistoryNotFound = errors.New("history does not exist")
+
+	ErrInvalidListOptions = errors.New("list options are not valid")
+)
 
-	syncService sync.Service
+type ServerService struct {
+	port      int
+	startTime time.Time
+	repo      *badger.Badger
+	pool      *connection.Pool
+	Proto     *qp.Protocol
+	identity  *ca.Identity
+
+	registrationService registration.Service
+	syncService         sync.Service
+	historyService      history.Service
+	sharingService      sharing.Service
+	lockoutService      lockout.Service
 
 	syncDirAdapter   SyncDirAdapter
 	serverRepository Repository
 }
 
-func NewService(repo *badger.Badger, serverRepository Repository, syncDirAdapter sync.SyncDirAdapter) (Service, error) {
-	password := ""
-
-	server, err := repo.NewServerRepository().GetPassword()
-	if err != nil {
-		password = config.GetViperEnvVariables("PASSWORD")
-	} else {
-		password = server.Password
-	}
-
+func NewService(repo *badger.Badger, serverRepository Repository, syncDirAdapter *fs.SyncDir, authority *ca.CA, identity *ca.Identity, auditService audit.Service) (Service, error) {
 	// get env variables (server password, port)
 	port, err := strconv.Atoi(config.GetViperEnvVariables("QUICS_PORT"))
 	if err != nil {
@@ -54,21 +67,53 @@ func NewService(repo *badger.Badger, serverRepository Repository, syncDirAdapter
 	historyRepository := repo.NewHistoryRepository()
 	syncRepository := repo.NewSyncRepository()
 	sharingRepository := repo.NewSharingRepository()
+	userRepository := repo.NewUserRepository()
+	lockoutRepository := repo.NewLockoutRepository()
+
+	err = migrateCredentials(context.Background(), serverRepository, registrationRepository, syncRepository)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+	err = migrateLinks(context.Background(), sharingRepository)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
 
 	registrationNetworkAdapter := qp.NewRegistrationAdapter(pool)
 	syncNetworkAdapter := qp.NewSyncAdapter(pool)
 
-	registrationService := registration.NewService(password, registrationRepository, registrationNetworkAdapter)
+	lockoutService := lockout.NewService(lockoutRepository)
+	registrationService := registration.NewService(serverRepository, registrationRepository, userRepository, lockoutService, auditService, authority, registrationNetworkAdapter)
 	historyService := history.NewService(historyRepository)
-	syncService := sync.NewService(registrationRepository, historyRepository, syncRepository, syncNetworkAdapter, syncDirAdapter)
-	sharingService := sharing.NewService(historyRepository, syncRepository, sharingRepository, syncDirAdapter)
+	syncService := sync.NewService(registrationRepository, historyRepository, syncRepository, lockoutService, auditService, syncNetworkAdapter, syncDirAdapter)
+	sharingService := sharing.NewService(registrationRepository, historyRepository, syncRepository, sharingRepository, syncService, auditService, syncDirAdapter)
+
+	// queued MUSTSYNC is sent when client connects, and the last presence is saved when its connection ends.
+	// Queue of client is dropped when client leaves the server.
+	pool.OnConnect(func(uuid string) {
+		err := syncService.CallQueuedMustSync(context.Background(), uuid)
+		if err != nil {
+			log.Println("quics err: ", err)
+		}
+	})
+	pool.OnDisconnect(func(uuid string, presence types.ClientPresence) {
+		err := registrationService.SavePresence(context.Background(), uuid, presence)
+		if err != nil {
+			log.Println("quics err: ", err)
+		}
+	})
+	pool.OnDelete(func(uuid string) {
+		syncService.ClearQueuedMustSync(context.Background(), uuid)
+	})
 
 	registrationHandler := qp.NewRegistrationHandler(registrationService)
 	syncHandler := qp.NewSyncHandler(syncService)
 	historyHandler := qp.NewHistoryHandler(historyService, sharingService)
 	sharingHandler := qp.NewSharingHandler(sharingService)
 
-	proto, err := qp.New("0.0.0.0", port, pool)
+	proto, err := qp.New("0.0.0.0", port, pool, authority, identity, registrationService)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
@@ -89,16 +134,27 @@ func NewService(repo *badger.Badger, serverRepository Repository, syncDirAdapter
 	proto.RecvTransactionHandleFunc(types.HISTORYDOWNLOAD, syncHandler.DownloadHistory)
 	proto.RecvTransactionHandleFunc(types.STARTSHARING, sharingHandler.StartSharing)
 	proto.RecvTransactionHandleFunc(types.STOPSHARING, sharingHandler.StopSharing)
+	proto.RecvTransactionHandleFunc(types.STARTDROP, sharingHandler.StartDrop)
+	proto.RecvTransactionHandleFunc(types.LISTSHARING, sharingHandler.ListSharing)
+	proto.RecvTransactionHandleFunc(types.REVOKESHARING, sharingHandler.RevokeSharing)
+	proto.RecvTransactionHandleFunc(types.INVITEMEMBER, syncHandler.InviteMember)
+	proto.RecvTransactionHandleFunc(types.REVOKEMEMBER, syncHandler.RevokeMember)
 
 	return &ServerService{
-		port:     port,
-		password: password,
-		repo:     repo,
-		Proto:    proto,
-
-		syncService:      syncService,
-		syncDirAdapter:   syncDirAdapter,
-		serverRepository: serverRepository,
+		port:      port,
+		startTime: time.Now(),
+		repo:      repo,
+		pool:      pool,
+		Proto:     proto,
+		identity:  identity,
+
+		registrationService: registrationService,
+		syncService:         syncService,
+		historyService:      historyService,
+		sharingService:      sharingService,
+		lockoutService:      lockoutService,
+		syncDirAdapter:      syncDirAdapter,
+		serverRepository:    serverRepository,
 	}, nil
 }
 
@@ -130,6 +186,14 @@ func (ss *ServerService) ListenProtocol() error {
 
 	// start quics protocol server
 	ss.syncService.BackgroundFullScan(300)
+	ss.sharingService.BackgroundCleanupLinks(300)
+	heartbeatTimeout, err := time.ParseDuration(config.GetViperEnvVariables("HEARTBEAT_TIMEOUT"))
+	if err != nil {
+		heartbeatTimeout, _ = time.ParseDuration(config.DefaultHeartbeatTimeout)
+	}
+	if heartbeatTimeout > 0 {
+		ss.pool.BackgroundEvictStale(heartbeatTimeout)
+	}
 	errChan := make(chan error)
 	go func() {
 		go func() {
@@ -143,14 +207,17 @@ func (ss *ServerService) ListenProtocol() error {
 		}
 	}()
 
-	err := <-errChan
+	err = <-errChan
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (ss *ServerService) SetPassword(request *types.Server) error {
+func (ss *ServerService) SetPassword(ctx context.Context, request *types.Server) error {
+	_, span := tracing.Start(ctx, "ServerService.SetPassword")
+	defer span.End()
+
 	log.Println("quics: set password")
 
 	err := config.WriteViperEnvVariables("PASSWORD", request.Password)
@@ -159,10 +226,19 @@ func (ss *ServerService) SetPassword(request *types.Server) error {
 		return err
 	}
 
+	err = ss.updatePasswordHash(ctx, request.Password)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return err
+	}
+
 	return nil
 }
 
-func (ss *ServerService) ResetPassword() error {
+func (ss *ServerService) ResetPassword(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "ServerService.ResetPassword")
+	defer span.End()
+
 	log.Println("quics: reset password")
 
 	err := config.WriteViperEnvVariables("PASSWORD", config.DefaultPassword)
@@ -171,11 +247,20 @@ func (ss *ServerService) ResetPassword() error {
 		return err
 	}
 
+	err = ss.updatePasswordHash(ctx, config.DefaultPassword)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return err
+	}
+
 	return nil
 }
 
-func (ss *ServerService) Ping(request *types.Ping) (*types.Ping, error) {
-	client, err := ss.serverRepository.GetClientByUUID(request.UUID)
+func (ss *ServerService) Ping(ctx context.Context, request *types.Ping) (*types.Ping, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.Ping")
+	defer span.End()
+
+	client, err := ss.serverRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
@@ -190,31 +275,54 @@ func (ss *ServerService) Ping(request *types.Ping) (*types.Ping, error) {
 	}, nil
 }
 
-func (ss *ServerService) ShowClient(uuid string) ([]types.Client, error) {
+func (ss *ServerService) ShowClient(ctx context.Context, uuid string) ([]types.Client, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ShowClient")
+	defer span.End()
+
 	log.Println("quics: show client logs (uudi: ", uuid, ")")
 
 	if uuid == "" {
-		clients, err := ss.serverRepository.GetAllClients()
+		clients, err := ss.serverRepository.GetAllClients(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return nil, err
 		}
+		ss.setPresence(clients)
 		return clients, nil
 	}
-	client, err := ss.serverRepository.GetClientByUUID(uuid)
+	client, err := ss.serverRepository.GetClientByUUID(ctx, uuid)
+	if err == ss.serverRepository.ErrKeyNotFound() {
+		return nil, ErrClientNotFound
+	}
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
 	}
 
-	return []types.Client{*client}, nil
+	clients := []types.Client{*client}
+	ss.setPresence(clients)
+	return clients, nil
+}
+
+// setPresence sets live presence of connected clients, and the others keep their last presence as offline
+func (ss *ServerService) setPresence(clients []types.Client) {
+	for i := range clients {
+		if presence, online := ss.pool.GetPresence(clients[i].UUID); online {
+			clients[i].Presence = presence
+		} else {
+			clients[i].Presence.Online = false
+		}
+	}
 }
 
-func (ss *ServerService) ShowDir(afterPath string) ([]types.RootDirectory, error) {
+func (ss *ServerService) ShowDir(ctx context.Context, afterPath string) ([]types.RootDirectory, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ShowDir")
+	defer span.End()
+
 	log.Println("quics: show dir logs (afterPath: ", afterPath, ")")
 
 	if afterPath == "" {
-		dirs, err := ss.serverRepository.GetAllRootDirectories()
+		dirs, err := ss.serverRepository.GetAllRootDirectories(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return nil, err
@@ -223,7 +331,10 @@ func (ss *ServerService) ShowDir(afterPath string) ([]types.RootDirectory, error
 		return dirs, nil
 	}
 
-	dir, err := ss.serverRepository.GetRootDirectoryByPath(afterPath)
+	dir, err := ss.serverRepository.GetRootDirectoryByPath(ctx, afterPath)
+	if err == ss.serverRepository.ErrKeyNotFound() {
+		return nil, ErrRootDirNotFound
+	}
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
@@ -231,11 +342,14 @@ func (ss *ServerService) ShowDir(afterPath string) ([]types.RootDirectory, error
 	return []types.RootDirectory{*dir}, nil
 }
 
-func (ss *ServerService) ShowFile(afterPath string) ([]types.File, error) {
+func (ss *ServerService) ShowFile(ctx context.Context, afterPath string) ([]types.File, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ShowFile")
+	defer span.End()
+
 	log.Println("quics: show file logs (afterPath: ", afterPath, ")")
 
 	if afterPath == "" {
-		files, err := ss.serverRepository.GetAllFiles()
+		files, err := ss.serverRepository.GetAllFiles(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return nil, err
@@ -244,7 +358,10 @@ func (ss *ServerService) ShowFile(afterPath string) ([]types.File, error) {
 		return files, nil
 	}
 
-	file, err := ss.serverRepository.GetFileByAfterPath(afterPath)
+	file, err := ss.serverRepository.GetFileByAfterPath(ctx, afterPath)
+	if err == ss.serverRepository.ErrKeyNotFound() {
+		return nil, ErrFileNotFound
+	}
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
@@ -252,11 +369,14 @@ func (ss *ServerService) ShowFile(afterPath string) ([]types.File, error) {
 	return []types.File{*file}, nil
 }
 
-func (ss *ServerService) ShowHistory(afterPath string) ([]types.FileHistory, error) {
+func (ss *ServerService) ShowHistory(ctx context.Context, afterPath string) ([]types.FileHistory, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.ShowHistory")
+	defer span.End()
+
 	log.Println("quics: show history logs (afterPath: ", afterPath, ")")
 
 	if afterPath == "" {
-		histories, err := ss.serverRepository.GetAllHistories()
+		histories, err := ss.serverRepository.GetAllHistories(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return nil, err
@@ -265,7 +385,10 @@ func (ss *ServerService) ShowHistory(afterPath string) ([]types.FileHistory, err
 		return histories, nil
 	}
 
-	history, err := ss.serverRepository.GetHistoryByAfterPath(afterPath)
+	history, err := ss.serverRepository.GetHistoryByAfterPath(ctx, afterPath)
+	if err == ss.serverRepository.ErrKeyNotFound() {
+		return nil, ErrHistoryNotFound
+	}
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
@@ -276,72 +399,397 @@ func (ss *ServerService) ShowHistory(afterPath string) ([]types.FileHistory, err
 	return []types.FileHistory{*history}, nil
 }
 
-func (ss *ServerService) RemoveClient(uuid string) error {
+func (ss *ServerService) RemoveClient(ctx context.Context, uuid string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.RemoveClient")
+	defer span.End()
+
 	log.Println("quics: remove client (uuid: ", uuid, ")")
 
 	if uuid == "" {
-		err := ss.serverRepository.DeleteAllClients()
+		err := ss.serverRepository.DeleteAllClients(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return err
 		}
+		ss.syncService.ClearQueuedMustSync(ctx, "")
 
 		return nil
 	}
-	err := ss.serverRepository.DeleteClientByUUID(uuid)
+	err := ss.serverRepository.DeleteClientByUUID(ctx, uuid)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return err
 	}
+	ss.syncService.ClearQueuedMustSync(ctx, uuid)
 
 	return nil
 }
 
-func (ss *ServerService) RemoveDir(afterPath string) error {
+func (ss *ServerService) RemoveDir(ctx context.Context, afterPath string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.RemoveDir")
+	defer span.End()
+
 	log.Println("quics: remove dir (afterPath: ", afterPath, ")")
 
 	if afterPath == "" {
-		err := ss.serverRepository.DeleteAllRootDirectories()
+		err := ss.serverRepository.DeleteAllRootDirectories(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return err
 		}
+		ss.revokeRemovedLinks(ctx)
 
 		return nil
 	}
 
-	err := ss.serverRepository.DeleteRootDirectoryByAfterPath(afterPath)
+	err := ss.serverRepository.DeleteRootDirectoryByAfterPath(ctx, afterPath)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return err
 	}
+	ss.revokeRemovedLinks(ctx)
 
 	return nil
 }
 
-func (ss *ServerService) RemoveFile(afterPath string) error {
+func (ss *ServerService) RemoveFile(ctx context.Context, afterPath string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.RemoveFile")
+	defer span.End()
+
 	log.Println("quics: remove file (afterPath: ", afterPath, ")")
 
 	if afterPath == "" {
-		err := ss.serverRepository.DeleteAllFiles()
+		err := ss.serverRepository.DeleteAllFiles(ctx)
 		if err != nil {
 			log.Println("quics err: ", err)
 			return err
 		}
+		ss.revokeRemovedLinks(ctx)
 
 		return nil
 	}
-	err := ss.serverRepository.DeleteFileByAfterPath(afterPath)
+	err := ss.serverRepository.DeleteFileByAfterPath(ctx, afterPath)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return err
 	}
+	ss.revokeRemovedLinks(ctx)
 
 	return nil
 }
 
-func (ss *ServerService) DownloadFile(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error) {
+func (ss *ServerService) DownloadFile(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.DownloadFile")
+	defer span.End()
+
 	log.Println("quics: download file (afterPath: ", afterPath, ")")
 
-	return ss.syncDirAdapter.GetFileFromHistoryDir(afterPath, timestamp)
+	return ss.syncDirAdapter.GetFileFromHistoryDir(ctx, afterPath, timestamp)
+}
+
+// VerifyPassword checks the password of administration request
+func (ss *ServerService) VerifyPassword(ctx context.Context, password string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.VerifyPassword")
+	defer span.End()
+
+	server, err := ss.serverRepository.GetPassword(ctx)
+	if err != nil {
+		return errors.New("[ServerService.VerifyPassword] get password: " + err.Error())
+	}
+
+	if !utils.ComparePassword(server.Password, password) {
+		return errors.New("[ServerService.VerifyPassword] password is not correct")
+	}
+
+	return nil
+}
+
+// GetStatus gets running status of quics server
+func (ss *ServerService) GetStatus(ctx context.Context) (*types.ServerStatus, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.GetStatus")
+	defer span.End()
+
+	return &types.ServerStatus{
+		Version:          config.Version,
+		StartTime:        ss.startTime,
+		Uptime:           time.Since(ss.startTime).Round(time.Second).String(),
+		ConnectedClients: ss.pool.GetAllUUIDs(),
+		PendingSyncNum:   ss.syncService.GetPendingSyncNum(ctx),
+		LastFullScans:    ss.syncService.GetFullScanResults(ctx),
+	}, nil
+}
+
+// CheckReadiness checks database, quics protocol listener and sync directory are ready to serve
+func (ss *ServerService) CheckReadiness(ctx context.Context) *types.Readiness {
+	ctx, span := tracing.Start(ctx, "ServerService.CheckReadiness")
+	defer span.End()
+
+	readiness := &types.Readiness{
+		Ready:  true,
+		Checks: []types.ReadinessCheck{},
+	}
+	addCheck := func(name string, err error) {
+		check := types.ReadinessCheck{
+			Name:  name,
+			Ready: err == nil,
+		}
+		if err != nil {
+			check.Message = err.Error()
+			readiness.Ready = false
+		}
+		readiness.Checks = append(readiness.Checks, check)
+	}
+
+	// check badger database is open
+	if ss.repo.IsOpen() {
+		addCheck("database", nil)
+	} else {
+		addCheck("database", errors.New("database is closed"))
+	}
+
+	// check quics protocol listener is accepting connections
+	if ss.Proto.IsListening() {
+		addCheck("protocol", nil)
+	} else {
+		addCheck("protocol", errors.New("quics protocol is not listening"))
+	}
+
+	// check sync directory is writable
+	addCheck("syncdir", ss.syncDirAdapter.CheckWritable(ctx))
+
+	// check there is enough free disk space
+	minFreeDiskMB, err := strconv.ParseUint(config.GetViperEnvVariables("READY_MIN_FREE_DISK_MB"), 10, 64)
+	if err != nil {
+		minFreeDiskMB, _ = strconv.ParseUint(config.DefaultReadyMinFreeDiskMB, 10, 64)
+	}
+	freeSpace, err := ss.syncDirAdapter.GetFreeSpace(ctx)
+	if errors.Is(err, errors.ErrUnsupported) {
+		addCheck("disk", nil)
+	} else if err != nil {
+		addCheck("disk", err)
+	} else if freeSpace < minFreeDiskMB*1024*1024 {
+		addCheck("disk", fmt.Errorf("free disk space is %d MB, less than %d MB", freeSpace/1024/1024, minFreeDiskMB))
+	} else {
+		addCheck("disk", nil)
+	}
+
+	return readiness
+}
+
+// InviteMember invites member to root directory
+func (ss *ServerService) InviteMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.InviteMember")
+	defer span.End()
+
+	return ss.syncService.InviteMember(ctx, requester, request)
+}
+
+// RevokeMember revokes member from root directory
+func (ss *ServerService) RevokeMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.RevokeMember")
+	defer span.End()
+
+	return ss.syncService.RevokeMember(ctx, requester, request)
+}
+
+// RevokeClient revokes certificate of client, the client cannot connect until it is removed and registered again
+func (ss *ServerService) RevokeClient(ctx context.Context, uuid string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.RevokeClient")
+	defer span.End()
+
+	return ss.registrationService.RevokeClient(ctx, uuid)
+}
+
+// DisconnectUser closes connections of clients of disabled user, they can not connect while the user is disabled
+func (ss *ServerService) DisconnectUser(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.DisconnectUser")
+	defer span.End()
+
+	return ss.registrationService.DisconnectUserClients(ctx, name)
+}
+
+// GetAllLockouts gets failed authentication attempts of all source IPs and client UUIDs
+func (ss *ServerService) GetAllLockouts(ctx context.Context) ([]types.Lockout, error) {
+	ctx, span := tracing.Start(ctx, "ServerService.GetAllLockouts")
+	defer span.End()
+
+	return ss.lockoutService.GetAllLockouts(ctx)
+}
+
+// ClearLockout clears lockout by key, or all lockouts if key is empty
+func (ss *ServerService) ClearLockout(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "ServerService.ClearLockout")
+	defer span.End()
+
+	if key == "" {
+		return ss.lockoutService.ClearAllLockouts(ctx)
+	}
+	return ss.lockoutService.ClearLockout(ctx, key)
+}
+
+// GetSharingService returns sharing service which shares sync service with quics-protocol server,
+// so that files uploaded with rest api are synchronized to clients
+func (ss *ServerService) GetSharingService() sharing.Service {
+	return ss.sharingService
+}
+
+// GetSyncService returns sync service of quics-protocol server,
+// so that files written with WebDAV, SFTP and S3 are synchronized to clients
+func (ss *ServerService) GetSyncService() sync.Service {
+	return ss.syncService
+}
+
+// GetHistoryService returns history service, which is used to list versions of files with S3 API
+func (ss *ServerService) GetHistoryService() history.Service {
+	return ss.historyService
+}
+
+// GetLockoutService returns lockout service of quics-protocol server,
+// so that password logins of WebDAV, SFTP and rest api share failed attempts with clients
+func (ss *ServerService) GetLockoutService() lockout.Service {
+	return ss.lockoutService
+}
+
+// GetCertificateInfo gets TLS certificate which is served by rest server and quics-protocol server
+func (ss *ServerService) GetCertificateInfo(ctx context.Context) (*types.CertificateInfo, error) {
+	_, span := tracing.Start(ctx, "ServerService.GetCertificateInfo")
+	defer span.End()
+
+	return ss.identity.Info(), nil
+}
+
+// RenewCertificate issues new TLS certificate, it is served to new connections without restart
+func (ss *ServerService) RenewCertificate(ctx context.Context) (*types.CertificateInfo, error) {
+	_, span := tracing.Start(ctx, "ServerService.RenewCertificate")
+	defer span.End()
+
+	err := ss.identity.Renew()
+	if err != nil {
+		return nil, errors.New("[ServerService.RenewCertificate] " + err.Error())
+	}
+
+	return ss.identity.Info(), nil
+}
+
+// updatePasswordHash saves the bcrypt hash of server password
+// revokeRemovedLinks revokes sharing links of removed files, failure does not fail the removal
+func (ss *ServerService) revokeRemovedLinks(ctx context.Context) {
+	_, err := ss.sharingService.CleanupLinks(ctx)
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+}
+
+func (ss *ServerService) updatePasswordHash(ctx context.Context, password string) error {
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("[ServerService.updatePasswordHash] hash password: " + err.Error())
+	}
+
+	err = ss.serverRepository.UpdatePassword(ctx, &types.Server{
+		Password:          passwordHash,
+		HashedCredentials: true,
+	})
+	if err != nil {
+		return errors.New("[ServerService.updatePasswordHash] update password: " + err.Error())
+	}
+
+	return nil
+}
+
+// migrateCredentials hashes plaintext passwords which are stored before hashed credential storage.
+// It runs once, the server record is marked after migration.
+func migrateCredentials(ctx context.Context, serverRepository Repository, registrationRepository registration.Repository, syncRepository sync.Repository) error {
+	ctx, span := tracing.Start(ctx, "server.migrateCredentials")
+	defer span.End()
+
+	server, err := serverRepository.GetPassword(ctx)
+	if err != nil {
+		// fresh database, password in qis.env is used
+		server = &types.Server{
+			Password: config.GetViperEnvVariables("PASSWORD"),
+		}
+	}
+	if server.HashedCredentials {
+		return nil
+	}
+	log.Println("quics: migrate stored credentials to hashes")
+
+	hashPassword := func(password string) (string, error) {
+		if utils.IsPasswordHash(password) {
+			return password, nil
+		}
+		return utils.HashPassword(password)
+	}
+
+	// root directory passwords
+	rootDirs, err := syncRepository.GetAllRootDir(ctx)
+	if err != nil {
+		return errors.New("[server.migrateCredentials] get all root directories: " + err.Error())
+	}
+	for _, rootDir := range rootDirs {
+		rootDir.Password, err = hashPassword(rootDir.Password)
+		if err != nil {
+			return errors.New("[server.migrateCredentials] hash root directory password: " + err.Error())
+		}
+		err = syncRepository.SaveRootDir(ctx, rootDir.AfterPath, &rootDir)
+		if err != nil {
+			return errors.New("[server.migrateCredentials] save root directory: " + err.Error())
+		}
+	}
+
+	// root directories copied into clients
+	clients, err := registrationRepository.GetAllClients(ctx)
+	if err != nil {
+		return errors.New("[server.migrateCredentials] get all clients: " + err.Error())
+	}
+	for _, client := range clients {
+		for i := range client.Root {
+			client.Root[i].Password, err = hashPassword(client.Root[i].Password)
+			if err != nil {
+				return errors.New("[server.migrateCredentials] hash root directory password: " + err.Error())
+			}
+		}
+		err = registrationRepository.SaveClient(ctx, client.UUID, &client)
+		if err != nil {
+			return errors.New("[server.migrateCredentials] save client: " + err.Error())
+		}
+	}
+
+	// server password is marked last, so an interrupted migration runs again
+	server.Password, err = hashPassword(server.Password)
+	if err != nil {
+		return errors.New("[server.migrateCredentials] hash server password: " + err.Error())
+	}
+	server.HashedCredentials = true
+	err = serverRepository.UpdatePassword(ctx, server)
+	if err != nil {
+		return errors.New("[server.migrateCredentials] update server password: " + err.Error())
+	}
+
+	return nil
+}
+
+// migrateLinks deletes sharing links which are made of client UUID and file path before random link IDs,
+// they are keyed by the link itself and can be guessed
+func migrateLinks(ctx context.Context, sharingRepository sharing.Repository) error {
+	ctx, span := tracing.Start(ctx, "server.migrateLinks")
+	defer span.End()
+
+	sharings, err := sharingRepository.GetAllLinks(ctx)
+	if err != nil {
+		return errors.New("[server.migrateLinks] get all links: " + err.Error())
+	}
+	for _, sharing := range sharings {
+		if sharing.ID != "" {
+			continue
+		}
+		log.Println("quics: delete guessable sharing link of ", sharing.AfterPath)
+		err = sharingRepository.DeleteLink(ctx, sharing.Link)
+		if err != nil {
+			return errors.New("[server.migrateLinks] delete link: " + err.Error())
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,97 @@
+package sharing
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+// WriteArchive streams files in shared directory as zip or tar.gz archive to w.
+// Each file is read from the latest directory while it is written, so that archive is not buffered in memory.
+// Files in archive are under the directory of shared directory name.
+func (ss *SharingService) WriteArchive(ctx context.Context, sharedDir *types.SharedDir, format string, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "SharingService.WriteArchive")
+	defer span.End()
+
+	switch format {
+	case ArchiveZip:
+		zipWriter := zip.NewWriter(w)
+		err := ss.writeArchiveFiles(ctx, sharedDir, func(name string, fileInfo *types.FileMetadata) (io.Writer, error) {
+			return zipWriter.CreateHeader(&zip.FileHeader{
+				Name:     name,
+				Method:   zip.Deflate,
+				Modified: fileInfo.ModTime,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return zipWriter.Close()
+	case ArchiveTarGz:
+		gzipWriter := gzip.NewWriter(w)
+		tarWriter := tar.NewWriter(gzipWriter)
+		err := ss.writeArchiveFiles(ctx, sharedDir, func(name string, fileInfo *types.FileMetadata) (io.Writer, error) {
+			err := tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     name,
+				Size:     fileInfo.Size,
+				Mode:     int64(fileInfo.Mode.Perm()),
+				ModTime:  fileInfo.ModTime,
+			})
+			return tarWriter, err
+		})
+		if err != nil {
+			return err
+		}
+		if err := tarWriter.Close(); err != nil {
+			return err
+		}
+		return gzipWriter.Close()
+	default:
+		return errors.New("[SharingService.WriteArchive] unknown archive format: " + format)
+	}
+}
+
+// writeArchiveFiles copies each file in shared directory to the writer which is created by createEntry
+func (ss *SharingService) writeArchiveFiles(ctx context.Context, sharedDir *types.SharedDir, createEntry func(name string, fileInfo *types.FileMetadata) (io.Writer, error)) error {
+	for _, sharedFile := range sharedDir.Files {
+		err := func() error {
+			fileInfo, fileContent, err := ss.syncDir.GetFileFromLatestDir(ctx, path.Join(sharedDir.AfterPath, sharedFile.Path))
+			if err != nil {
+				return errors.New("[SharingService.WriteArchive] get file from latest dir: " + err.Error())
+			}
+			if closer, ok := fileContent.(io.Closer); ok {
+				defer closer.Close()
+			}
+
+			entry, err := createEntry(path.Join(sharedDir.Name, sharedFile.Path), fileInfo)
+			if err != nil {
+				return errors.New("[SharingService.WriteArchive] create archive entry: " + err.Error())
+			}
+
+			// size of tar entry is fixed by header, so only the size is copied even if the file grows
+			_, err = io.CopyN(entry, fileContent, fileInfo.Size)
+			if err != nil {
+				return errors.New("[SharingService.WriteArchive] write " + sharedFile.Path + ": " + err.Error())
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
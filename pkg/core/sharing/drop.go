@@ -0,0 +1,252 @@
+package sharing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// CreateDropLink creates upload-only link which accepts files into the folder of root directory.
+// Requester is "client:<uuid>", "user:<name>" or admin, and must be owner or writer of the root directory.
+func (ss *SharingService) CreateDropLink(ctx context.Context, requester string, request *types.DropReq) (*types.ShareRes, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.CreateDropLink")
+	defer span.End()
+
+	afterPath := strings.TrimSuffix(request.AfterPath, "/")
+	if !strings.HasPrefix(afterPath, "/") || afterPath == "/" {
+		return nil, errors.New("[SharingService.CreateDropLink] invalid path: " + request.AfterPath)
+	}
+	if request.MaxCnt == 0 {
+		return nil, errors.New("[SharingService.CreateDropLink] max count of files is required")
+	}
+
+	exist, err := ss.isExistFile(ctx, afterPath)
+	if err != nil {
+		err = errors.New("[SharingService.CreateDropLink] check file by path: " + err.Error())
+		return nil, err
+	}
+	if exist {
+		return nil, errors.New("[SharingService.CreateDropLink] path is file, folder is required: " + afterPath)
+	}
+
+	rootDirName, _ := utils.GetNamesByAfterPath(afterPath)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, "/"+rootDirName)
+	if err != nil {
+		err = errors.New("[SharingService.CreateDropLink] get rootDir by path: " + err.Error())
+		return nil, err
+	}
+	role, err := ss.syncService.GetRequesterRole(ctx, rootDir, requester)
+	if err != nil {
+		err = errors.New("[SharingService.CreateDropLink] get requester role: " + err.Error())
+		return nil, err
+	}
+	if !types.CanWrite(role) {
+		return nil, errors.New("[SharingService.CreateDropLink] requester does not have write permission on " + rootDir.AfterPath)
+	}
+
+	id, err := randomHex(linkIDLength)
+	if err != nil {
+		err = errors.New("[SharingService.CreateDropLink] generate link id: " + err.Error())
+		return nil, err
+	}
+	link := "https://" + config.GetRestServerAddress() + "/api/v1/drop?id=" + id
+
+	owner := requester
+	if uuid, _ := types.ParseMember(requester); uuid != "" {
+		owner = uuid
+	}
+
+	sharing := &types.Sharing{
+		ID:       id,
+		Link:     link,
+		Count:    0,
+		MaxCount: uint(request.MaxCnt),
+		Owner:    owner,
+
+		AfterPath: afterPath,
+		Drop:      true,
+		MaxSize:   request.MaxSize,
+
+		Note:      request.Note,
+		CreatedAt: time.Now(),
+	}
+	if request.ExpireSec > 0 {
+		sharing.ExpiresAt = sharing.CreatedAt.Add(time.Duration(request.ExpireSec) * time.Second)
+	}
+	if request.Password != "" {
+		sharing.PasswordHash, err = utils.HashPassword(request.Password)
+		if err != nil {
+			err = errors.New("[SharingService.CreateDropLink] hash password: " + err.Error())
+			return nil, err
+		}
+	}
+
+	err = ss.sharingRepository.SaveLink(ctx, sharing)
+	if err != nil {
+		err = errors.New("[SharingService.CreateDropLink] save link to repository: " + err.Error())
+		return nil, err
+	}
+	ss.auditService.Record(ctx, types.AuditCreateShare, owner, afterPath, fmt.Sprint("id: ", id, ", drop: true, max size: ", request.MaxSize, ", max count: ", request.MaxCnt, ", expire sec: ", request.ExpireSec, ", password: ", request.Password != ""))
+
+	return &types.ShareRes{
+		ID:   id,
+		Link: link,
+	}, nil
+}
+
+// GetDropInfo returns information of drop link to show upload form, it does not count usage of link
+func (ss *SharingService) GetDropInfo(ctx context.Context, id string, password string) (*types.DropInfo, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.GetDropInfo")
+	defer span.End()
+
+	sharing, err := ss.getValidLink(ctx, id, password, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DropInfo{
+		ID:           sharing.ID,
+		Name:         filepath.Base(sharing.AfterPath),
+		Note:         sharing.Note,
+		MaxSize:      sharing.MaxSize,
+		RemainingCnt: sharing.MaxCount - sharing.Count,
+		ExpiresAt:    sharing.ExpiresAt,
+	}, nil
+}
+
+// UploadDropFile commits uploaded file into the folder of drop link through sync service,
+// so that it has history and is synchronized to clients of root directory.
+// If file of the same name exists, number is added to the name (e.g., "report (1).pdf").
+// It returns the name of committed file.
+func (ss *SharingService) UploadDropFile(ctx context.Context, id string, password string, fileName string, size int64, fileContent io.Reader) (string, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.UploadDropFile")
+	defer span.End()
+
+	sharing, err := ss.getValidLink(ctx, id, password, true, false)
+	if err != nil {
+		return "", err
+	}
+	if size < 0 || (sharing.MaxSize > 0 && uint64(size) > sharing.MaxSize) {
+		return "", ErrFileTooLarge
+	}
+
+	// uploader can not choose other folder with file name
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "" || fileName == "." || fileName == ".." || fileName == "/" {
+		return "", ErrInvalidFileName
+	}
+
+	// reserve usage and path before upload, so that concurrent uploads can not exceed max count or overwrite each other
+	afterPath, err := ss.reserveDropUpload(ctx, sharing.ID, fileName)
+	if err != nil {
+		return "", err
+	}
+	defer ss.releaseDropUpload(sharing.ID, afterPath)
+
+	fileMetadata := &types.FileMetadata{
+		Name:    filepath.Base(afterPath),
+		Size:    size,
+		Mode:    os.FileMode(0644),
+		ModTime: time.Now(),
+		IsDir:   false,
+	}
+	_, err = ss.syncService.CommitFile(ctx, "drop:"+sharing.ID, afterPath, fileMetadata, fileContent)
+	if err != nil {
+		err = errors.New("[SharingService.UploadDropFile] commit file: " + err.Error())
+		return "", err
+	}
+
+	err = ss.countLinkUsage(ctx, sharing, afterPath, false)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Base(afterPath), nil
+}
+
+// reserveDropUpload reserves usage of drop link and available path in its folder until upload is committed and counted.
+// Usages which are reserved by other uploads are counted too.
+func (ss *SharingService) reserveDropUpload(ctx context.Context, id string, fileName string) (string, error) {
+	ss.linkMut.Lock()
+	defer ss.linkMut.Unlock()
+
+	sharing, err := ss.sharingRepository.GetLink(ctx, id)
+	if err == ss.sharingRepository.ErrKeyNotFound() {
+		return "", ErrLinkNotFound
+	}
+	if err != nil {
+		return "", errors.New("[SharingService.reserveDropUpload] get link from repository: " + err.Error())
+	}
+
+	reserved := ss.dropUploads[id]
+	if sharing.Count+uint(len(reserved)) >= sharing.MaxCount {
+		return "", ErrLinkNotFound
+	}
+
+	afterPath, err := ss.getAvailablePath(ctx, sharing.AfterPath, fileName, reserved)
+	if err != nil {
+		return "", err
+	}
+	if reserved == nil {
+		reserved = map[string]struct{}{}
+		ss.dropUploads[id] = reserved
+	}
+	reserved[afterPath] = struct{}{}
+
+	return afterPath, nil
+}
+
+// releaseDropUpload releases reservation of reserveDropUpload
+func (ss *SharingService) releaseDropUpload(id string, afterPath string) {
+	ss.linkMut.Lock()
+	defer ss.linkMut.Unlock()
+
+	delete(ss.dropUploads[id], afterPath)
+	if len(ss.dropUploads[id]) == 0 {
+		delete(ss.dropUploads, id)
+	}
+}
+
+// getAvailablePath returns path of file in folder which does not exist yet and is not reserved
+func (ss *SharingService) getAvailablePath(ctx context.Context, folder string, fileName string, reserved map[string]struct{}) (string, error) {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+
+	afterPath := folder + "/" + fileName
+	for i := 1; ; i++ {
+		if _, ok := reserved[afterPath]; ok {
+			afterPath = folder + "/" + base + " (" + fmt.Sprint(i) + ")" + ext
+			continue
+		}
+		exist, err := ss.isExistFile(ctx, afterPath)
+		if err != nil {
+			return "", errors.New("[SharingService.getAvailablePath] check file by path: " + err.Error())
+		}
+		if !exist {
+			return afterPath, nil
+		}
+		afterPath = folder + "/" + base + " (" + fmt.Sprint(i) + ")" + ext
+	}
+}
+
+// isExistFile checks file of path exists and is not deleted
+func (ss *SharingService) isExistFile(ctx context.Context, afterPath string) (bool, error) {
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return file.LatestHash != "", nil
+}
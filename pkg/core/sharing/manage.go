@@ -0,0 +1,314 @@
+package sharing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// only recent usages are kept in link, all usages are recorded in audit log
+const maxShareUsages = 100
+
+type userAgentKey struct{}
+
+// WithUserAgent returns context which has user agent of downloader, it is recorded with usage of link
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+func getUserAgent(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentKey{}).(string)
+	return userAgent
+}
+
+// ListLinks returns sharing links with their usages, filtered by owner and path in request.
+// Requester is "client:<uuid>", "user:<name>" or admin, only admin can list links of others.
+func (ss *SharingService) ListLinks(ctx context.Context, requester string, request *types.ListShareReq) (*types.ListShareRes, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.ListLinks")
+	defer span.End()
+
+	links, err := ss.sharingRepository.GetAllLinks(ctx)
+	if err != nil {
+		err = errors.New("[SharingService.ListLinks] get all links from repository: " + err.Error())
+		return nil, err
+	}
+
+	response := &types.ListShareRes{
+		Links: []types.ShareInfo{},
+	}
+	for _, sharing := range links {
+		if !isLinkOwner(&sharing, requester) {
+			continue
+		}
+		if request.Owner != "" && sharing.Owner != request.Owner {
+			continue
+		}
+		if request.AfterPath != "" && !isUnderPath(sharing.AfterPath, request.AfterPath) {
+			continue
+		}
+		response.Links = append(response.Links, toShareInfo(&sharing))
+	}
+	sort.Slice(response.Links, func(i, j int) bool {
+		return response.Links[i].CreatedAt.Before(response.Links[j].CreatedAt)
+	})
+
+	return response, nil
+}
+
+// RevokeLinks deletes sharing links which match all of the conditions in request and returns IDs of them.
+// Requester is "client:<uuid>", "user:<name>" or admin, links of others are skipped unless requester is admin.
+func (ss *SharingService) RevokeLinks(ctx context.Context, requester string, request *types.RevokeShareReq) (*types.RevokeShareRes, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.RevokeLinks")
+	defer span.End()
+
+	if len(request.IDs) == 0 && request.Owner == "" && request.AfterPath == "" && request.RootDir == "" {
+		return nil, errors.New("[SharingService.RevokeLinks] at least one of link ID, owner, path and root directory is required")
+	}
+
+	ids := map[string]bool{}
+	for _, link := range request.IDs {
+		ids[getLinkID(link)] = true
+	}
+
+	links, err := ss.sharingRepository.GetAllLinks(ctx)
+	if err != nil {
+		err = errors.New("[SharingService.RevokeLinks] get all links from repository: " + err.Error())
+		return nil, err
+	}
+
+	response := &types.RevokeShareRes{
+		IDs: []string{},
+	}
+	for _, sharing := range links {
+		if !isLinkOwner(&sharing, requester) {
+			continue
+		}
+		if len(ids) > 0 && !ids[sharing.ID] {
+			continue
+		}
+		if request.Owner != "" && sharing.Owner != request.Owner {
+			continue
+		}
+		if request.AfterPath != "" && !isUnderPath(sharing.AfterPath, request.AfterPath) {
+			continue
+		}
+		if request.RootDir != "" && !isUnderPath(sharing.AfterPath, "/"+strings.Trim(request.RootDir, "/")) {
+			continue
+		}
+
+		err = ss.revokeLink(ctx, &sharing, requester, "revoked by requester")
+		if err != nil {
+			err = errors.New("[SharingService.RevokeLinks] " + err.Error())
+			return nil, err
+		}
+		response.IDs = append(response.IDs, sharing.ID)
+	}
+
+	return response, nil
+}
+
+// CleanupLinks deletes links which are expired or used up, and links of which shared file,
+// directory or root directory is removed. It returns IDs of deleted links.
+func (ss *SharingService) CleanupLinks(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.CleanupLinks")
+	defer span.End()
+
+	links, err := ss.sharingRepository.GetAllLinks(ctx)
+	if err != nil {
+		err = errors.New("[SharingService.CleanupLinks] get all links from repository: " + err.Error())
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, sharing := range links {
+		reason, err := ss.getInvalidReason(ctx, &sharing)
+		if err != nil {
+			err = errors.New("[SharingService.CleanupLinks] check link " + sharing.ID + ": " + err.Error())
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+
+		err = ss.revokeLink(ctx, &sharing, "system", reason)
+		if err != nil {
+			err = errors.New("[SharingService.CleanupLinks] " + err.Error())
+			return nil, err
+		}
+		ids = append(ids, sharing.ID)
+	}
+
+	return ids, nil
+}
+
+// BackgroundCleanupLinks runs CleanupLinks periodically, so that links of removed files are revoked
+// even if they are not accessed
+func (ss *SharingService) BackgroundCleanupLinks(secInterval uint64) {
+	go func() {
+		for {
+			time.Sleep(time.Duration(secInterval) * time.Second)
+
+			ctx, span := tracing.Start(context.Background(), "SharingService.BackgroundCleanupLinks")
+			ids, err := ss.CleanupLinks(ctx)
+			if err != nil {
+				log.Println("quics err: ", err)
+				tracing.EndWithError(span, err)
+				continue
+			}
+			if len(ids) > 0 {
+				log.Println("quics: revoke invalid sharing links ", ids)
+			}
+			span.End()
+		}
+	}()
+}
+
+// getInvalidReason returns why link can not be used anymore, it is empty if link is valid
+func (ss *SharingService) getInvalidReason(ctx context.Context, sharing *types.Sharing) (string, error) {
+	if sharing.Count >= sharing.MaxCount && time.Since(sharing.LastUsedAt) > resumePeriod {
+		return "used up", nil
+	}
+	if !sharing.ExpiresAt.IsZero() && time.Now().After(sharing.ExpiresAt) {
+		return "expired", nil
+	}
+
+	rootDirName, _ := utils.GetNamesByAfterPath(sharing.AfterPath)
+	_, err := ss.syncRepository.GetRootDirByPath(ctx, "/"+rootDirName)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return "root directory is removed", nil
+	}
+	if err != nil {
+		return "", errors.New("get rootDir by path: " + err.Error())
+	}
+
+	// folder of drop link is created when first file is uploaded
+	if sharing.Drop {
+		return "", nil
+	}
+
+	if sharing.IsDir {
+		files, err := ss.getFilesInDir(ctx, sharing.AfterPath)
+		if err != nil {
+			return "", errors.New("get files in directory: " + err.Error())
+		}
+		if len(files) == 0 {
+			return "shared directory is removed", nil
+		}
+		return "", nil
+	}
+
+	exist, err := ss.isExistFile(ctx, sharing.AfterPath)
+	if err != nil {
+		return "", errors.New("check file by path: " + err.Error())
+	}
+	if !exist {
+		return "shared file is removed", nil
+	}
+	return "", nil
+}
+
+// revokeLink deletes link and records it to audit log
+func (ss *SharingService) revokeLink(ctx context.Context, sharing *types.Sharing, actor string, reason string) error {
+	err := ss.sharingRepository.DeleteLink(ctx, sharing.ID)
+	if err != nil {
+		return errors.New("delete link " + sharing.ID + " from repository: " + err.Error())
+	}
+	ss.auditService.Record(ctx, types.AuditRevokeShare, actor, sharing.AfterPath, fmt.Sprint("id: ", sharing.ID, ", reason: ", reason))
+
+	return nil
+}
+
+// countLinkUsage increases download count of link and records the downloader, ErrLinkNotFound is returned if link has been used up.
+// Link is read again under lock, so that concurrent usages are not lost nor exceed max count.
+// If resume is true, download which continues the counted download of the same client is not counted again.
+func (ss *SharingService) countLinkUsage(ctx context.Context, sharing *types.Sharing, afterPath string, resume bool) error {
+	ss.linkMut.Lock()
+	defer ss.linkMut.Unlock()
+
+	latest, err := ss.sharingRepository.GetLink(ctx, sharing.ID)
+	if err != nil {
+		return errors.New("[SharingService.countLinkUsage] get link from repository: " + err.Error())
+	}
+	*sharing = *latest
+
+	if resume && isContinuation(ctx, sharing, afterPath) {
+		return nil
+	}
+	if sharing.Count >= sharing.MaxCount {
+		return ErrLinkNotFound
+	}
+
+	sharing.Count++
+	sharing.LastUsedAt = time.Now()
+	sharing.Usages = append(sharing.Usages, types.ShareUsage{
+		Time:      sharing.LastUsedAt,
+		IP:        audit.SourceIP(ctx),
+		UserAgent: getUserAgent(ctx),
+		Path:      afterPath,
+	})
+	if len(sharing.Usages) > maxShareUsages {
+		sharing.Usages = sharing.Usages[len(sharing.Usages)-maxShareUsages:]
+	}
+
+	err = ss.sharingRepository.UpdateLink(ctx, sharing)
+	if err != nil {
+		return errors.New("[SharingService.countLinkUsage] update link from repository: " + err.Error())
+	}
+	ss.auditService.Record(ctx, types.AuditUseShare, "anonymous", afterPath, fmt.Sprint("id: ", sharing.ID, ", count: ", sharing.Count, "/", sharing.MaxCount))
+
+	return nil
+}
+
+// isContinuation checks the same client (IP and user agent) has downloaded the file of link during resumePeriod
+func isContinuation(ctx context.Context, sharing *types.Sharing, afterPath string) bool {
+	ip := audit.SourceIP(ctx)
+	userAgent := getUserAgent(ctx)
+	for _, usage := range sharing.Usages {
+		if usage.Path == afterPath && usage.IP == ip && usage.UserAgent == userAgent && time.Since(usage.Time) <= resumePeriod {
+			return true
+		}
+	}
+	return false
+}
+
+// isLinkOwner checks requester ("client:<uuid>", "user:<name>" or admin) can manage the link
+func isLinkOwner(sharing *types.Sharing, requester string) bool {
+	return requester == types.AdminMember || requester == sharing.Owner || requester == types.ClientMember(sharing.Owner)
+}
+
+// isUnderPath checks afterPath is the path or under it (e.g., /rootDir/a is under /rootDir but /rootDir2 is not)
+func isUnderPath(afterPath string, parent string) bool {
+	parent = strings.TrimSuffix(parent, "/")
+	return afterPath == parent || strings.HasPrefix(afterPath, parent+"/")
+}
+
+func toShareInfo(sharing *types.Sharing) types.ShareInfo {
+	return types.ShareInfo{
+		ID:          sharing.ID,
+		Link:        sharing.Link,
+		Owner:       sharing.Owner,
+		AfterPath:   sharing.AfterPath,
+		Timestamp:   sharing.Timestamp,
+		Latest:      sharing.Latest,
+		IsDir:       sharing.IsDir,
+		Drop:        sharing.Drop,
+		Count:       sharing.Count,
+		MaxCount:    sharing.MaxCount,
+		MaxSize:     sharing.MaxSize,
+		HasPassword: sharing.PasswordHash != "",
+		Note:        sharing.Note,
+		CreatedAt:   sharing.CreatedAt,
+		ExpiresAt:   sharing.ExpiresAt,
+		LastUsedAt:  sharing.LastUsedAt,
+		Usages:      sharing.Usages,
+	}
+}
@@ -1,24 +1,39 @@
 package sharing
 
 import (
+	"context"
 	"io"
 
 	"github.com/quic-s/quics/pkg/types"
 )
 
 type Repository interface {
-	SaveLink(sharing *types.Sharing) error
-	GetLink(link string) (*types.Sharing, error)
-	DeleteLink(link string) error
-	UpdateLink(*types.Sharing) error
+	SaveLink(ctx context.Context, sharing *types.Sharing) error
+	GetLink(ctx context.Context, id string) (*types.Sharing, error)
+	DeleteLink(ctx context.Context, id string) error
+	UpdateLink(ctx context.Context, sharing *types.Sharing) error
+	GetAllLinks(ctx context.Context) ([]types.Sharing, error)
+	ErrKeyNotFound() error
 }
 
 type Service interface {
-	CreateLink(request *types.ShareReq) (*types.ShareRes, error)
-	DeleteLink(request *types.StopShareReq) (*types.StopShareRes, error)
-	DownloadFile(uuid string, afterPath string) (*types.FileMetadata, io.Reader, error)
+	CreateLink(ctx context.Context, request *types.ShareReq) (*types.ShareRes, error)
+	DeleteLink(ctx context.Context, request *types.StopShareReq) (*types.StopShareRes, error)
+	DownloadFile(ctx context.Context, id string, password string, filePath string, resume bool) (*types.FileMetadata, io.Reader, error)
+	CountDownload(ctx context.Context, id string, filePath string, resume bool) error
+	GetSharedDir(ctx context.Context, id string, password string) (*types.SharedDir, error)
+	DownloadDir(ctx context.Context, id string, password string) (*types.SharedDir, error)
+	WriteArchive(ctx context.Context, sharedDir *types.SharedDir, format string, w io.Writer) error
+	CreateDropLink(ctx context.Context, requester string, request *types.DropReq) (*types.ShareRes, error)
+	GetDropInfo(ctx context.Context, id string, password string) (*types.DropInfo, error)
+	UploadDropFile(ctx context.Context, id string, password string, fileName string, size int64, fileContent io.Reader) (string, error)
+	ListLinks(ctx context.Context, requester string, request *types.ListShareReq) (*types.ListShareRes, error)
+	RevokeLinks(ctx context.Context, requester string, request *types.RevokeShareReq) (*types.RevokeShareRes, error)
+	CleanupLinks(ctx context.Context) ([]string, error)
+	BackgroundCleanupLinks(secInterval uint64)
 }
 
 type SyncDirAdapter interface {
-	GetFileFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	GetFileFromLatestDir(ctx context.Context, afterPath string) (*types.FileMetadata, io.Reader, error)
+	GetFileFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
 }
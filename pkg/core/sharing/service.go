@@ -1,74 +1,192 @@
 package sharing
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	stdsync "sync"
+	"time"
 
 	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/history"
+	"github.com/quic-s/quics/pkg/core/registration"
 	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+const (
+	// link ID is random token, so that link can not be guessed from client UUID or file path
+	linkIDLength = 16
+
+	// used up link is kept for a while, so that partial download of the last usage can be resumed
+	resumePeriod = time.Hour
+)
+
+var (
+	ErrLinkNotFound      = errors.New("link does not exist or is expired")
+	ErrPasswordRequired  = errors.New("download password is required")
+	ErrPasswordIncorrect = errors.New("download password is not correct")
+	ErrVersionNotFound   = errors.New("shared version of file no longer exists")
+	ErrIsDirectory       = errors.New("link shares directory, file path is required")
+	ErrIsNotDirectory    = errors.New("link does not share directory")
+	ErrFileTooLarge      = errors.New("file is larger than max size of link")
+	ErrInvalidFileName   = errors.New("file name is not valid")
 )
 
 type SharingService struct {
-	historyRepository history.Repository
-	syncRepository    sync.Repository
-	sharingRepository Repository
-	syncDir           SyncDirAdapter
+	registrationRepository registration.Repository
+	historyRepository      history.Repository
+	syncRepository         sync.Repository
+	sharingRepository      Repository
+	syncService            sync.Service
+	auditService           audit.Service
+	syncDir                SyncDirAdapter
+
+	// linkMut serializes count of links and reservations of drop uploads
+	linkMut     stdsync.Mutex
+	dropUploads map[string]map[string]struct{}
 }
 
-func NewService(historyRepository history.Repository, syncRepository sync.Repository, sharingRepository Repository, syncDir SyncDirAdapter) *SharingService {
+func NewService(registrationRepository registration.Repository, historyRepository history.Repository, syncRepository sync.Repository, sharingRepository Repository, syncService sync.Service, auditService audit.Service, syncDir SyncDirAdapter) *SharingService {
 	return &SharingService{
-		historyRepository: historyRepository,
-		syncRepository:    syncRepository,
-		sharingRepository: sharingRepository,
-		syncDir:           syncDir,
+		registrationRepository: registrationRepository,
+		historyRepository:      historyRepository,
+		syncRepository:         syncRepository,
+		sharingRepository:      sharingRepository,
+		syncService:            syncService,
+		auditService:           auditService,
+		syncDir:                syncDir,
+		dropUploads:            map[string]map[string]struct{}{},
 	}
 }
 
-func (ss *SharingService) CreateLink(request *types.ShareReq) (*types.ShareRes, error) {
-	// get file for creating link
-	file, err := ss.syncRepository.GetFileByPath(request.AfterPath)
-	if err != nil {
+func (ss *SharingService) CreateLink(ctx context.Context, request *types.ShareReq) (*types.ShareRes, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.CreateLink")
+	defer span.End()
+
+	afterPath := strings.TrimSuffix(request.AfterPath, "/")
+	if !strings.HasPrefix(afterPath, "/") || afterPath == "/" {
+		return nil, errors.New("[SharingService.CreateLink] invalid path: " + request.AfterPath)
+	}
+
+	// get file for creating link, directory is shared if there is no file of the path but files under it
+	isDir := false
+	rootDirName, _ := utils.GetNamesByAfterPath(afterPath)
+	rootDirKey := "/" + rootDirName
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		files, err := ss.getFilesInDir(ctx, afterPath)
+		if err != nil {
+			err = errors.New("[SharingService.CreateLink] get files in directory: " + err.Error())
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, errors.New("[SharingService.CreateLink] no such file or directory: " + afterPath)
+		}
+		isDir = true
+	} else if err != nil {
 		err = errors.New("[SharingService.CreateLink] get file by path: " + err.Error())
 		return nil, err
 	}
 
-	// get file history for UUID to find last edited person
-	fileHistory, err := ss.historyRepository.GetFileHistory(request.AfterPath, file.LatestSyncTimestamp)
+	// only owner or writer of root directory can share file
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, rootDirKey)
+	if err != nil {
+		err = errors.New("[SharingService.CreateLink] get rootDir by path: " + err.Error())
+		return nil, err
+	}
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
+	if err != nil {
+		err = errors.New("[SharingService.CreateLink] get client by uuid: " + err.Error())
+		return nil, err
+	}
+	if !types.CanWrite(rootDir.GetRole(client.UUID, client.OwnerUser)) {
+		return nil, errors.New("[SharingService.CreateLink] client does not have write permission on " + rootDir.AfterPath)
+	}
+
+	// pin version of the time of sharing if neither version nor latest is chosen,
+	// directory is always shared with latest files
+	latest := request.Latest || isDir
+	version := request.Version
+	if latest {
+		version = 0
+	} else {
+		if version == 0 {
+			version = file.LatestSyncTimestamp
+		}
+		_, err = ss.historyRepository.GetFileHistory(ctx, afterPath, version)
+		if err != nil {
+			err = errors.New("[SharingService.CreateLink] get file history of version " + fmt.Sprint(version) + ": " + err.Error())
+			return nil, err
+		}
+	}
+
+	id, err := randomHex(linkIDLength)
 	if err != nil {
-		err = errors.New("[SharingService.CreateLink] get file history: " + err.Error())
+		err = errors.New("[SharingService.CreateLink] generate link id: " + err.Error())
 		return nil, err
 	}
-	prefixLink := "https://" + config.GetRestServerAddress() + "/api/v1/download/files"
-	// make link
-	paramUUID := "?uuid=" + fileHistory.UUID
-	paramFile := "&file=" + file.AfterPath
-	link := prefixLink + paramUUID + paramFile
+	link := "https://" + config.GetRestServerAddress() + "/api/v1/download/files?id=" + id
 
 	// save link to database
 	sharing := &types.Sharing{
+		ID:       id,
 		Link:     link,
 		Count:    0,
 		MaxCount: uint(request.MaxCnt),
 		Owner:    request.UUID,
-		File:     *file,
+
+		AfterPath: afterPath,
+		Timestamp: version,
+		Latest:    latest,
+		IsDir:     isDir,
+
+		Note:      request.Note,
+		CreatedAt: time.Now(),
+	}
+	if request.ExpireSec > 0 {
+		sharing.ExpiresAt = sharing.CreatedAt.Add(time.Duration(request.ExpireSec) * time.Second)
+	}
+	if request.Password != "" {
+		sharing.PasswordHash, err = utils.HashPassword(request.Password)
+		if err != nil {
+			err = errors.New("[SharingService.CreateLink] hash password: " + err.Error())
+			return nil, err
+		}
 	}
 
-	err = ss.sharingRepository.SaveLink(sharing)
+	err = ss.sharingRepository.SaveLink(ctx, sharing)
 	if err != nil {
 		err = errors.New("[SharingService.CreateLink] save link to repository: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditCreateShare, request.UUID, afterPath, fmt.Sprint("id: ", id, ", directory: ", isDir, ", version: ", version, ", latest: ", latest, ", max count: ", request.MaxCnt, ", expire sec: ", request.ExpireSec, ", password: ", request.Password != ""))
 
 	return &types.ShareRes{
+		ID:   id,
 		Link: link,
 	}, nil
 }
 
-func (ss *SharingService) DeleteLink(request *types.StopShareReq) (*types.StopShareRes, error) {
+func (ss *SharingService) DeleteLink(ctx context.Context, request *types.StopShareReq) (*types.StopShareRes, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.DeleteLink")
+	defer span.End()
+
 	// get sharing data using link
-	sharing, err := ss.sharingRepository.GetLink(request.Link)
+	id := getLinkID(request.Link)
+	sharing, err := ss.sharingRepository.GetLink(ctx, id)
 	if err != nil {
 		err = errors.New("[SharingService.DeleteLink] get link from repository: " + err.Error())
 		return nil, err
@@ -80,56 +198,287 @@ func (ss *SharingService) DeleteLink(request *types.StopShareReq) (*types.StopSh
 	}
 
 	// delete link
-	err = ss.sharingRepository.DeleteLink(request.Link)
+	err = ss.sharingRepository.DeleteLink(ctx, id)
 	if err != nil {
 		err = errors.New("[SharingService.DeleteLink] delete link from repository: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditStopShare, request.UUID, sharing.AfterPath, "id: "+id)
 
 	return &types.StopShareRes{
 		UUID: request.UUID,
 	}, nil
 }
 
-func (ss *SharingService) DownloadFile(uuid string, afterPath string) (*types.FileMetadata, io.Reader, error) {
-	prefixLink := "https://" + config.GetRestServerAddress() + "/api/v1/download/files"
-	paramUUID := "?uuid=" + uuid
-	paramFile := "&file=" + afterPath
-	link := prefixLink + paramUUID + paramFile
+// DownloadFile returns shared file of link ID, filePath is the path of file in shared directory (only for directory link).
+// ErrLinkNotFound is returned when link does not exist, is expired or has been used up,
+// ErrPasswordRequired or ErrPasswordIncorrect is returned when download password of link does not match,
+// and ErrVersionNotFound is returned when the shared version (or latest file) no longer exists.
+// It does not count usage of link, CountDownload must be called before the file is sent.
+// If resume is true, used up link is also returned so that the last download can be continued.
+func (ss *SharingService) DownloadFile(ctx context.Context, id string, password string, filePath string, resume bool) (*types.FileMetadata, io.Reader, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.DownloadFile")
+	defer span.End()
 
-	// get sharing data using link
-	sharing, err := ss.sharingRepository.GetLink(link)
+	sharing, err := ss.getValidLink(ctx, id, password, false, resume)
 	if err != nil {
-		err = errors.New("[SharingService.DownloadFile] get link from repository: " + err.Error())
 		return nil, nil, err
 	}
 
-	// check if the link has been used up
-	if sharing.Count >= sharing.MaxCount {
-		err := ss.sharingRepository.DeleteLink(link)
+	afterPath, err := getSharedPath(sharing, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileInfo, fileContent, err := ss.getSharedFile(ctx, sharing, afterPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// name of history file has timestamp, name of shared file is used instead
+	fileInfo.Name = filepath.Base(afterPath)
+
+	return fileInfo, fileContent, nil
+}
+
+// CountDownload counts usage of link for file of DownloadFile, every response which sends the file must be counted.
+// If resume is true and the same client (IP and user agent) has downloaded the file during resumePeriod,
+// it continues the counted download and is not counted again.
+// ErrLinkNotFound is returned when link does not exist or has been used up.
+func (ss *SharingService) CountDownload(ctx context.Context, id string, filePath string, resume bool) error {
+	ctx, span := tracing.Start(ctx, "SharingService.CountDownload")
+	defer span.End()
+
+	sharing, err := ss.sharingRepository.GetLink(ctx, id)
+	if err == ss.sharingRepository.ErrKeyNotFound() {
+		return ErrLinkNotFound
+	}
+	if err != nil {
+		return errors.New("[SharingService.CountDownload] get link from repository: " + err.Error())
+	}
+	if sharing.Drop {
+		return ErrLinkNotFound
+	}
+
+	afterPath, err := getSharedPath(sharing, filePath)
+	if err != nil {
+		return err
+	}
+
+	return ss.countLinkUsage(ctx, sharing, afterPath, resume)
+}
+
+// GetSharedDir returns files in shared directory of link ID, it does not count usage of link
+func (ss *SharingService) GetSharedDir(ctx context.Context, id string, password string) (*types.SharedDir, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.GetSharedDir")
+	defer span.End()
+
+	sharing, err := ss.getValidLink(ctx, id, password, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if !sharing.IsDir {
+		return nil, ErrIsNotDirectory
+	}
+
+	return ss.getSharedDir(ctx, sharing)
+}
+
+// DownloadDir counts usage of directory link and returns files in shared directory to be written by WriteArchive
+func (ss *SharingService) DownloadDir(ctx context.Context, id string, password string) (*types.SharedDir, error) {
+	ctx, span := tracing.Start(ctx, "SharingService.DownloadDir")
+	defer span.End()
+
+	sharing, err := ss.getValidLink(ctx, id, password, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if !sharing.IsDir {
+		return nil, ErrIsNotDirectory
+	}
+
+	sharedDir, err := ss.getSharedDir(ctx, sharing)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ss.countLinkUsage(ctx, sharing, sharing.AfterPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return sharedDir, nil
+}
+
+// getValidLink returns link which is not expired nor used up, and checks password of it.
+// Drop link is only returned when drop is true, and download link only when drop is false.
+// If resume is true, used up link is also returned during resumePeriod after the last use,
+// so that the last download can be resumed (whether it is counted is checked by countLinkUsage).
+func (ss *SharingService) getValidLink(ctx context.Context, id string, password string, drop bool, resume bool) (*types.Sharing, error) {
+	if id == "" {
+		return nil, ErrLinkNotFound
+	}
+
+	// get sharing data using link
+	sharing, err := ss.sharingRepository.GetLink(ctx, id)
+	if err == ss.sharingRepository.ErrKeyNotFound() {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		err = errors.New("[SharingService.getValidLink] get link from repository: " + err.Error())
+		return nil, err
+	}
+	if sharing.Drop != drop {
+		return nil, ErrLinkNotFound
+	}
+
+	// revoke link if it has been used up or expired, or shared file has been removed
+	reason, err := ss.getInvalidReason(ctx, sharing)
+	if err != nil {
+		err = errors.New("[SharingService.getValidLink] check link: " + err.Error())
+		return nil, err
+	}
+	if reason != "" {
+		err := ss.revokeLink(ctx, sharing, "system", reason)
 		if err != nil {
-			err = errors.New("[SharingService.DownloadFile] delete link from repository: " + err.Error())
+			err = errors.New("[SharingService.getValidLink] " + err.Error())
+			return nil, err
+		}
+
+		return nil, ErrLinkNotFound
+	}
+	if sharing.Count >= sharing.MaxCount && !resume {
+		return nil, ErrLinkNotFound
+	}
+
+	if sharing.PasswordHash != "" {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		if !utils.ComparePassword(sharing.PasswordHash, password) {
+			return nil, ErrPasswordIncorrect
+		}
+	}
+
+	return sharing, nil
+}
+
+// getSharedPath returns path of shared file, filePath is the path of file in shared directory (only for directory link)
+func getSharedPath(sharing *types.Sharing, filePath string) (string, error) {
+	if !sharing.IsDir {
+		return sharing.AfterPath, nil
+	}
+	if filePath == "" {
+		return "", ErrIsDirectory
+	}
+	// path.Clean of rooted path removes "..", so that file out of shared directory can not be downloaded
+	return sharing.AfterPath + path.Clean("/"+filePath), nil
+}
+
+// getSharedFile resolves shared version of link, latest file in sync directory or pinned version in history directory
+func (ss *SharingService) getSharedFile(ctx context.Context, sharing *types.Sharing, afterPath string) (*types.FileMetadata, io.Reader, error) {
+	if sharing.Latest {
+		file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+		if err == ss.syncRepository.ErrKeyNotFound() || (err == nil && !file.ContentsExisted) {
+			return nil, nil, ErrVersionNotFound
+		}
+		if err != nil {
+			err = errors.New("[SharingService.getSharedFile] get file by path: " + err.Error())
 			return nil, nil, err
 		}
 
-		return nil, nil, errors.New("[SharingService.DownloadFile] link has been used up")
+		fileInfo, fileContent, err := ss.syncDir.GetFileFromLatestDir(ctx, afterPath)
+		if os.IsNotExist(err) {
+			return nil, nil, ErrVersionNotFound
+		}
+		if err != nil {
+			err = errors.New("[SharingService.getSharedFile] get file from latest dir: " + err.Error())
+			return nil, nil, err
+		}
+		return fileInfo, fileContent, nil
 	}
 
-	fileInfo, fileContent, err := ss.syncDir.GetFileFromHistoryDir(sharing.File.AfterPath, sharing.File.LatestSyncTimestamp)
+	_, err := ss.historyRepository.GetFileHistory(ctx, afterPath, sharing.Timestamp)
+	if err == ss.historyRepository.ErrKeyNotFound() {
+		return nil, nil, ErrVersionNotFound
+	}
 	if err != nil {
-		err = errors.New("[SharingService.DownloadFile] get file from history dir: " + err.Error())
+		err = errors.New("[SharingService.getSharedFile] get file history: " + err.Error())
 		return nil, nil, err
 	}
 
-	// increase count
-	sharing.Count++
-
-	// update link
-	err = ss.sharingRepository.UpdateLink(sharing)
+	fileInfo, fileContent, err := ss.syncDir.GetFileFromHistoryDir(ctx, afterPath, sharing.Timestamp)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrVersionNotFound
+	}
 	if err != nil {
-		err = errors.New("[SharingService.DownloadFile] update link from repository: " + err.Error())
+		err = errors.New("[SharingService.getSharedFile] get file from history dir: " + err.Error())
 		return nil, nil, err
 	}
-
 	return fileInfo, fileContent, nil
 }
+
+// getSharedDir returns existing files under shared directory, paths are relative to the directory
+func (ss *SharingService) getSharedDir(ctx context.Context, sharing *types.Sharing) (*types.SharedDir, error) {
+	files, err := ss.getFilesInDir(ctx, sharing.AfterPath)
+	if err != nil {
+		err = errors.New("[SharingService.getSharedDir] get files in directory: " + err.Error())
+		return nil, err
+	}
+
+	sharedDir := &types.SharedDir{
+		ID:        sharing.ID,
+		Name:      filepath.Base(sharing.AfterPath),
+		Note:      sharing.Note,
+		ExpiresAt: sharing.ExpiresAt,
+		AfterPath: sharing.AfterPath,
+		Files:     []types.SharedFile{},
+	}
+	for _, file := range files {
+		sharedDir.Files = append(sharedDir.Files, types.SharedFile{
+			Path:    strings.TrimPrefix(file.AfterPath, sharing.AfterPath+"/"),
+			Size:    file.Metadata.Size,
+			ModTime: file.Metadata.ModTime,
+		})
+	}
+	sort.Slice(sharedDir.Files, func(i, j int) bool {
+		return sharedDir.Files[i].Path < sharedDir.Files[j].Path
+	})
+
+	return sharedDir, nil
+}
+
+// getFilesInDir returns files which have contents under directory
+func (ss *SharingService) getFilesInDir(ctx context.Context, afterPath string) ([]types.File, error) {
+	files, err := ss.syncRepository.GetAllFiles(ctx, afterPath+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	existingFiles := []types.File{}
+	for _, file := range files {
+		if file.ContentsExisted {
+			existingFiles = append(existingFiles, file)
+		}
+	}
+	return existingFiles, nil
+}
+
+// getLinkID returns link ID from link (e.g., https://<addr>/api/v1/download/files?id=<ID>) or link ID itself
+func getLinkID(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err == nil {
+		if id := parsedURL.Query().Get("id"); id != "" {
+			return id
+		}
+	}
+	return link
+}
+
+func randomHex(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+// GetAllConflicts returns open conflicts of all root directories, or of rootDirPath if it is not empty
+func (ss *SyncService) GetAllConflicts(ctx context.Context, rootDirPath string) ([]types.ConflictInfo, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetAllConflicts")
+	defer span.End()
+
+	rootDirs := []string{}
+	if rootDirPath != "" {
+		rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, rootDirPath)
+		if err == ss.syncRepository.ErrKeyNotFound() {
+			return nil, ErrRootDirNotFound
+		}
+		if err != nil {
+			err = errors.New("[SyncService.GetAllConflicts] get rootDir data by path: " + err.Error())
+			return nil, err
+		}
+		rootDirs = append(rootDirs, rootDir.AfterPath+"/")
+	} else {
+		rootDirList, err := ss.syncRepository.GetAllRootDir(ctx)
+		if err != nil {
+			err = errors.New("[SyncService.GetAllConflicts] get all rootDir data: " + err.Error())
+			return nil, err
+		}
+		for _, rootDir := range rootDirList {
+			rootDirs = append(rootDirs, rootDir.AfterPath+"/")
+		}
+	}
+
+	conflicts, err := ss.syncRepository.GetConflictList(ctx, rootDirs)
+	if err != nil {
+		err = errors.New("[SyncService.GetAllConflicts] get conflict list using repository: " + err.Error())
+		return nil, err
+	}
+
+	conflictInfos := []types.ConflictInfo{}
+	for _, conflict := range conflicts {
+		conflictInfo, err := ss.GetConflictInfo(ctx, conflict.AfterPath)
+		if err != nil {
+			// conflict can be resolved while listing
+			log.Println("quics err: [SyncService.GetAllConflicts] get conflict info: ", err)
+			continue
+		}
+		conflictInfos = append(conflictInfos, *conflictInfo)
+	}
+	sort.Slice(conflictInfos, func(i, j int) bool {
+		return conflictInfos[i].AfterPath < conflictInfos[j].AfterPath
+	})
+
+	return conflictInfos, nil
+}
+
+// GetConflictInfo returns conflicted file with its candidates sorted by side, "server" is the first
+func (ss *SyncService) GetConflictInfo(ctx context.Context, afterPath string) (*types.ConflictInfo, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetConflictInfo")
+	defer span.End()
+
+	file, err := ss.getConflictedFile(ctx, afterPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conflictInfo := &types.ConflictInfo{
+		AfterPath:           file.AfterPath,
+		RootDir:             file.RootDirKey,
+		LatestHash:          file.LatestHash,
+		LatestSyncTimestamp: file.LatestSyncTimestamp,
+		Candidates:          []types.ConflictCandidate{},
+	}
+	for side, stagingFile := range file.Conflict.StagingFiles {
+		uploaded := file.ContentsExisted && file.LatestHash != ""
+		if side != "server" {
+			_, err := ss.syncDirAdapter.GetFileInfoFromConflictDir(ctx, file.AfterPath, side)
+			uploaded = err == nil
+		}
+		conflictInfo.Candidates = append(conflictInfo.Candidates, types.ConflictCandidate{
+			Side:      side,
+			UUID:      stagingFile.UUID,
+			Timestamp: stagingFile.Timestamp,
+			Hash:      stagingFile.Hash,
+			Size:      stagingFile.File.Size,
+			ModTime:   stagingFile.File.ModTime,
+			Uploaded:  uploaded,
+		})
+	}
+	sort.Slice(conflictInfo.Candidates, func(i, j int) bool {
+		if conflictInfo.Candidates[i].Side == "server" || conflictInfo.Candidates[j].Side == "server" {
+			return conflictInfo.Candidates[i].Side == "server"
+		}
+		return conflictInfo.Candidates[i].Side < conflictInfo.Candidates[j].Side
+	})
+
+	return conflictInfo, nil
+}
+
+// OpenConflictCandidate opens contents of candidate, "server" candidate is the latest file and others are in {rootDir}.conflict
+func (ss *SyncService) OpenConflictCandidate(ctx context.Context, afterPath string, side string) (*types.FileMetadata, io.Reader, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.OpenConflictCandidate")
+	defer span.End()
+
+	file, err := ss.getConflictedFile(ctx, afterPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, exists := file.Conflict.StagingFiles[side]; !exists {
+		return nil, nil, ErrCandidateNotFound
+	}
+
+	if side == "server" {
+		// removed file does not have contents
+		if !file.ContentsExisted || file.LatestHash == "" {
+			return nil, nil, ErrCandidateNotUploaded
+		}
+		fileMetadata, fileContent, err := ss.syncDirAdapter.GetFileFromLatestDir(ctx, file.AfterPath)
+		if err != nil {
+			err = errors.New("[SyncService.OpenConflictCandidate] get file from latestDir: " + err.Error())
+			return nil, nil, err
+		}
+		return fileMetadata, fileContent, nil
+	}
+
+	fileMetadata, fileContent, err := ss.syncDirAdapter.GetFileFromConflictDir(ctx, file.AfterPath, side)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrCandidateNotUploaded
+	}
+	if err != nil {
+		err = errors.New("[SyncService.OpenConflictCandidate] get file from conflictDir: " + err.Error())
+		return nil, nil, err
+	}
+	return fileMetadata, fileContent, nil
+}
+
+// ResolveConflict resolves conflicted file with candidate as CHOOSEONE of client does, requester needs write permission.
+// Selected candidate is saved as new version and sent to clients of root directory with FORCESYNC.
+func (ss *SyncService) ResolveConflict(ctx context.Context, requester string, request *types.ConflictResolveReq) error {
+	ctx, span := tracing.Start(ctx, "SyncService.ResolveConflict")
+	defer span.End()
+
+	log.Println("quics: ResolveConflict: ", request.AfterPath, " (", request.Side, ") by ", requester)
+
+	file, err := ss.getConflictedFile(ctx, request.AfterPath)
+	if err != nil {
+		return err
+	}
+
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+	if err != nil {
+		err = errors.New("[SyncService.ResolveConflict] get rootDir data by path: " + err.Error())
+		return err
+	}
+	role, err := ss.GetRequesterRole(ctx, rootDir, requester)
+	if err != nil {
+		err = errors.New("[SyncService.ResolveConflict] get requester role: " + err.Error())
+		return err
+	}
+	if !types.CanWrite(role) {
+		return ErrPermissionDenied
+	}
+
+	if _, exists := file.Conflict.StagingFiles[request.Side]; !exists {
+		return ErrCandidateNotFound
+	}
+	if request.Side != "server" {
+		_, err = ss.syncDirAdapter.GetFileInfoFromConflictDir(ctx, file.AfterPath, request.Side)
+		if err != nil {
+			return ErrCandidateNotUploaded
+		}
+	}
+
+	err = ss.chooseOne(ctx, file, request.Side, requester)
+	if err != nil {
+		err = errors.New("[SyncService.ResolveConflict] choose one: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// getConflictedFile returns file which has conflict that is not resolved yet
+func (ss *SyncService) getConflictedFile(ctx context.Context, afterPath string) (*types.File, error) {
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return nil, ErrConflictNotFound
+	}
+	if err != nil {
+		return nil, errors.New("[SyncService.getConflictedFile] get file data by path: " + err.Error())
+	}
+	if reflect.ValueOf(file.Conflict).IsZero() {
+		return nil, ErrConflictNotFound
+	}
+	return file, nil
+}
@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/quic-s/quics/pkg/core/registration"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+var errNotFound = errors.New("key not found")
+
+type fakeSyncRepository struct {
+	Repository
+	rootDirs map[string]*types.RootDirectory
+	files    map[string]*types.File
+	updated  int
+}
+
+func (r *fakeSyncRepository) GetRootDirByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error) {
+	rootDir, ok := r.rootDirs[afterPath]
+	if !ok {
+		return nil, errNotFound
+	}
+	copied := *rootDir
+	return &copied, nil
+}
+
+func (r *fakeSyncRepository) GetFileByPath(ctx context.Context, afterPath string) (*types.File, error) {
+	file, ok := r.files[afterPath]
+	if !ok {
+		return nil, errNotFound
+	}
+	copied := *file
+	return &copied, nil
+}
+
+func (r *fakeSyncRepository) UpdateFile(ctx context.Context, file *types.File) error {
+	r.updated++
+	return nil
+}
+
+func (r *fakeSyncRepository) ErrKeyNotFound() error {
+	return errNotFound
+}
+
+type fakeRegistrationRepository struct {
+	registration.Repository
+	clients map[string]*types.Client
+}
+
+func (r *fakeRegistrationRepository) GetClientByUUID(ctx context.Context, uuid string) (*types.Client, error) {
+	client, ok := r.clients[uuid]
+	if !ok {
+		return nil, errNotFound
+	}
+	return client, nil
+}
+
+// fakeSyncDirAdapter counts writes of contents, which must not happen for denied requests
+type fakeSyncDirAdapter struct {
+	SyncDirAdapter
+	written int
+}
+
+func (a *fakeSyncDirAdapter) SaveFileToHistoryDir(ctx context.Context, afterPath string, timestamp uint64, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+	a.written++
+	return nil
+}
+
+func (a *fakeSyncDirAdapter) SaveFileToConflictDir(ctx context.Context, uuid string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+	a.written++
+	return nil
+}
+
+// newPermissionTestService makes service with root directory /rootDir which has owner, reader and outsider clients.
+// File /rootDir/a.txt has pending contents given by the client of uuid.
+func newPermissionTestService(uuid string) (*SyncService, *fakeSyncRepository, *fakeSyncDirAdapter) {
+	syncRepository := &fakeSyncRepository{
+		rootDirs: map[string]*types.RootDirectory{
+			"/rootDir": {
+				AfterPath:  "/rootDir",
+				Owner:      "owner",
+				UUIDs:      []string{"owner", "reader"},
+				ACL:        map[string]string{types.ClientMember("reader"): types.RootRoleReader, types.ClientMember("outsider"): types.RootRoleWriter},
+				Restricted: true,
+			},
+		},
+		files: map[string]*types.File{
+			"/rootDir/a.txt": {
+				AfterPath:           "/rootDir/a.txt",
+				RootDirKey:          "/rootDir",
+				LatestHash:          "hash",
+				LatestSyncTimestamp: 1,
+				LatestEditClient:    uuid,
+				ContentsExisted:     false,
+			},
+		},
+	}
+	registrationRepository := &fakeRegistrationRepository{
+		clients: map[string]*types.Client{
+			"owner":    {UUID: "owner"},
+			"reader":   {UUID: "reader"},
+			"outsider": {UUID: "outsider"},
+		},
+	}
+	syncDirAdapter := &fakeSyncDirAdapter{}
+
+	ss := NewService(registrationRepository, nil, syncRepository, nil, nil, nil, syncDirAdapter).(*SyncService)
+	return ss, syncRepository, syncDirAdapter
+}
+
+func TestPleaseSyncRequiresWritePermission(t *testing.T) {
+	for _, uuid := range []string{"reader", "outsider"} {
+		ss, syncRepository, _ := newPermissionTestService(uuid)
+
+		_, err := ss.UpdateFileWithoutContents(context.Background(), &types.PleaseSyncReq{
+			UUID:                uuid,
+			AfterPath:           "/rootDir/a.txt",
+			LastUpdateTimestamp: 2,
+			LastUpdateHash:      "new hash",
+			LastSyncHash:        "hash",
+		})
+		if err == nil {
+			t.Fatalf("PLEASESYNC of %s: got nil error", uuid)
+		}
+		if syncRepository.updated != 0 {
+			t.Fatalf("PLEASESYNC of %s: file is updated %d times, want 0", uuid, syncRepository.updated)
+		}
+	}
+}
+
+func TestPleaseTakeRequiresWritePermission(t *testing.T) {
+	for _, uuid := range []string{"reader", "outsider"} {
+		ss, syncRepository, syncDirAdapter := newPermissionTestService(uuid)
+
+		_, err := ss.UpdateFileWithContents(context.Background(), &types.PleaseTakeReq{
+			UUID:      uuid,
+			AfterPath: "/rootDir/a.txt",
+		}, &types.FileMetadata{Name: "a.txt", Size: 4}, strings.NewReader("evil"))
+		if err == nil {
+			t.Fatalf("PLEASETAKE of %s: got nil error", uuid)
+		}
+		if syncDirAdapter.written != 0 || syncRepository.updated != 0 {
+			t.Fatalf("PLEASETAKE of %s: contents are written %d times and file is updated %d times, want 0", uuid, syncDirAdapter.written, syncRepository.updated)
+		}
+	}
+}
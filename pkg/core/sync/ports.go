@@ -1,76 +1,95 @@
 package sync
 
 import (
+	"context"
 	"io"
 
 	"github.com/quic-s/quics/pkg/types"
 )
 
 type Repository interface {
-	SaveRootDir(afterPath string, rootDir *types.RootDirectory) error
-	GetRootDirByPath(afterPath string) (*types.RootDirectory, error)
-	GetAllRootDir() ([]types.RootDirectory, error)
+	SaveRootDir(ctx context.Context, afterPath string, rootDir *types.RootDirectory) error
+	GetRootDirByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error)
+	GetAllRootDir(ctx context.Context) ([]types.RootDirectory, error)
 
-	IsExistFileByPath(afterPath string) (bool, error)
-	SaveFileByPath(afterPath string, file *types.File) error
-	GetFileByPath(afterPath string) (*types.File, error)
-	UpdateFile(file *types.File) error
-	GetAllFiles(prefix string) ([]types.File, error)
+	IsExistFileByPath(ctx context.Context, afterPath string) (bool, error)
+	SaveFileByPath(ctx context.Context, afterPath string, file *types.File) error
+	GetFileByPath(ctx context.Context, afterPath string) (*types.File, error)
+	UpdateFile(ctx context.Context, file *types.File) error
+	GetAllFiles(ctx context.Context, prefix string) ([]types.File, error)
 
-	UpdateConflict(afterpath string, conflict *types.Conflict) error
-	GetConflict(afterpath string) (*types.Conflict, error)
-	GetConflictList(rootDirs []string) ([]types.Conflict, error)
-	DeleteConflict(afterpath string) error
+	UpdateConflict(ctx context.Context, afterpath string, conflict *types.Conflict) error
+	GetConflict(ctx context.Context, afterpath string) (*types.Conflict, error)
+	GetConflictList(ctx context.Context, rootDirs []string) ([]types.Conflict, error)
+	DeleteConflict(ctx context.Context, afterpath string) error
 
 	ErrKeyNotFound() error
 }
 
 type Service interface {
-	RegisterRootDir(request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error)
-	SyncRootDir(request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error)
-	GetRootDirList() (*types.AskRootDirRes, error)
-	GetRootDirByPath(afterPath string) (*types.RootDirectory, error)
-	DisconnectRootDir(request *types.DisconnectRootDirReq) (*types.DisconnectRootDirRes, error)
-
-	UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSyncReq) (*types.PleaseSyncRes, error)
-	UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.PleaseTakeRes, error)
-	CallMustSync(filePath string, UUIDs []string) error
-
-	GetConflictList(*types.AskConflictListReq) (*types.AskConflictListRes, error)
-	ChooseOne(request *types.PleaseFileReq) (*types.PleaseFileRes, error)
-	CallForceSync(filePath string, UUIDs []string) error
-
-	FullScan(uuid string) error
+	RegisterRootDir(ctx context.Context, request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error)
+	SyncRootDir(ctx context.Context, request *types.RootDirRegisterReq, ip string) (*types.RootDirRegisterRes, error)
+	GetRootDirList(ctx context.Context) (*types.AskRootDirRes, error)
+	GetRootDirByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error)
+	DisconnectRootDir(ctx context.Context, request *types.DisconnectRootDirReq) (*types.DisconnectRootDirRes, error)
+	InviteMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error)
+	GetRequesterRole(ctx context.Context, rootDir *types.RootDirectory, requester string) (string, error)
+	RevokeMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error)
+
+	UpdateFileWithoutContents(ctx context.Context, pleaseSyncReq *types.PleaseSyncReq) (*types.PleaseSyncRes, error)
+	UpdateFileWithContents(ctx context.Context, pleaseTakeReq *types.PleaseTakeReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.PleaseTakeRes, error)
+	CommitFile(ctx context.Context, actor string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.File, error)
+	DeleteFile(ctx context.Context, actor string, afterPath string) (*types.File, error)
+	MoveFile(ctx context.Context, actor string, oldPath string, newPath string) (*types.File, error)
+	UploadFile(ctx context.Context, requester string, request *types.FileUploadReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.FileUploadRes, error)
+	UploadFiles(ctx context.Context, requester string, uploads []Upload) ([]types.FileUploadRes, error)
+	CheckUpload(ctx context.Context, requester string, request *types.FileUploadReq) error
+	CallMustSync(ctx context.Context, filePath string, UUIDs []string) error
+	CallQueuedMustSync(ctx context.Context, UUID string) error
+	ClearQueuedMustSync(ctx context.Context, UUID string)
+
+	GetConflictList(ctx context.Context, request *types.AskConflictListReq) (*types.AskConflictListRes, error)
+	ChooseOne(ctx context.Context, request *types.PleaseFileReq) (*types.PleaseFileRes, error)
+	CallForceSync(ctx context.Context, filePath string, UUIDs []string) error
+	GetAllConflicts(ctx context.Context, rootDirPath string) ([]types.ConflictInfo, error)
+	GetConflictInfo(ctx context.Context, afterPath string) (*types.ConflictInfo, error)
+	OpenConflictCandidate(ctx context.Context, afterPath string, side string) (*types.FileMetadata, io.Reader, error)
+	ResolveConflict(ctx context.Context, requester string, request *types.ConflictResolveReq) error
+
+	FullScan(ctx context.Context, uuid string) error
 	BackgroundFullScan(interval uint64) error
-	Rescan(*types.RescanReq) (*types.RescanRes, error)
+	Rescan(ctx context.Context, request *types.RescanReq) (*types.RescanRes, error)
+	GetPendingSyncNum(ctx context.Context) int64
+	GetFullScanResults(ctx context.Context) []types.FullScanResult
 
-	GetFilesByRootDir(rootDirPath string) []types.File
-	GetFiles() []types.File
-	GetFileByPath(afterPath string) (*types.File, error)
+	GetFilesByRootDir(ctx context.Context, rootDirPath string) []types.File
+	GetFiles(ctx context.Context) []types.File
+	GetFileByPath(ctx context.Context, afterPath string) (*types.File, error)
 
-	RollbackFileByHistory(request *types.RollBackReq) (*types.RollBackRes, error)
+	RollbackFileByHistory(ctx context.Context, request *types.RollBackReq) (*types.RollBackRes, error)
 
-	DownloadHistory(request *types.DownloadHistoryReq) (*types.DownloadHistoryRes, string, error)
+	DownloadHistory(ctx context.Context, request *types.DownloadHistoryReq) (*types.DownloadHistoryRes, string, error)
 
-	GetStagingNum(request *types.AskStagingNumReq) (*types.AskStagingNumRes, error)
-	GetConflictFiles(request *types.AskStagingNumReq) ([]types.ConflictDownloadReq, error)
+	GetStagingNum(ctx context.Context, request *types.AskStagingNumReq) (*types.AskStagingNumRes, error)
+	GetConflictFiles(ctx context.Context, request *types.AskStagingNumReq) ([]types.ConflictDownloadReq, error)
 }
 
 type SyncDirAdapter interface {
-	SaveFileToLatestDir(afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error
-	GetFileFromLatestDir(afterPath string) (*types.FileMetadata, io.Reader, error)
-	DeleteFileFromLatestDir(afterPath string) error
-	SaveFileToConflictDir(uuid string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error
-	GetFileFromConflictDir(afterPath string, uuid string) (*types.FileMetadata, io.Reader, error)
-	GetFileInfoFromConflictDir(afterPath string, uuid string) (*types.FileMetadata, error)
-	DeleteFilesFromConflictDir(afterPath string) error
-	SaveFileToHistoryDir(afterPath string, timestamp uint64, fileMetadata *types.FileMetadata, fileContent io.Reader) error
-	GetFileFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
-	GetFileInfoFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, error)
+	SaveFileToLatestDir(ctx context.Context, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error
+	GetFileFromLatestDir(ctx context.Context, afterPath string) (*types.FileMetadata, io.Reader, error)
+	DeleteFileFromLatestDir(ctx context.Context, afterPath string) error
+	SaveFileToConflictDir(ctx context.Context, uuid string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error
+	GetFileFromConflictDir(ctx context.Context, afterPath string, uuid string) (*types.FileMetadata, io.Reader, error)
+	GetFileInfoFromConflictDir(ctx context.Context, afterPath string, uuid string) (*types.FileMetadata, error)
+	DeleteFilesFromConflictDir(ctx context.Context, afterPath string) error
+	SaveFileToHistoryDir(ctx context.Context, afterPath string, timestamp uint64, fileMetadata *types.FileMetadata, fileContent io.Reader) error
+	GetFileFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error)
+	GetFileInfoFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, error)
 }
 
 type NetworkAdapter interface {
-	OpenTransaction(transactionName string, uuid string) (Transaction, error)
+	OpenTransaction(ctx context.Context, transactionName string, uuid string) (Transaction, error)
+	IsOnline(uuid string) bool
 }
 
 type Transaction interface {
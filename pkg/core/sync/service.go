@@ -2,50 +2,96 @@ package sync
 
 import (
 	"context"
+	"crypto/sha1"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/history"
+	"github.com/quic-s/quics/pkg/core/lockout"
 	"github.com/quic-s/quics/pkg/core/registration"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 	"github.com/quic-s/quics/pkg/utils"
 	"golang.org/x/exp/slices"
 )
 
+var (
+	ErrInvalidPath          = errors.New("path is not a file path in root directory")
+	ErrRootDirNotFound      = errors.New("root directory does not exist")
+	ErrPermissionDenied     = errors.New("requester does not have write permission on root directory")
+	ErrNotOwner             = errors.New("only owner of root directory can manage members")
+	ErrFileConflicted       = errors.New("file is conflicted, resolve the conflict first")
+	ErrVersionMismatch      = errors.New("file is not at the expected version")
+	ErrContentsPending      = errors.New("contents of the latest version are not received from client yet, try again later")
+	ErrConflictNotFound     = errors.New("file is not conflicted")
+	ErrCandidateNotFound    = errors.New("candidate does not exist in conflict")
+	ErrCandidateNotUploaded = errors.New("contents of candidate are not uploaded yet")
+)
+
 type SyncService struct {
 	cancelMut              sync.RWMutex
 	cancel                 map[string]context.CancelFunc
+	pendingSyncNum         atomic.Int64
+	fullScanMut            sync.RWMutex
+	fullScanResults        map[string]types.FullScanResult
+	queuedMut              sync.Mutex
+	queuedMustSync         map[string]map[string]struct{} // UUID of offline client -> file paths, lost on restart (fullscan and SYNCROOTDIR of client catch up)
+	lockNum                uint8
+	pathMut                map[byte]*sync.Mutex // locked by hash value of file path while file is committed
 	FSTrigger              chan string
 	registrationRepository registration.Repository
 	historyRepository      history.Repository
 	syncRepository         Repository
+	lockoutService         lockout.Service
+	auditService           audit.Service
 	networkAdapter         NetworkAdapter
 	syncDirAdapter         SyncDirAdapter
 }
 
-func NewService(registrationRepository registration.Repository, historyRepository history.Repository, syncRepository Repository, networkAdapter NetworkAdapter, syncDirAdpater SyncDirAdapter) Service {
+func NewService(registrationRepository registration.Repository, historyRepository history.Repository, syncRepository Repository, lockoutService lockout.Service, auditService audit.Service, networkAdapter NetworkAdapter, syncDirAdpater SyncDirAdapter) Service {
+	lockNum := uint8(32)
+	pathMut := map[byte]*sync.Mutex{}
+	for i := uint8(0); i < lockNum; i++ {
+		pathMut[i] = &sync.Mutex{}
+	}
+
 	return &SyncService{
 		cancelMut:              sync.RWMutex{},
 		cancel:                 map[string]context.CancelFunc{},
+		fullScanMut:            sync.RWMutex{},
+		fullScanResults:        map[string]types.FullScanResult{},
+		queuedMut:              sync.Mutex{},
+		queuedMustSync:         map[string]map[string]struct{}{},
+		lockNum:                lockNum,
+		pathMut:                pathMut,
 		FSTrigger:              make(chan string),
 		registrationRepository: registrationRepository,
 		historyRepository:      historyRepository,
 		syncRepository:         syncRepository,
+		lockoutService:         lockoutService,
+		auditService:           auditService,
 		networkAdapter:         networkAdapter,
 		syncDirAdapter:         syncDirAdpater,
 	}
 }
 
 // RegisterRootDir registers initial root directory to client database
-func (ss *SyncService) RegisterRootDir(request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error) {
+func (ss *SyncService) RegisterRootDir(ctx context.Context, request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.RegisterRootDir")
+	defer span.End()
+
 	log.Println("quics: RegisterRootDir: ", request)
-	_, err := ss.syncRepository.GetRootDirByPath(request.AfterPath)
+	_, err := ss.syncRepository.GetRootDirByPath(ctx, request.AfterPath)
 	if err == nil {
 		return nil, errors.New("[SyncService.RegisterRootDir] root dir is already exists")
 	} else if err != ss.syncRepository.ErrKeyNotFound() && err != nil {
@@ -54,7 +100,7 @@ func (ss *SyncService) RegisterRootDir(request *types.RootDirRegisterReq) (*type
 	}
 
 	// get client entity by uuid in request data
-	client, err := ss.registrationRepository.GetClientByUUID(request.UUID)
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		err = errors.New("[SyncService.RegisterRootDir] get client data by uuid: " + err.Error())
 		return nil, err
@@ -63,30 +109,38 @@ func (ss *SyncService) RegisterRootDir(request *types.RootDirRegisterReq) (*type
 	UUIDs := make([]string, 0)
 	UUIDs = append(UUIDs, request.UUID)
 
+	passwordHash, err := utils.HashPassword(request.RootDirPassword)
+	if err != nil {
+		err = errors.New("[SyncService.RegisterRootDir] hash root directory password: " + err.Error())
+		return nil, err
+	}
+
 	// create root directory entity
 	rootDir := &types.RootDirectory{
 		BeforePath: utils.GetQuicsSyncDirPath(),
 		AfterPath:  request.AfterPath,
 		Owner:      client.UUID,
-		Password:   request.RootDirPassword,
+		OwnerUser:  client.OwnerUser,
+		Password:   passwordHash,
 		UUIDs:      UUIDs,
 	}
 	rootDirs := append(client.Root, *rootDir)
 	client.Root = rootDirs
 
 	// save updated client entity
-	err = ss.registrationRepository.SaveClient(client.UUID, client)
+	err = ss.registrationRepository.SaveClient(ctx, client.UUID, client)
 	if err != nil {
 		err = errors.New("[SyncService.RegisterRootDir] save client using repository: " + err.Error())
 		return nil, err
 	}
 
 	// save requested root directory
-	err = ss.syncRepository.SaveRootDir(request.AfterPath, rootDir)
+	err = ss.syncRepository.SaveRootDir(ctx, request.AfterPath, rootDir)
 	if err != nil {
 		err = errors.New("[SyncService.RegisterRootDir] save rootDir using repository: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditRegisterRootDir, request.UUID, request.AfterPath, "")
 
 	return &types.RootDirRegisterRes{
 		UUID: request.UUID,
@@ -94,30 +148,46 @@ func (ss *SyncService) RegisterRootDir(request *types.RootDirRegisterReq) (*type
 }
 
 // SyncRootDir syncs root directory to other client from owner client
-func (ss *SyncService) SyncRootDir(request *types.RootDirRegisterReq) (*types.RootDirRegisterRes, error) {
+func (ss *SyncService) SyncRootDir(ctx context.Context, request *types.RootDirRegisterReq, ip string) (*types.RootDirRegisterRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.SyncRootDir")
+	defer span.End()
+
 	log.Println("quics: SyncRootDir: ", request)
-	client, err := ss.registrationRepository.GetClientByUUID(request.UUID)
+	err := ss.lockoutService.Check(ctx, ip, request.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.SyncRootDir] " + err.Error())
+		return nil, err
+	}
+
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		err = errors.New("[SyncService.SyncRootDir] get client data by uuid: " + err.Error())
 		return nil, err
 	}
 
-	rootDir, err := ss.syncRepository.GetRootDirByPath(request.AfterPath)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.SyncRootDir] get rootDir data by path: " + err.Error())
 		return nil, err
 	}
 
 	// password check
-	if rootDir.Password != request.RootDirPassword {
+	if !utils.ComparePassword(rootDir.Password, request.RootDirPassword) {
+		ss.lockoutService.RecordFailure(ctx, types.SYNCROOTDIR, ip, request.UUID)
 		return nil, errors.New("[SyncService.SyncRootDir] root directory password is not correct")
 	}
+	ss.lockoutService.RecordSuccess(ctx, request.UUID)
+
+	// member check, password gives the role until owner manages members
+	if rootDir.GetRole(client.UUID, client.OwnerUser) == "" && rootDir.PasswordRole() == "" {
+		return nil, errors.New("[SyncService.SyncRootDir] client is not a member of root directory")
+	}
 
 	if !slices.Contains[[]string, string](rootDir.UUIDs, client.UUID) {
 		// add client UUID to root directory
 		rootDir.UUIDs = append(rootDir.UUIDs, client.UUID)
 	}
-	err = ss.syncRepository.SaveRootDir(rootDir.AfterPath, rootDir)
+	err = ss.syncRepository.SaveRootDir(ctx, rootDir.AfterPath, rootDir)
 	if err != nil {
 		err = errors.New("[SyncService.SyncRootDir] save rootDir using repository: " + err.Error())
 		return nil, err
@@ -131,11 +201,12 @@ func (ss *SyncService) SyncRootDir(request *types.RootDirRegisterReq) (*types.Ro
 	}
 
 	// save updated client entity with new root directory
-	err = ss.registrationRepository.SaveClient(client.UUID, client)
+	err = ss.registrationRepository.SaveClient(ctx, client.UUID, client)
 	if err != nil {
 		err = errors.New("[SyncService.SyncRootDir] save client using repository: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditSyncRootDir, request.UUID, request.AfterPath, "")
 
 	response := &types.RootDirRegisterRes{
 		UUID: request.UUID,
@@ -145,9 +216,12 @@ func (ss *SyncService) SyncRootDir(request *types.RootDirRegisterReq) (*types.Ro
 }
 
 // GetRootDirList gets root directory list of client
-func (ss *SyncService) GetRootDirList() (*types.AskRootDirRes, error) {
+func (ss *SyncService) GetRootDirList(ctx context.Context) (*types.AskRootDirRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetRootDirList")
+	defer span.End()
+
 	log.Println("quics: GetRootDirList")
-	rootDirs, err := ss.syncRepository.GetAllRootDir()
+	rootDirs, err := ss.syncRepository.GetAllRootDir(ctx)
 	if err != nil {
 		err = errors.New("[SyncService.GetRootDirList] get all rootDir: " + err.Error())
 		return nil, err
@@ -165,9 +239,12 @@ func (ss *SyncService) GetRootDirList() (*types.AskRootDirRes, error) {
 }
 
 // GetRootDirByPath gets root directory by path
-func (ss *SyncService) GetRootDirByPath(path string) (*types.RootDirectory, error) {
+func (ss *SyncService) GetRootDirByPath(ctx context.Context, path string) (*types.RootDirectory, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetRootDirByPath")
+	defer span.End()
+
 	log.Println("quics: GetRootDirByPath: ", path)
-	rootDir, err := ss.syncRepository.GetRootDirByPath(path)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, path)
 	if err != nil {
 		err = errors.New("[SyncService.GetRootDirByPath] get rootDir data by path: " + err.Error())
 		return nil, err
@@ -176,14 +253,17 @@ func (ss *SyncService) GetRootDirByPath(path string) (*types.RootDirectory, erro
 	return rootDir, nil
 }
 
-func (ss *SyncService) DisconnectRootDir(request *types.DisconnectRootDirReq) (*types.DisconnectRootDirRes, error) {
+func (ss *SyncService) DisconnectRootDir(ctx context.Context, request *types.DisconnectRootDirReq) (*types.DisconnectRootDirRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.DisconnectRootDir")
+	defer span.End()
+
 	log.Println("quics: DisconnectRootDir: ", request)
-	client, err := ss.registrationRepository.GetClientByUUID(request.UUID)
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		err = errors.New("[SyncService.DisconnectRootDir] get client data by uuit: " + err.Error())
 		return nil, err
 	}
-	rootDir, err := ss.syncRepository.GetRootDirByPath(request.AfterPath)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.DisconnectRootDir] get rootDir data by path: " + err.Error())
 		return nil, err
@@ -196,7 +276,7 @@ func (ss *SyncService) DisconnectRootDir(request *types.DisconnectRootDirReq) (*
 			i--
 		}
 	}
-	err = ss.syncRepository.SaveRootDir(rootDir.AfterPath, rootDir)
+	err = ss.syncRepository.SaveRootDir(ctx, rootDir.AfterPath, rootDir)
 	if err != nil {
 		err = errors.New("[SyncService.DisconnectRootDir] save rootDir by path: " + err.Error())
 		return nil, err
@@ -210,11 +290,12 @@ func (ss *SyncService) DisconnectRootDir(request *types.DisconnectRootDirReq) (*
 		}
 	}
 	// save updated client entity with new root directory
-	err = ss.registrationRepository.SaveClient(client.UUID, client)
+	err = ss.registrationRepository.SaveClient(ctx, client.UUID, client)
 	if err != nil {
 		err = errors.New("[SyncService.DisconnectRootDir] save client using repository: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditDisconnectRootDir, request.UUID, request.AfterPath, "")
 
 	response := &types.DisconnectRootDirRes{
 		UUID:      client.UUID,
@@ -224,15 +305,22 @@ func (ss *SyncService) DisconnectRootDir(request *types.DisconnectRootDirReq) (*
 }
 
 // UpdateFileWithoutContents updates file (ContentExisted = false)
-func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSyncReq) (*types.PleaseSyncRes, error) {
+func (ss *SyncService) UpdateFileWithoutContents(ctx context.Context, pleaseSyncReq *types.PleaseSyncReq) (*types.PleaseSyncRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.UpdateFileWithoutContents")
+	defer span.End()
+
 	log.Println("quics: UpdateFileWithoutContents: ", pleaseSyncReq)
 
-	file, err := ss.syncRepository.GetFileByPath(pleaseSyncReq.AfterPath)
+	// file can not be committed on server side while version of client is given
+	unlock := ss.lockPaths([]string{pleaseSyncReq.AfterPath})
+	defer unlock()
+
+	file, err := ss.syncRepository.GetFileByPath(ctx, pleaseSyncReq.AfterPath)
 	if err == ss.syncRepository.ErrKeyNotFound() {
 		// check request type is remove and file is not exist
 		if pleaseSyncReq.LastUpdateHash == "" {
 			// if file is deleted then remove file from {rootDir}
-			err = ss.syncDirAdapter.DeleteFileFromLatestDir(file.AfterPath)
+			err = ss.syncDirAdapter.DeleteFileFromLatestDir(ctx, file.AfterPath)
 			if err != nil && !os.IsNotExist(err) {
 				err = errors.New("[SyncService.UpdateFileWithoutContents] delete file from latestDir: " + err.Error())
 				return nil, err
@@ -274,7 +362,7 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 		return nil, err
 	}
 
-	rootDir, err := ss.syncRepository.GetRootDirByPath(file.RootDirKey)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
 	if err != nil {
 
 		return nil, err
@@ -285,6 +373,13 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 		return nil, errors.New("[SyncService.UpdateFileWithoutContents] request on unconnected rootDir err")
 	}
 
+	// check client has write permission on file's rootDir
+	err = ss.checkWritePermission(ctx, rootDir, pleaseSyncReq.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.UpdateFileWithoutContents] check permission: " + err.Error())
+		return nil, err
+	}
+
 	switch {
 	// check file has been updated
 	case file.LatestHash == pleaseSyncReq.LastUpdateHash:
@@ -319,7 +414,7 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 			file.NeedForceSync = false
 		}
 
-		err = ss.syncRepository.UpdateFile(file)
+		err = ss.syncRepository.UpdateFile(ctx, file)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithoutContents] update file data: " + err.Error())
 			return nil, err
@@ -335,7 +430,7 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 			Hash:       file.LatestHash,
 			File:       file.Metadata,
 		}
-		err = ss.historyRepository.SaveNewFileHistory(fileHistory.AfterPath, fileHistory)
+		err = ss.historyRepository.SaveNewFileHistory(ctx, fileHistory.AfterPath, fileHistory)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithoutContents] save new file history data: " + err.Error())
 			return nil, err
@@ -358,7 +453,7 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 				AfterPath:    file.AfterPath,
 				StagingFiles: map[string]types.FileHistory{},
 			}
-			latestFileHistory, err := ss.historyRepository.GetFileHistory(file.AfterPath, file.LatestSyncTimestamp)
+			latestFileHistory, err := ss.historyRepository.GetFileHistory(ctx, file.AfterPath, file.LatestSyncTimestamp)
 			if err != nil {
 				err = errors.New("[SyncService.UpdateFileWithoutContents] get file history data: " + err.Error())
 				return nil, err
@@ -376,12 +471,12 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 			File:      pleaseSyncReq.Metadata,
 		}
 
-		err = ss.syncRepository.UpdateFile(file)
+		err = ss.syncRepository.UpdateFile(ctx, file)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithoutContents] update file data: " + err.Error())
 			return nil, err
 		}
-		err = ss.syncRepository.UpdateConflict(file.AfterPath, &file.Conflict)
+		err = ss.syncRepository.UpdateConflict(ctx, file.AfterPath, &file.Conflict)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithoutContents] update conflict data: " + err.Error())
 			return nil, err
@@ -398,19 +493,49 @@ func (ss *SyncService) UpdateFileWithoutContents(pleaseSyncReq *types.PleaseSync
 }
 
 // UpdateFileWithContents updates file (ContentExisted = true)
-func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.PleaseTakeRes, error) {
+func (ss *SyncService) UpdateFileWithContents(ctx context.Context, pleaseTakeReq *types.PleaseTakeReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.PleaseTakeRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.UpdateFileWithContents")
+	defer span.End()
+
 	log.Println("quics: UpdateFileWithContents: ", pleaseTakeReq)
-	file, err := ss.syncRepository.GetFileByPath(pleaseTakeReq.AfterPath)
+
+	// contents are written to the version given with PLEASESYNC, which must not be changed while they are written
+	unlock := ss.lockPaths([]string{pleaseTakeReq.AfterPath})
+	defer unlock()
+	file, err := ss.syncRepository.GetFileByPath(ctx, pleaseTakeReq.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.UpdateFileWithContents] get file data by path: " + err.Error())
 		return nil, err
 	}
 
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+	if err != nil {
+		err = errors.New("[SyncService.UpdateFileWithContents] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+
+	// check client is connected on file's rootDir before contents are written
+	if !slices.Contains[[]string, string](rootDir.UUIDs, pleaseTakeReq.UUID) {
+		return nil, errors.New("[SyncService.UpdateFileWithContents] request on unconnected rootDir err")
+	}
+
+	// check client has write permission on file's rootDir
+	err = ss.checkWritePermission(ctx, rootDir, pleaseTakeReq.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.UpdateFileWithContents] check permission: " + err.Error())
+		return nil, err
+	}
+
 	// check file is coflicted
 	if reflect.ValueOf(file.Conflict).IsZero() {
+		// contents are only taken from client which gave the latest version, and only once
+		if file.ContentsExisted || file.LatestEditClient != pleaseTakeReq.UUID {
+			return nil, errors.New("[SyncService.UpdateFileWithContents] contents of the latest version are not requested from client")
+		}
+
 		// if file is not conflicted then update file
 		// save latest file to {rootDir}
-		err = ss.syncDirAdapter.SaveFileToHistoryDir(file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
+		err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithContents] save file to historyDir: " + err.Error())
 			return nil, err
@@ -419,14 +544,14 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 		// check file is deleted
 		if file.LatestHash == "" {
 			// if file is deleted then remove file from {rootDir}
-			err = ss.syncDirAdapter.DeleteFileFromLatestDir(file.AfterPath)
+			err = ss.syncDirAdapter.DeleteFileFromLatestDir(ctx, file.AfterPath)
 			if err != nil && !os.IsNotExist(err) {
 				err = errors.New("[SyncService.UpdateFileWithContents] delete file from latestDir: " + err.Error())
 				return nil, err
 			}
 		} else {
 			// check file hash is correct
-			fileInfo, err := ss.syncDirAdapter.GetFileInfoFromHistoryDir(file.AfterPath, file.LatestSyncTimestamp)
+			fileInfo, err := ss.syncDirAdapter.GetFileInfoFromHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp)
 			if err != nil {
 				err = errors.New("[SyncService.UpdateFileWithContents] get file from historyDir: " + err.Error())
 				return nil, err
@@ -439,12 +564,12 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 			}
 
 			// if file is not deleted then save file to {rootDir}
-			fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(file.AfterPath, file.LatestSyncTimestamp)
+			fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp)
 			if err != nil {
 				err = errors.New("[SyncService.UpdateFileWithContents] get file from historyDir: " + err.Error())
 				return nil, err
 			}
-			err = ss.syncDirAdapter.SaveFileToLatestDir(file.AfterPath, fileMetadata, fileContent)
+			err = ss.syncDirAdapter.SaveFileToLatestDir(ctx, file.AfterPath, fileMetadata, fileContent)
 			if err != nil {
 				err = errors.New("[SyncService.UpdateFileWithContents] save file to latestDir: " + err.Error())
 				return nil, err
@@ -453,18 +578,23 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 		}
 
 		file.ContentsExisted = true
-		err = ss.syncRepository.UpdateFile(file)
+		err = ss.syncRepository.UpdateFile(ctx, file)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithContents] update file data: " + err.Error())
 			return nil, err
 		}
+		if file.LatestHash == "" {
+			ss.auditService.Record(ctx, types.AuditDeleteFile, pleaseTakeReq.UUID, file.AfterPath, fmt.Sprint("timestamp: ", file.LatestSyncTimestamp))
+		} else {
+			ss.auditService.Record(ctx, types.AuditWriteFile, pleaseTakeReq.UUID, file.AfterPath, fmt.Sprint("timestamp: ", file.LatestSyncTimestamp, ", hash: ", file.LatestHash))
+		}
 
 		// TODO: call must sync
 		// -> must sync transaction with goroutine (and end please transaction)
 
-		go func() {
+		go func(ctx context.Context) {
 			// extract root directory of this file
-			rootDir, err := ss.syncRepository.GetRootDirByPath(file.RootDirKey)
+			rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
 			if err != nil {
 				err = errors.New("[goroutine in SyncService.UpdateFileWithContents] get rootDir data by path: " + err.Error())
 				log.Println("quics err: ", err)
@@ -479,13 +609,13 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 				}
 			}
 
-			err = ss.CallMustSync(file.AfterPath, UUIDs)
+			err = ss.CallMustSync(ctx, file.AfterPath, UUIDs)
 			if err != nil {
 				err = errors.New("[goroutine in SyncService.UpdateFileWithContents] call mustsync: " + err.Error())
 				log.Println("quics err: ", err)
 				return
 			}
-		}()
+		}(tracing.Detach(ctx))
 
 		// <- must sync transaction with goroutine (and end please transaction)
 
@@ -498,18 +628,18 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 		// if file is conflicted then save file to {rootDir}.conflict
 
 		// save file to {rootDir}.conflict
-		err = ss.syncDirAdapter.SaveFileToConflictDir(pleaseTakeReq.UUID, file.AfterPath, fileMetadata, fileContent)
+		err = ss.syncDirAdapter.SaveFileToConflictDir(ctx, pleaseTakeReq.UUID, file.AfterPath, fileMetadata, fileContent)
 		if err != nil {
 			// delete staging file info from conflict info when error occurred
 			delete(file.Conflict.StagingFiles, pleaseTakeReq.UUID)
-			ss.syncRepository.UpdateFile(file)
-			ss.syncRepository.UpdateConflict(file.AfterPath, &file.Conflict)
+			ss.syncRepository.UpdateFile(ctx, file)
+			ss.syncRepository.UpdateConflict(ctx, file.AfterPath, &file.Conflict)
 			err = errors.New("[SyncService.UpdateFileWithContents] save file to conflictDir: " + err.Error())
 			return nil, err
 		}
 
 		// check file hash is correct
-		fileInfo, err := ss.syncDirAdapter.GetFileInfoFromConflictDir(file.AfterPath, pleaseTakeReq.UUID)
+		fileInfo, err := ss.syncDirAdapter.GetFileInfoFromConflictDir(ctx, file.AfterPath, pleaseTakeReq.UUID)
 		if err != nil {
 			err = errors.New("[SyncService.UpdateFileWithContents] get file from conflictDir: " + err.Error())
 			return nil, err
@@ -518,8 +648,8 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 		if file.LatestHash != "" && downloadedHash != file.Conflict.StagingFiles[pleaseTakeReq.UUID].Hash {
 			// delete staging file info from conflict info when error occurred
 			delete(file.Conflict.StagingFiles, pleaseTakeReq.UUID)
-			ss.syncRepository.UpdateFile(file)
-			ss.syncRepository.UpdateConflict(file.AfterPath, &file.Conflict)
+			ss.syncRepository.UpdateFile(ctx, file)
+			ss.syncRepository.UpdateConflict(ctx, file.AfterPath, &file.Conflict)
 			return nil, errors.New("[SyncService.UpdateFileWithContents] file hash is not correct")
 		}
 
@@ -533,8 +663,256 @@ func (ss *SyncService) UpdateFileWithContents(pleaseTakeReq *types.PleaseTakeReq
 	}
 }
 
+// CommitFile commits file which is written on server side (not by client) as new version of the file.
+// It saves history and latest file in the same way as PLEASESYNC and calls MUSTSYNC to all clients of root directory.
+// The caller must check permission of actor on root directory, actor is recorded as editor of the version.
+func (ss *SyncService) CommitFile(ctx context.Context, actor string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.File, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.CommitFile")
+	defer span.End()
+
+	unlock := ss.lockPaths([]string{afterPath})
+	defer unlock()
+
+	return ss.commitFile(ctx, actor, afterPath, fileMetadata, fileContent)
+}
+
+// commitFile is CommitFile without lock, lock of the path must be held by caller
+func (ss *SyncService) commitFile(ctx context.Context, actor string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.File, error) {
+	log.Println("quics: CommitFile: ", afterPath, " by ", actor)
+	rootDirName, _ := utils.GetNamesByAfterPath(afterPath)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, "/"+rootDirName)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		file = &types.File{
+			BeforePath: utils.GetQuicsSyncDirPath(),
+			AfterPath:  afterPath,
+			RootDirKey: rootDir.AfterPath,
+		}
+	} else if err != nil {
+		err = errors.New("[SyncService.commitFile] get file data by path: " + err.Error())
+		return nil, err
+	}
+	if !reflect.ValueOf(file.Conflict).IsZero() {
+		return nil, errors.New("[SyncService.commitFile] file is conflicted: " + afterPath)
+	}
+	// contents of PLEASESYNC are written to the latest version when PLEASETAKE arrives
+	if err == nil && !file.ContentsExisted {
+		return nil, ErrContentsPending
+	}
+
+	timestamp := file.LatestSyncTimestamp + 1
+	err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, afterPath, timestamp, fileMetadata, fileContent)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] save file to historyDir: " + err.Error())
+		return nil, err
+	}
+	// hash is made from the metadata of saved file, as clients make it from their files
+	historyFileMetadata, historyFileContent, err := ss.syncDirAdapter.GetFileFromHistoryDir(ctx, afterPath, timestamp)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] get file from historyDir: " + err.Error())
+		return nil, err
+	}
+	if closer, ok := historyFileContent.(io.Closer); ok {
+		defer closer.Close()
+	}
+	err = ss.syncDirAdapter.SaveFileToLatestDir(ctx, afterPath, historyFileMetadata, historyFileContent)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] save file to latestDir: " + err.Error())
+		return nil, err
+	}
+
+	file.LatestHash = utils.MakeHashFromFileMetadata(afterPath, historyFileMetadata)
+	file.LatestSyncTimestamp = timestamp
+	file.LatestEditClient = actor
+	file.ContentsExisted = true
+	file.NeedForceSync = false
+	file.Metadata = *historyFileMetadata
+
+	fileHistory := &types.FileHistory{
+		Date:       time.Now().String(),
+		UUID:       actor,
+		BeforePath: file.BeforePath,
+		AfterPath:  file.AfterPath,
+		Timestamp:  file.LatestSyncTimestamp,
+		Hash:       file.LatestHash,
+		File:       file.Metadata,
+	}
+	err = ss.historyRepository.SaveNewFileHistory(ctx, fileHistory.AfterPath, fileHistory)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] save new file history data: " + err.Error())
+		return nil, err
+	}
+	err = ss.syncRepository.SaveFileByPath(ctx, file.AfterPath, file)
+	if err != nil {
+		err = errors.New("[SyncService.commitFile] save file data: " + err.Error())
+		return nil, err
+	}
+	ss.auditService.Record(ctx, types.AuditWriteFile, actor, file.AfterPath, fmt.Sprint("timestamp: ", file.LatestSyncTimestamp, ", hash: ", file.LatestHash))
+
+	go func(ctx context.Context) {
+		err := ss.CallMustSync(ctx, file.AfterPath, rootDir.UUIDs)
+		if err != nil {
+			err = errors.New("[goroutine in SyncService.CommitFile] call mustsync: " + err.Error())
+			log.Println("quics err: ", err)
+		}
+	}(tracing.Detach(ctx))
+
+	return file, nil
+}
+
+// DeleteFile deletes file on server side (not by client) as new version of the file, in the same way as PLEASESYNC of removed file.
+// It saves empty history and calls MUSTSYNC to all clients of root directory, so that they remove the file.
+// The caller must check permission of actor on root directory, actor is recorded as editor of the version.
+func (ss *SyncService) DeleteFile(ctx context.Context, actor string, afterPath string) (*types.File, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.DeleteFile")
+	defer span.End()
+
+	unlock := ss.lockPaths([]string{afterPath})
+	defer unlock()
+
+	return ss.deleteFile(ctx, actor, afterPath)
+}
+
+// deleteFile is DeleteFile without lock, lock of the path must be held by caller
+func (ss *SyncService) deleteFile(ctx context.Context, actor string, afterPath string) (*types.File, error) {
+	log.Println("quics: DeleteFile: ", afterPath, " by ", actor)
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() || (err == nil && file.LatestHash == "") {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		err = errors.New("[SyncService.deleteFile] get file data by path: " + err.Error())
+		return nil, err
+	}
+	if !reflect.ValueOf(file.Conflict).IsZero() {
+		return nil, errors.New("[SyncService.deleteFile] file is conflicted: " + afterPath)
+	}
+	if !file.ContentsExisted {
+		return nil, ErrContentsPending
+	}
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+	if err != nil {
+		err = errors.New("[SyncService.deleteFile] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+
+	// history of removed file has empty contents, which is sent to clients with MUSTSYNC
+	timestamp := file.LatestSyncTimestamp + 1
+	emptyFileMetadata := &types.FileMetadata{
+		Name:    filepath.Base(afterPath),
+		Mode:    0644,
+		ModTime: time.Now(),
+	}
+	err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, afterPath, timestamp, emptyFileMetadata, strings.NewReader(""))
+	if err != nil {
+		err = errors.New("[SyncService.deleteFile] save file to historyDir: " + err.Error())
+		return nil, err
+	}
+	err = ss.syncDirAdapter.DeleteFileFromLatestDir(ctx, afterPath)
+	if err != nil && !os.IsNotExist(err) {
+		err = errors.New("[SyncService.deleteFile] delete file from latestDir: " + err.Error())
+		return nil, err
+	}
+
+	file.LatestHash = ""
+	file.LatestSyncTimestamp = timestamp
+	file.LatestEditClient = actor
+	file.ContentsExisted = true
+	file.NeedForceSync = false
+	file.Metadata = types.FileMetadata{}
+
+	fileHistory := &types.FileHistory{
+		Date:       time.Now().String(),
+		UUID:       actor,
+		BeforePath: file.BeforePath,
+		AfterPath:  file.AfterPath,
+		Timestamp:  file.LatestSyncTimestamp,
+		Hash:       file.LatestHash,
+		File:       file.Metadata,
+	}
+	err = ss.historyRepository.SaveNewFileHistory(ctx, fileHistory.AfterPath, fileHistory)
+	if err != nil {
+		err = errors.New("[SyncService.deleteFile] save new file history data: " + err.Error())
+		return nil, err
+	}
+	err = ss.syncRepository.UpdateFile(ctx, file)
+	if err != nil {
+		err = errors.New("[SyncService.deleteFile] update file data: " + err.Error())
+		return nil, err
+	}
+	ss.auditService.Record(ctx, types.AuditDeleteFile, actor, file.AfterPath, fmt.Sprint("timestamp: ", file.LatestSyncTimestamp))
+
+	go func(ctx context.Context) {
+		err := ss.CallMustSync(ctx, file.AfterPath, rootDir.UUIDs)
+		if err != nil {
+			err = errors.New("[goroutine in SyncService.DeleteFile] call mustsync: " + err.Error())
+			log.Println("quics err: ", err)
+		}
+	}(tracing.Detach(ctx))
+
+	return file, nil
+}
+
+// MoveFile moves file on server side by committing latest file to new path and deleting old path,
+// so that both paths have history and clients receive both changes with MUSTSYNC.
+// The caller must check permission of actor on root directories of both paths.
+func (ss *SyncService) MoveFile(ctx context.Context, actor string, oldPath string, newPath string) (*types.File, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.MoveFile")
+	defer span.End()
+
+	// old path can not be written between the commit of new path and the delete of old path
+	unlock := ss.lockPaths([]string{oldPath, newPath})
+	defer unlock()
+
+	file, err := ss.syncRepository.GetFileByPath(ctx, oldPath)
+	if err == ss.syncRepository.ErrKeyNotFound() || (err == nil && file.LatestHash == "") {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		err = errors.New("[SyncService.MoveFile] get file data by path: " + err.Error())
+		return nil, err
+	}
+	if !reflect.ValueOf(file.Conflict).IsZero() {
+		return nil, errors.New("[SyncService.MoveFile] file is conflicted: " + oldPath)
+	}
+	if !file.ContentsExisted {
+		return nil, ErrContentsPending
+	}
+
+	fileMetadata, fileContent, err := ss.syncDirAdapter.GetFileFromLatestDir(ctx, oldPath)
+	if err != nil {
+		err = errors.New("[SyncService.MoveFile] get file from latestDir: " + err.Error())
+		return nil, err
+	}
+	if closer, ok := fileContent.(io.Closer); ok {
+		defer closer.Close()
+	}
+	fileMetadata.Name = filepath.Base(newPath)
+
+	newFile, err := ss.commitFile(ctx, actor, newPath, fileMetadata, fileContent)
+	if err != nil {
+		err = errors.New("[SyncService.MoveFile] " + err.Error())
+		return nil, err
+	}
+	_, err = ss.deleteFile(ctx, actor, oldPath)
+	if err != nil {
+		err = errors.New("[SyncService.MoveFile] " + err.Error())
+		return nil, err
+	}
+
+	return newFile, nil
+}
+
 // CallMustSync calls must sync transaction
-func (ss *SyncService) CallMustSync(filePath string, UUIDs []string) error {
+func (ss *SyncService) CallMustSync(ctx context.Context, filePath string, UUIDs []string) error {
+	ctx, span := tracing.Start(ctx, "SyncService.CallMustSync")
+	defer span.End()
+
 	ss.cancelMut.Lock()
 	if _, exists := ss.cancel[filePath]; exists {
 		log.Println("quics: Cancel MUSTSYNC of ", filePath)
@@ -542,7 +920,7 @@ func (ss *SyncService) CallMustSync(filePath string, UUIDs []string) error {
 	}
 	ss.cancelMut.Unlock()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(tracing.Detach(ctx))
 
 	// use cancelMut mutex for atomic to cancel map
 	ss.cancelMut.Lock()
@@ -556,112 +934,203 @@ func (ss *SyncService) CallMustSync(filePath string, UUIDs []string) error {
 	}()
 
 	for _, UUID := range UUIDs {
-		transaction, err := ss.networkAdapter.OpenTransaction(types.MUSTSYNC, UUID)
+		// offline client receives MUSTSYNC when it connects again
+		if !ss.networkAdapter.IsOnline(UUID) {
+			ss.queueMustSync(UUID, filePath)
+			continue
+		}
+
+		err := ss.sendMustSync(ctx, filePath, UUID)
 		if err != nil {
-			err = errors.New("[SyncService.CallMustSync] open transaction: " + err.Error())
-			return err
+			ss.queueMustSync(UUID, filePath)
+			err = errors.New("[SyncService.CallMustSync] " + err.Error())
+			log.Println("quics err: ", err)
 		}
-		log.Println("quics: MUSTSYNC to ", UUID)
+	}
+	return nil
+}
 
-		// -> must sync
+// CallQueuedMustSync calls must sync transaction of files which are changed while client is offline
+func (ss *SyncService) CallQueuedMustSync(ctx context.Context, UUID string) error {
+	ctx, span := tracing.Start(ctx, "SyncService.CallQueuedMustSync")
+	defer span.End()
 
-		go func() {
-			defer func() {
-				err = transaction.Close()
-				if err != nil {
-					err = errors.New("[SyncService.CallMustSync] close transaction: " + err.Error())
-					log.Println("quics err: ", err)
-					return
-				}
-			}()
-			file, err := ss.syncRepository.GetFileByPath(filePath)
-			if err != nil {
-				err = errors.New("[SyncService.CallMustSync] get file data by path: " + err.Error())
-				log.Println("quics err: ", err)
-				return
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
+	ss.queuedMut.Lock()
+	filePaths := ss.queuedMustSync[UUID]
+	delete(ss.queuedMustSync, UUID)
+	ss.queuedMut.Unlock()
+	if len(filePaths) == 0 {
+		return nil
+	}
+	log.Println("quics: MUSTSYNC ", len(filePaths), " queued files to ", UUID)
 
-			mustSyncReq := &types.MustSyncReq{
-				LatestHash:          file.LatestHash,
-				LatestSyncTimestamp: file.LatestSyncTimestamp,
-				BeforePath:          file.BeforePath,
-				AfterPath:           file.AfterPath,
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
+	ctx = tracing.Detach(ctx)
+	for filePath := range filePaths {
+		// client may leave root directory while it is offline
+		file, err := ss.syncRepository.GetFileByPath(ctx, filePath)
+		if err != nil {
+			err = errors.New("[SyncService.CallQueuedMustSync] get file data by path: " + err.Error())
+			log.Println("quics err: ", err)
+			continue
+		}
+		rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+		if err != nil {
+			err = errors.New("[SyncService.CallQueuedMustSync] get root directory data by path: " + err.Error())
+			log.Println("quics err: ", err)
+			continue
+		}
+		if !slices.Contains(rootDir.UUIDs, UUID) {
+			continue
+		}
 
-			mustSyncRes, err := transaction.RequestMustSync(mustSyncReq)
-			if err != nil {
-				err = errors.New("[SyncService.CallMustSync] request mustsync using transaction: " + err.Error())
-				log.Println("quics err: ", err)
-				return
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
+		err = ss.sendMustSync(ctx, filePath, UUID)
+		if err != nil {
+			ss.queueMustSync(UUID, filePath)
+			err = errors.New("[SyncService.CallQueuedMustSync] " + err.Error())
+			log.Println("quics err: ", err)
+		}
+	}
+	return nil
+}
 
-			// <- must sync
+// ClearQueuedMustSync drops must sync transactions queued for client which leaves the server, all queues are dropped if UUID is empty
+func (ss *SyncService) ClearQueuedMustSync(ctx context.Context, UUID string) {
+	ctx, span := tracing.Start(ctx, "SyncService.ClearQueuedMustSync")
+	defer span.End()
 
-			// -> give file
+	ss.queuedMut.Lock()
+	defer ss.queuedMut.Unlock()
+	if UUID == "" {
+		ss.queuedMustSync = map[string]map[string]struct{}{}
+		return
+	}
+	delete(ss.queuedMustSync, UUID)
+}
 
-			giveYouReq := &types.GiveYouReq{
-				UUID:      mustSyncRes.UUID,
-				AfterPath: mustSyncRes.AfterPath,
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
-			if mustSyncRes.AfterPath == "" {
-				log.Println("quics: ", errors.New("[SyncService.CallMustSync] mustSyncRes.AfterPath is empty"))
-				return
-			}
+// queueMustSync keeps file path to call must sync transaction when client connects again
+func (ss *SyncService) queueMustSync(UUID string, filePath string) {
+	log.Println("quics: queue MUSTSYNC of ", filePath, " to offline client ", UUID)
 
-			historyFilePath := utils.GetHistoryFileNameByAfterPath(mustSyncRes.AfterPath, mustSyncRes.LatestSyncTimestamp)
-			giveYouRes, err := transaction.RequestGiveYou(giveYouReq, historyFilePath)
-			if err != nil {
-				err = errors.New("[SyncService.CallMustSync] request giveyou using transaction: " + err.Error())
-				log.Println("quics err: ", err)
-				return
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
+	ss.queuedMut.Lock()
+	defer ss.queuedMut.Unlock()
+	if _, exists := ss.queuedMustSync[UUID]; !exists {
+		ss.queuedMustSync[UUID] = map[string]struct{}{}
+	}
+	ss.queuedMustSync[UUID][filePath] = struct{}{}
+}
 
-			file, err = ss.syncRepository.GetFileByPath(giveYouRes.AfterPath)
-			if err != nil {
-				err = errors.New("[SyncService.CallMustSync] get file data by path: " + err.Error())
-				log.Println("quics err: ", err)
-				return
-			}
-			if ctx.Err() != nil {
-				log.Println("quics err: ", ctx.Err())
-				return
-			}
+// sendMustSync opens must sync transaction to client, and gives the latest file in background
+func (ss *SyncService) sendMustSync(ctx context.Context, filePath string, UUID string) error {
+	transaction, err := ss.networkAdapter.OpenTransaction(ctx, types.MUSTSYNC, UUID)
+	if err != nil {
+		err = errors.New("open transaction: " + err.Error())
+		return err
+	}
+	log.Println("quics: MUSTSYNC to ", UUID)
+
+	// -> must sync
 
-			err = validateGiveYouTransaction(file, giveYouRes)
+	ss.pendingSyncNum.Add(1)
+	go func() {
+		defer ss.pendingSyncNum.Add(-1)
+		defer func() {
+			err = transaction.Close()
 			if err != nil {
-				err = errors.New("[SyncService.CallMustSync] validate give you transaction: " + err.Error())
+				err = errors.New("[SyncService.sendMustSync] close transaction: " + err.Error())
 				log.Println("quics err: ", err)
 				return
 			}
-			// <- give file
 		}()
-	}
+		file, err := ss.syncRepository.GetFileByPath(ctx, filePath)
+		if err != nil {
+			err = errors.New("[SyncService.sendMustSync] get file data by path: " + err.Error())
+			log.Println("quics err: ", err)
+			return
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+
+		mustSyncReq := &types.MustSyncReq{
+			LatestHash:          file.LatestHash,
+			LatestSyncTimestamp: file.LatestSyncTimestamp,
+			BeforePath:          file.BeforePath,
+			AfterPath:           file.AfterPath,
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+
+		mustSyncRes, err := transaction.RequestMustSync(mustSyncReq)
+		if err != nil {
+			err = errors.New("[SyncService.sendMustSync] request mustsync using transaction: " + err.Error())
+			log.Println("quics err: ", err)
+			return
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+
+		// <- must sync
+
+		// -> give file
+
+		giveYouReq := &types.GiveYouReq{
+			UUID:      mustSyncRes.UUID,
+			AfterPath: mustSyncRes.AfterPath,
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+		if mustSyncRes.AfterPath == "" {
+			log.Println("quics: ", errors.New("[SyncService.sendMustSync] mustSyncRes.AfterPath is empty"))
+			return
+		}
+
+		historyFilePath := utils.GetHistoryFileNameByAfterPath(mustSyncRes.AfterPath, mustSyncRes.LatestSyncTimestamp)
+		giveYouRes, err := transaction.RequestGiveYou(giveYouReq, historyFilePath)
+		if err != nil {
+			err = errors.New("[SyncService.sendMustSync] request giveyou using transaction: " + err.Error())
+			log.Println("quics err: ", err)
+			return
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+
+		file, err = ss.syncRepository.GetFileByPath(ctx, giveYouRes.AfterPath)
+		if err != nil {
+			err = errors.New("[SyncService.sendMustSync] get file data by path: " + err.Error())
+			log.Println("quics err: ", err)
+			return
+		}
+		if ctx.Err() != nil {
+			log.Println("quics err: ", ctx.Err())
+			return
+		}
+
+		err = validateGiveYouTransaction(file, giveYouRes)
+		if err != nil {
+			err = errors.New("[SyncService.sendMustSync] validate give you transaction: " + err.Error())
+			log.Println("quics err: ", err)
+			return
+		}
+		// <- give file
+	}()
 	return nil
 }
 
-func (ss *SyncService) GetConflictList(request *types.AskConflictListReq) (*types.AskConflictListRes, error) {
+func (ss *SyncService) GetConflictList(ctx context.Context, request *types.AskConflictListReq) (*types.AskConflictListRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetConflictList")
+	defer span.End()
+
 	log.Println("quics: GetConflictList: ", request)
-	client, err := ss.registrationRepository.GetClientByUUID(request.UUID)
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		err = errors.New("[SyncService.GetConflictList] get client data by uuid: " + err.Error())
 		return nil, err
@@ -670,7 +1139,7 @@ func (ss *SyncService) GetConflictList(request *types.AskConflictListReq) (*type
 	for _, r := range client.Root {
 		rootDirs = append(rootDirs, r.AfterPath)
 	}
-	conflicts, err := ss.syncRepository.GetConflictList(rootDirs)
+	conflicts, err := ss.syncRepository.GetConflictList(ctx, rootDirs)
 	if err != nil {
 		err = errors.New("[SyncService.GetConflictList] get conflict list using repository: " + err.Error())
 		return nil, err
@@ -682,15 +1151,18 @@ func (ss *SyncService) GetConflictList(request *types.AskConflictListReq) (*type
 	}, nil
 }
 
-func (ss *SyncService) ChooseOne(request *types.PleaseFileReq) (*types.PleaseFileRes, error) {
+func (ss *SyncService) ChooseOne(ctx context.Context, request *types.PleaseFileReq) (*types.PleaseFileRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.ChooseOne")
+	defer span.End()
+
 	log.Println("quics: ChooseOne: ", request)
-	client, err := ss.registrationRepository.GetClientByUUID(request.UUID)
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, request.UUID)
 	if err != nil {
 		err = errors.New("[SyncService.ChooseOne] get client data by uuid: " + err.Error())
 		return nil, err
 	}
 
-	file, err := ss.syncRepository.GetFileByPath(request.AfterPath)
+	file, err := ss.syncRepository.GetFileByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.ChooseOne] get file data by path: " + err.Error())
 		return nil, err
@@ -701,61 +1173,91 @@ func (ss *SyncService) ChooseOne(request *types.PleaseFileReq) (*types.PleaseFil
 		return nil, errors.New("[SyncService.ChooseOne] root directory is not registered")
 	}
 
+	// check client has write permission on file's rootDir
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+	if err != nil {
+		err = errors.New("[SyncService.ChooseOne] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+	err = ss.checkWritePermission(ctx, rootDir, client.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.ChooseOne] check permission: " + err.Error())
+		return nil, err
+	}
+
+	err = ss.chooseOne(ctx, file, request.Side, request.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.ChooseOne] choose one: " + err.Error())
+		return nil, err
+	}
+
+	response := &types.PleaseFileRes{
+		UUID:      request.UUID,
+		AfterPath: request.AfterPath,
+	}
+
+	return response, nil
+}
+
+// chooseOne saves selected candidate (side) of conflicted file as new version, and sends it to clients with FORCESYNC.
+// side is "server" or UUID of client which uploaded the candidate, actor is recorded as editor of the file.
+func (ss *SyncService) chooseOne(ctx context.Context, file *types.File, side string, actor string) error {
 	if reflect.ValueOf(file.Conflict).IsZero() {
-		return nil, errors.New("[SyncService.ChooseOne] file is not conflicted")
+		return ErrConflictNotFound
 	}
 
-	if _, exists := file.Conflict.StagingFiles[request.Side]; !exists {
-		return nil, errors.New("[SyncService.ChooseOne] side is not exists")
+	if _, exists := file.Conflict.StagingFiles[side]; !exists {
+		return ErrCandidateNotFound
 	}
 
+	var err error
 	// save file to {rootDir}
-	if request.Side == "server" {
+	if side == "server" {
 		fileMetadata, fileContent := &types.FileMetadata{}, io.Reader(nil)
 		if file.ContentsExisted {
-			fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(file.AfterPath, file.LatestSyncTimestamp)
+			fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp)
 			if err != nil {
-				err = errors.New("[SyncService.ChooseOne] get file from historyDir: " + err.Error())
-				return nil, err
+				err = errors.New("[SyncService.chooseOne] get file from historyDir: " + err.Error())
+				return err
 			}
 		}
 		// when selected side is server
 		// save server file as new file to {rootDir}
 		file.LatestSyncTimestamp = file.LatestSyncTimestamp + 1
-		file.LatestEditClient = request.UUID
+		file.LatestEditClient = actor
 		file.NeedForceSync = true
 		file.Conflict = types.Conflict{}
 
 		// save file as new history when contents existed
 		if file.ContentsExisted {
-			err = ss.syncDirAdapter.SaveFileToHistoryDir(file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
+			err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
 			if err != nil {
-				err = errors.New("[SyncService.ChooseOne] save file to historyDir: " + err.Error())
-				return nil, err
+				err = errors.New("[SyncService.chooseOne] save file to historyDir: " + err.Error())
+				return err
 			}
 		}
 
-		err = ss.syncDirAdapter.DeleteFilesFromConflictDir(file.AfterPath)
+		err = ss.syncDirAdapter.DeleteFilesFromConflictDir(ctx, file.AfterPath)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] delete file from conflictDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] delete file from conflictDir: " + err.Error())
+			return err
 		}
 
-		err = ss.syncRepository.DeleteConflict(file.AfterPath)
+		err = ss.syncRepository.DeleteConflict(ctx, file.AfterPath)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] delete conflict data from repository: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] delete conflict data from repository: " + err.Error())
+			return err
 		}
 
-		err = ss.syncRepository.UpdateFile(file)
+		err = ss.syncRepository.UpdateFile(ctx, file)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] update file data using repository: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] update file data using repository: " + err.Error())
+			return err
 		}
 	} else {
 		// when selected side is client
 		// save client file as new file to {rootDir}
-		selectedConflictFile := file.Conflict.StagingFiles[request.Side]
+		selectedConflictFile := file.Conflict.StagingFiles[side]
 		file.LatestHash = selectedConflictFile.Hash
 		file.LatestSyncTimestamp = file.LatestSyncTimestamp + 1
 		file.LatestEditClient = selectedConflictFile.UUID
@@ -763,46 +1265,46 @@ func (ss *SyncService) ChooseOne(request *types.PleaseFileReq) (*types.PleaseFil
 		file.NeedForceSync = true
 		file.Conflict = types.Conflict{}
 
-		fileMetadata, fileContent, err := ss.syncDirAdapter.GetFileFromConflictDir(file.AfterPath, selectedConflictFile.UUID)
+		fileMetadata, fileContent, err := ss.syncDirAdapter.GetFileFromConflictDir(ctx, file.AfterPath, selectedConflictFile.UUID)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] get file from conflictDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] get file from conflictDir: " + err.Error())
+			return err
 		}
 
-		err = ss.syncDirAdapter.SaveFileToHistoryDir(file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
+		err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] save file to historyDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] save file to historyDir: " + err.Error())
+			return err
 		}
 
-		fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(file.AfterPath, file.LatestSyncTimestamp)
+		fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] get file from historyDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] get file from historyDir: " + err.Error())
+			return err
 		}
 
-		err = ss.syncDirAdapter.SaveFileToLatestDir(file.AfterPath, fileMetadata, fileContent)
+		err = ss.syncDirAdapter.SaveFileToLatestDir(ctx, file.AfterPath, fileMetadata, fileContent)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] save file to latestDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] save file to latestDir: " + err.Error())
+			return err
 		}
 
-		err = ss.syncDirAdapter.DeleteFilesFromConflictDir(file.AfterPath)
+		err = ss.syncDirAdapter.DeleteFilesFromConflictDir(ctx, file.AfterPath)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] delete candidate files from conflictDir: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] delete candidate files from conflictDir: " + err.Error())
+			return err
 		}
 
-		err = ss.syncRepository.DeleteConflict(file.AfterPath)
+		err = ss.syncRepository.DeleteConflict(ctx, file.AfterPath)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] delete conflict data: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] delete conflict data: " + err.Error())
+			return err
 		}
 
-		err = ss.syncRepository.UpdateFile(file)
+		err = ss.syncRepository.UpdateFile(ctx, file)
 		if err != nil {
-			err = errors.New("[SyncService.ChooseOne] update file data using repository: " + err.Error())
-			return nil, err
+			err = errors.New("[SyncService.chooseOne] update file data using repository: " + err.Error())
+			return err
 		}
 	}
 
@@ -810,47 +1312,58 @@ func (ss *SyncService) ChooseOne(request *types.PleaseFileReq) (*types.PleaseFil
 	// -> force sync transaction with goroutine (and end please transaction)
 
 	if file.ContentsExisted {
-		go func() {
+		go func(ctx context.Context) {
 			// extract root directory of this file
-			rootDir, err := ss.syncRepository.GetRootDirByPath(file.RootDirKey)
+			rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
 			if err != nil {
-				err = errors.New("[goroutine in SyncService.ChooseOne] get rootDiy by path: " + err.Error())
+				err = errors.New("[goroutine in SyncService.chooseOne] get rootDiy by path: " + err.Error())
 				log.Println("quics err: ", err)
 				return
 			}
 
-			err = ss.CallForceSync(file.AfterPath, rootDir.UUIDs)
+			err = ss.CallForceSync(ctx, file.AfterPath, rootDir.UUIDs)
 			if err != nil {
-				err = errors.New("[goroutine in SyncService.ChooseOne] call forcesync: " + err.Error())
+				err = errors.New("[goroutine in SyncService.chooseOne] call forcesync: " + err.Error())
 				log.Println("quics err: ", err)
 				return
 			}
-		}()
+		}(tracing.Detach(ctx))
 	}
 
-	response := &types.PleaseFileRes{
-		UUID:      request.UUID,
-		AfterPath: request.AfterPath,
-	}
+	ss.auditService.Record(ctx, types.AuditChooseOne, actor, file.AfterPath, "side: "+side)
 
-	return response, nil
+	return nil
 }
 
-func (ss *SyncService) CallForceSync(filePath string, UUIDs []string) error {
+func (ss *SyncService) CallForceSync(ctx context.Context, filePath string, UUIDs []string) error {
+	ctx, span := tracing.Start(ctx, "SyncService.CallForceSync")
+	defer span.End()
+
 	log.Println("quics: CallForceSync: ", filePath)
+
+	// readers only receive MUSTSYNC
+	UUIDs, readerUUIDs := ss.splitReaders(ctx, filePath, UUIDs)
+	if len(readerUUIDs) > 0 {
+		err := ss.CallMustSync(ctx, filePath, readerUUIDs)
+		if err != nil {
+			err = errors.New("[SyncService.CallForceSync] call mustsync to readers: " + err.Error())
+			return err
+		}
+	}
+
 	if _, exists := ss.cancel[filePath]; exists {
 		log.Println("quics: Cancel FORCESYNC of ", filePath)
 		ss.cancel[filePath]()
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(tracing.Detach(ctx))
 	ss.cancel[filePath] = cancel
 
 	defer func() {
 		delete(ss.cancel, filePath)
 	}()
 	for _, UUID := range UUIDs {
-		transaction, err := ss.networkAdapter.OpenTransaction(types.FORCESYNC, UUID)
+		transaction, err := ss.networkAdapter.OpenTransaction(ctx, types.FORCESYNC, UUID)
 		if err != nil {
 			err = errors.New("[SyncService.CallForceSync] open transaction: " + err.Error())
 			return err
@@ -859,7 +1372,9 @@ func (ss *SyncService) CallForceSync(filePath string, UUIDs []string) error {
 
 		// -> force sync
 
+		ss.pendingSyncNum.Add(1)
 		go func() {
+			defer ss.pendingSyncNum.Add(-1)
 			defer func() {
 				err = transaction.Close()
 				if err != nil {
@@ -868,7 +1383,7 @@ func (ss *SyncService) CallForceSync(filePath string, UUIDs []string) error {
 					return
 				}
 			}()
-			file, err := ss.syncRepository.GetFileByPath(filePath)
+			file, err := ss.syncRepository.GetFileByPath(ctx, filePath)
 			if err != nil {
 				err = errors.New("[SyncService.CallForceSync] get file by paht: " + err.Error())
 				log.Println("quics err: ", err)
@@ -910,15 +1425,26 @@ func (ss *SyncService) CallForceSync(filePath string, UUIDs []string) error {
 	return nil
 }
 
-func (ss *SyncService) FullScan(uuid string) error {
+func (ss *SyncService) FullScan(ctx context.Context, uuid string) (err error) {
+	ctx, span := tracing.Start(ctx, "SyncService.FullScan")
+	defer span.End()
+
 	log.Println("quics: FullScan: ", uuid)
-	client, err := ss.registrationRepository.GetClientByUUID(uuid)
+	result := types.FullScanResult{
+		UUID:      uuid,
+		StartTime: time.Now(),
+	}
+	defer func() {
+		ss.saveFullScanResult(result, err)
+	}()
+
+	client, err := ss.registrationRepository.GetClientByUUID(ctx, uuid)
 	if err != nil {
 		err = errors.New("[SyncService.FullScan] get client data by uuid: " + err.Error())
 		return err
 	}
 
-	transaction, err := ss.networkAdapter.OpenTransaction(types.FULLSCAN, uuid)
+	transaction, err := ss.networkAdapter.OpenTransaction(ctx, types.FULLSCAN, uuid)
 	if err != nil {
 		err = errors.New("[SyncService.FullScan] open transaction: " + err.Error())
 		return err
@@ -939,17 +1465,20 @@ func (ss *SyncService) FullScan(uuid string) error {
 	}
 
 	for _, rootDir := range client.Root {
-		allFiles, err := ss.syncRepository.GetAllFiles(rootDir.AfterPath)
+		allFiles, err := ss.syncRepository.GetAllFiles(ctx, rootDir.AfterPath)
 		if err != nil {
 			err = errors.New("[SyncService.FullScan] get all file data from repository: " + err.Error())
 			return err
 		}
 		for i, file := range allFiles {
+			result.ScannedFiles++
 			if !file.ContentsExisted && file.LatestEditClient == uuid {
-				err := ss.CallNeedContent(&allFiles[i])
+				err := ss.CallNeedContent(ctx, &allFiles[i])
 				if err != nil {
 					err = errors.New("[SyncService.FullScan] call needcontent: " + err.Error())
 					log.Println("quics err: ", err, "; continue to next")
+				} else {
+					result.NeedContents++
 				}
 			}
 			if !reflect.ValueOf(file.Conflict).IsZero() {
@@ -963,19 +1492,21 @@ func (ss *SyncService) FullScan(uuid string) error {
 					if clientFile.LastUpdateTimestamp == clientFile.LastSyncTimestamp && file.LatestSyncTimestamp > clientFile.LastUpdateTimestamp {
 						// need must synce
 						if file.NeedForceSync {
-							err = ss.CallForceSync(file.AfterPath, []string{uuid})
+							err = ss.CallForceSync(ctx, file.AfterPath, []string{uuid})
 							if err != nil {
 								err = errors.New("[SyncService.FullScan] call forcesync: " + err.Error())
 								log.Println("quics err: ", err, "; continue to next")
 								break
 							}
+							result.ForceSyncFiles++
 						} else {
-							err = ss.CallMustSync(file.AfterPath, []string{uuid})
+							err = ss.CallMustSync(ctx, file.AfterPath, []string{uuid})
 							if err != nil {
 								err = errors.New("[SyncService.FullScan] call mustsync: " + err.Error())
 								log.Println("quics err: ", err, "; continue to next")
 								break
 							}
+							result.MustSyncFiles++
 						}
 					}
 					break
@@ -986,19 +1517,21 @@ func (ss *SyncService) FullScan(uuid string) error {
 			if !exist && file.LatestHash != "" {
 				// need must sync
 				if file.NeedForceSync {
-					err = ss.CallForceSync(file.AfterPath, []string{uuid})
+					err = ss.CallForceSync(ctx, file.AfterPath, []string{uuid})
 					if err != nil {
 						err = errors.New("[SyncService.FullScan] call forcesync: " + err.Error())
 						log.Println("quics err: ", err, "; continue to next")
 						continue
 					}
+					result.ForceSyncFiles++
 				} else {
-					err = ss.CallMustSync(file.AfterPath, []string{uuid})
+					err = ss.CallMustSync(ctx, file.AfterPath, []string{uuid})
 					if err != nil {
 						err = errors.New("[SyncService.FullScan] call mustsync: " + err.Error())
 						log.Println("quics err: ", err, "; continue to next")
 						continue
 					}
+					result.MustSyncFiles++
 				}
 			}
 
@@ -1008,6 +1541,29 @@ func (ss *SyncService) FullScan(uuid string) error {
 	return nil
 }
 
+// GetPendingSyncNum gets the number of MUSTSYNC/FORCESYNC transactions which are not finished yet
+func (ss *SyncService) GetPendingSyncNum(ctx context.Context) int64 {
+	_, span := tracing.Start(ctx, "SyncService.GetPendingSyncNum")
+	defer span.End()
+
+	return ss.pendingSyncNum.Load()
+}
+
+// GetFullScanResults gets the last fullscan result of each client
+func (ss *SyncService) GetFullScanResults(ctx context.Context) []types.FullScanResult {
+	_, span := tracing.Start(ctx, "SyncService.GetFullScanResults")
+	defer span.End()
+
+	ss.fullScanMut.RLock()
+	defer ss.fullScanMut.RUnlock()
+
+	results := make([]types.FullScanResult, 0, len(ss.fullScanResults))
+	for _, result := range ss.fullScanResults {
+		results = append(results, result)
+	}
+	return results
+}
+
 func (ss *SyncService) BackgroundFullScan(secInterval uint64) error {
 	go func() {
 		for {
@@ -1018,16 +1574,23 @@ func (ss *SyncService) BackgroundFullScan(secInterval uint64) error {
 	go func() {
 		for {
 			uuid := <-ss.FSTrigger
+			ctx, span := tracing.Start(context.Background(), "SyncService.BackgroundFullScan")
+			span.SetAttributes(tracing.AttrClientUUID.String(uuid))
 			if uuid == "all" {
-				clients, err := ss.registrationRepository.GetAllClients()
+				clients, err := ss.registrationRepository.GetAllClients(ctx)
 				if err != nil {
 					err = errors.New("[SyncService.BackgroundFullScan] get all client data: " + err.Error())
 					log.Println("quics err: ", err, "; continue to next")
+					tracing.EndWithError(span, err)
 					continue
 				}
 
 				for _, client := range clients {
-					err = ss.FullScan(client.UUID)
+					// offline client is scanned when it is online at next interval
+					if !ss.networkAdapter.IsOnline(client.UUID) {
+						continue
+					}
+					err = ss.FullScan(ctx, client.UUID)
 					if err != nil {
 						err = errors.New("[SyncService.BackgroundFullScan] run fullscan to all client: " + err.Error())
 						log.Println("quics err: ", err, "; continue to next")
@@ -1035,19 +1598,24 @@ func (ss *SyncService) BackgroundFullScan(secInterval uint64) error {
 					}
 				}
 			} else {
-				err := ss.FullScan(uuid)
+				err := ss.FullScan(ctx, uuid)
 				if err != nil {
 					err = errors.New("[SyncService.BackgroundFullScan] run fullscan to " + uuid + ": " + err.Error())
 					log.Println("quics err: ", err, "; continue to next")
+					tracing.EndWithError(span, err)
 					continue
 				}
 			}
+			span.End()
 		}
 	}()
 	return nil
 }
 
-func (ss *SyncService) Rescan(request *types.RescanReq) (*types.RescanRes, error) {
+func (ss *SyncService) Rescan(ctx context.Context, request *types.RescanReq) (*types.RescanRes, error) {
+	_, span := tracing.Start(ctx, "SyncService.Rescan")
+	defer span.End()
+
 	log.Println("quics: [SyncService.Rescan] ", request)
 	ss.FSTrigger <- request.UUID
 	rescanRes := &types.RescanRes{
@@ -1056,13 +1624,16 @@ func (ss *SyncService) Rescan(request *types.RescanReq) (*types.RescanRes, error
 	return rescanRes, nil
 }
 
-func (ss *SyncService) CallNeedContent(file *types.File) error {
+func (ss *SyncService) CallNeedContent(ctx context.Context, file *types.File) error {
+	ctx, span := tracing.Start(ctx, "SyncService.CallNeedContent")
+	defer span.End()
+
 	log.Println("quics: [SyncService.CallNeedContent] ", file)
 	if file.ContentsExisted {
 		return errors.New("[SyncService.CallNeedContent] file contents is already existed")
 	}
 
-	transaction, err := ss.networkAdapter.OpenTransaction(types.NEEDCONTENT, file.LatestEditClient)
+	transaction, err := ss.networkAdapter.OpenTransaction(ctx, types.NEEDCONTENT, file.LatestEditClient)
 	if err != nil {
 		err = errors.New("[SyncService.CallNeedContent] open transaction: " + err.Error())
 		return err
@@ -1101,20 +1672,20 @@ func (ss *SyncService) CallNeedContent(file *types.File) error {
 	}
 
 	// save file to history dir
-	err = ss.syncDirAdapter.SaveFileToHistoryDir(file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
+	err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp, fileMetadata, fileContent)
 	if err != nil {
 		err = errors.New("[SyncService.CallNeedContent] save file to historyDir: " + err.Error())
 		return err
 	}
 
 	// copy file to latest dir
-	fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(file.AfterPath, file.LatestSyncTimestamp)
+	fileMetadata, fileContent, err = ss.syncDirAdapter.GetFileFromHistoryDir(ctx, file.AfterPath, file.LatestSyncTimestamp)
 	if err != nil {
 		err = errors.New("[SyncService.CallNeedContent] get file from historyDir: " + err.Error())
 		return err
 	}
 
-	err = ss.syncDirAdapter.SaveFileToLatestDir(file.AfterPath, fileMetadata, fileContent)
+	err = ss.syncDirAdapter.SaveFileToLatestDir(ctx, file.AfterPath, fileMetadata, fileContent)
 	if err != nil {
 		err = errors.New("[SyncService.CallNeedContent] save file to latestDir: " + err.Error())
 		return err
@@ -1122,7 +1693,7 @@ func (ss *SyncService) CallNeedContent(file *types.File) error {
 
 	// update file
 	file.ContentsExisted = true
-	err = ss.syncRepository.UpdateFile(file)
+	err = ss.syncRepository.UpdateFile(ctx, file)
 	if err != nil {
 		err = errors.New("[SyncService.CallNeedContent] update file data: " + err.Error())
 		return err
@@ -1132,9 +1703,12 @@ func (ss *SyncService) CallNeedContent(file *types.File) error {
 }
 
 // GetFilesByRootDir returns files by root directory path
-func (ss *SyncService) GetFilesByRootDir(rootDirPath string) []types.File {
+func (ss *SyncService) GetFilesByRootDir(ctx context.Context, rootDirPath string) []types.File {
+	ctx, span := tracing.Start(ctx, "SyncService.GetFilesByRootDir")
+	defer span.End()
+
 	log.Println("quics: GetFilesByRootDir: ", rootDirPath)
-	files, err := ss.syncRepository.GetAllFiles(rootDirPath)
+	files, err := ss.syncRepository.GetAllFiles(ctx, rootDirPath)
 	if err != nil {
 		return nil
 	}
@@ -1143,9 +1717,12 @@ func (ss *SyncService) GetFilesByRootDir(rootDirPath string) []types.File {
 }
 
 // GetFiles returns all files in database
-func (ss *SyncService) GetFiles() []types.File {
+func (ss *SyncService) GetFiles(ctx context.Context) []types.File {
+	ctx, span := tracing.Start(ctx, "SyncService.GetFiles")
+	defer span.End()
+
 	log.Println("quics: GetFiles")
-	files, err := ss.syncRepository.GetAllFiles("")
+	files, err := ss.syncRepository.GetAllFiles(ctx, "")
 	if err != nil {
 		return nil
 	}
@@ -1153,20 +1730,38 @@ func (ss *SyncService) GetFiles() []types.File {
 }
 
 // GetFileByPath returns file entity by path
-func (ss *SyncService) GetFileByPath(path string) (*types.File, error) {
+func (ss *SyncService) GetFileByPath(ctx context.Context, path string) (*types.File, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetFileByPath")
+	defer span.End()
+
 	log.Println("quics: GetFileByPath: ", path)
-	return ss.syncRepository.GetFileByPath(path)
+	return ss.syncRepository.GetFileByPath(ctx, path)
 }
 
-func (ss *SyncService) RollbackFileByHistory(request *types.RollBackReq) (*types.RollBackRes, error) {
+func (ss *SyncService) RollbackFileByHistory(ctx context.Context, request *types.RollBackReq) (*types.RollBackRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.RollbackFileByHistory")
+	defer span.End()
+
 	log.Println("quics: RollbackFileByHistory: ", request)
-	fileData, err := ss.syncRepository.GetFileByPath(request.AfterPath)
+	fileData, err := ss.syncRepository.GetFileByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] get file data by path: " + err.Error())
 		return nil, err
 	}
 
-	historyData, err := ss.historyRepository.GetFileHistory(request.AfterPath, request.Version)
+	// check client has write permission on file's rootDir
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, fileData.RootDirKey)
+	if err != nil {
+		err = errors.New("[SyncService.RollbackFileByHistory] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+	err = ss.checkWritePermission(ctx, rootDir, request.UUID)
+	if err != nil {
+		err = errors.New("[SyncService.RollbackFileByHistory] check permission: " + err.Error())
+		return nil, err
+	}
+
+	historyData, err := ss.historyRepository.GetFileHistory(ctx, request.AfterPath, request.Version)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] get file history data by path: " + err.Error())
 		return nil, err
@@ -1181,7 +1776,7 @@ func (ss *SyncService) RollbackFileByHistory(request *types.RollBackReq) (*types
 		Hash:       historyData.Hash,
 		File:       historyData.File,
 	}
-	err = ss.historyRepository.SaveNewFileHistory(request.AfterPath, newHistoryData)
+	err = ss.historyRepository.SaveNewFileHistory(ctx, request.AfterPath, newHistoryData)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] save new file history data: " + err.Error())
 		return nil, err
@@ -1198,38 +1793,39 @@ func (ss *SyncService) RollbackFileByHistory(request *types.RollBackReq) (*types
 		NeedForceSync:       false,
 		Metadata:            newHistoryData.File,
 	}
-	err = ss.syncRepository.SaveFileByPath(newFileData.AfterPath, newFileData)
+	err = ss.syncRepository.SaveFileByPath(ctx, newFileData.AfterPath, newFileData)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] save file data: " + err.Error())
 		return nil, err
 	}
 
-	historyFileMetadata, historyFileInfo, err := ss.syncDirAdapter.GetFileFromHistoryDir(historyData.AfterPath, historyData.Timestamp)
+	historyFileMetadata, historyFileInfo, err := ss.syncDirAdapter.GetFileFromHistoryDir(ctx, historyData.AfterPath, historyData.Timestamp)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] get file from historyDir: " + err.Error())
 		return nil, err
 	}
 
-	err = ss.syncDirAdapter.SaveFileToHistoryDir(newHistoryData.AfterPath, newHistoryData.Timestamp, historyFileMetadata, historyFileInfo)
+	err = ss.syncDirAdapter.SaveFileToHistoryDir(ctx, newHistoryData.AfterPath, newHistoryData.Timestamp, historyFileMetadata, historyFileInfo)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] save file to historyDir: " + err.Error())
 		return nil, err
 	}
 
-	fileMetadata, fileInfo, err := ss.syncDirAdapter.GetFileFromHistoryDir(newHistoryData.AfterPath, newHistoryData.Timestamp)
+	fileMetadata, fileInfo, err := ss.syncDirAdapter.GetFileFromHistoryDir(ctx, newHistoryData.AfterPath, newHistoryData.Timestamp)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] get file from historyDir: " + err.Error())
 		return nil, err
 	}
 
-	err = ss.syncDirAdapter.SaveFileToLatestDir(newFileData.AfterPath, fileMetadata, fileInfo)
+	err = ss.syncDirAdapter.SaveFileToLatestDir(ctx, newFileData.AfterPath, fileMetadata, fileInfo)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] save file to latestDir: " + err.Error())
 		return nil, err
 	}
+	ss.auditService.Record(ctx, types.AuditRollbackFile, request.UUID, request.AfterPath, fmt.Sprint("version: ", request.Version))
 
 	// call must sync
-	rootDir, err := ss.syncRepository.GetRootDirByPath(newFileData.RootDirKey)
+	rootDir, err = ss.syncRepository.GetRootDirByPath(ctx, newFileData.RootDirKey)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] get rootDir data by path: " + err.Error())
 		return nil, err
@@ -1237,7 +1833,7 @@ func (ss *SyncService) RollbackFileByHistory(request *types.RollBackReq) (*types
 
 	UUIDs := rootDir.UUIDs
 
-	err = ss.CallMustSync(newFileData.AfterPath, UUIDs)
+	err = ss.CallMustSync(ctx, newFileData.AfterPath, UUIDs)
 	if err != nil {
 		err = errors.New("[SyncService.RollbackFileByHistory] call mustdync: " + err.Error())
 		return nil, err
@@ -1248,10 +1844,13 @@ func (ss *SyncService) RollbackFileByHistory(request *types.RollBackReq) (*types
 	}, nil
 }
 
-func (ss *SyncService) GetStagingNum(request *types.AskStagingNumReq) (*types.AskStagingNumRes, error) {
+func (ss *SyncService) GetStagingNum(ctx context.Context, request *types.AskStagingNumReq) (*types.AskStagingNumRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetStagingNum")
+	defer span.End()
+
 	log.Println("quics: GetStagingNum: ", request)
 	// get file by afterPath
-	file, err := ss.syncRepository.GetFileByPath(request.AfterPath)
+	file, err := ss.syncRepository.GetFileByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.GetStagingNum] get file data by path: " + err.Error())
 		return nil, err
@@ -1263,9 +1862,12 @@ func (ss *SyncService) GetStagingNum(request *types.AskStagingNumReq) (*types.As
 	}, nil
 }
 
-func (ss *SyncService) GetConflictFiles(request *types.AskStagingNumReq) ([]types.ConflictDownloadReq, error) {
+func (ss *SyncService) GetConflictFiles(ctx context.Context, request *types.AskStagingNumReq) ([]types.ConflictDownloadReq, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetConflictFiles")
+	defer span.End()
+
 	// get file by afterPath
-	conflict, err := ss.syncRepository.GetConflict(request.AfterPath)
+	conflict, err := ss.syncRepository.GetConflict(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.GetConflictFiles] get conflict data by path: " + err.Error())
 		return nil, err
@@ -1287,15 +1889,18 @@ func (ss *SyncService) GetConflictFiles(request *types.AskStagingNumReq) ([]type
 	return response, nil
 }
 
-func (ss *SyncService) DownloadHistory(request *types.DownloadHistoryReq) (*types.DownloadHistoryRes, string, error) {
+func (ss *SyncService) DownloadHistory(ctx context.Context, request *types.DownloadHistoryReq) (*types.DownloadHistoryRes, string, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.DownloadHistory")
+	defer span.End()
+
 	log.Println("quics: DownloadHistory: ", request)
-	history, err := ss.historyRepository.GetFileHistory(request.AfterPath, request.Version)
+	history, err := ss.historyRepository.GetFileHistory(ctx, request.AfterPath, request.Version)
 	if err != nil {
 		err = errors.New("[SyncService.DownloadHistory] get file history data: " + err.Error())
 		return nil, "", err
 	}
 
-	file, err := ss.syncRepository.GetFileByPath(request.AfterPath)
+	file, err := ss.syncRepository.GetFileByPath(ctx, request.AfterPath)
 	if err != nil {
 		err = errors.New("[SyncService.DownloadHistory] get file data: " + err.Error())
 		return nil, "", err
@@ -1309,6 +1914,122 @@ func (ss *SyncService) DownloadHistory(request *types.DownloadHistoryReq) (*type
 	}, filePath, nil
 }
 
+// InviteMember adds member to ACL of root directory, requester must be owner of root directory
+func (ss *SyncService) InviteMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.InviteMember")
+	defer span.End()
+
+	log.Println("quics: InviteMember: ", request.AfterPath, " ", request.Member, " (", request.Role, ") by ", requester)
+	if !types.IsValidRootRole(request.Role) {
+		return nil, errors.New("[SyncService.InviteMember] unknown role: " + request.Role)
+	}
+	if uuid, user := types.ParseMember(request.Member); uuid == "" && user == "" {
+		return nil, errors.New("[SyncService.InviteMember] member must be user:<name> or client:<uuid>")
+	}
+
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, request.AfterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return nil, ErrRootDirNotFound
+	}
+	if err != nil {
+		err = errors.New("[SyncService.InviteMember] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+
+	role, err := ss.GetRequesterRole(ctx, rootDir, requester)
+	if err != nil {
+		err = errors.New("[SyncService.InviteMember] get requester role: " + err.Error())
+		return nil, err
+	}
+	if role != types.RootRoleOwner {
+		return nil, ErrNotOwner
+	}
+
+	rootDir.Restrict()
+	rootDir.ACL[request.Member] = request.Role
+
+	err = ss.syncRepository.SaveRootDir(ctx, rootDir.AfterPath, rootDir)
+	if err != nil {
+		err = errors.New("[SyncService.InviteMember] save rootDir using repository: " + err.Error())
+		return nil, err
+	}
+
+	return &types.RootMemberRes{
+		UUID:      request.UUID,
+		AfterPath: rootDir.AfterPath,
+		Member:    request.Member,
+		Role:      request.Role,
+	}, nil
+}
+
+// RevokeMember removes member from ACL of root directory, requester must be owner of root directory.
+// Clients which lose access are disconnected from root directory.
+func (ss *SyncService) RevokeMember(ctx context.Context, requester string, request *types.RootMemberReq) (*types.RootMemberRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.RevokeMember")
+	defer span.End()
+
+	log.Println("quics: RevokeMember: ", request.AfterPath, " ", request.Member, " by ", requester)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, request.AfterPath)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return nil, ErrRootDirNotFound
+	}
+	if err != nil {
+		err = errors.New("[SyncService.RevokeMember] get rootDir data by path: " + err.Error())
+		return nil, err
+	}
+
+	role, err := ss.GetRequesterRole(ctx, rootDir, requester)
+	if err != nil {
+		err = errors.New("[SyncService.RevokeMember] get requester role: " + err.Error())
+		return nil, err
+	}
+	if role != types.RootRoleOwner {
+		return nil, ErrNotOwner
+	}
+
+	rootDir.Restrict()
+	delete(rootDir.ACL, request.Member)
+
+	// disconnect clients which can not access root directory anymore
+	UUIDs := []string{}
+	for _, UUID := range rootDir.UUIDs {
+		client, err := ss.registrationRepository.GetClientByUUID(ctx, UUID)
+		if err != nil {
+			err = errors.New("[SyncService.RevokeMember] get client data by uuid: " + err.Error())
+			return nil, err
+		}
+		if rootDir.GetRole(client.UUID, client.OwnerUser) != "" {
+			UUIDs = append(UUIDs, UUID)
+			continue
+		}
+
+		for i := 0; i < len(client.Root); i++ {
+			if client.Root[i].AfterPath == rootDir.AfterPath {
+				client.Root = append(client.Root[:i], client.Root[i+1:]...)
+				i--
+			}
+		}
+		err = ss.registrationRepository.SaveClient(ctx, client.UUID, client)
+		if err != nil {
+			err = errors.New("[SyncService.RevokeMember] save client using repository: " + err.Error())
+			return nil, err
+		}
+	}
+	rootDir.UUIDs = UUIDs
+
+	err = ss.syncRepository.SaveRootDir(ctx, rootDir.AfterPath, rootDir)
+	if err != nil {
+		err = errors.New("[SyncService.RevokeMember] save rootDir using repository: " + err.Error())
+		return nil, err
+	}
+
+	return &types.RootMemberRes{
+		UUID:      request.UUID,
+		AfterPath: rootDir.AfterPath,
+		Member:    request.Member,
+	}, nil
+}
+
 // ********************************************************************************
 //                                  Private Logic
 // ********************************************************************************
@@ -1337,3 +2058,90 @@ func validateGiveYouTransaction(file *types.File, giveYouRes *types.GiveYouRes)
 
 	return nil
 }
+
+// saveFullScanResult keeps the last fullscan result of the client to show server status
+func (ss *SyncService) saveFullScanResult(result types.FullScanResult, err error) {
+	result.EndTime = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	ss.fullScanMut.Lock()
+	defer ss.fullScanMut.Unlock()
+	ss.fullScanResults[result.UUID] = result
+}
+
+// GetRequesterRole gets the role of requester ("client:<uuid>", "user:<name>", admin or admin API token) in root directory
+func (ss *SyncService) GetRequesterRole(ctx context.Context, rootDir *types.RootDirectory, requester string) (string, error) {
+	if types.IsAdminRequester(requester) {
+		return types.RootRoleOwner, nil
+	}
+
+	uuid, user := types.ParseMember(requester)
+	if uuid != "" {
+		client, err := ss.registrationRepository.GetClientByUUID(ctx, uuid)
+		if err != nil {
+			return "", err
+		}
+		return rootDir.GetRole(client.UUID, client.OwnerUser), nil
+	}
+	return rootDir.GetRole("", user), nil
+}
+
+// checkWritePermission checks client is owner or writer of root directory
+func (ss *SyncService) checkWritePermission(ctx context.Context, rootDir *types.RootDirectory, uuid string) error {
+	role, err := ss.GetRequesterRole(ctx, rootDir, types.ClientMember(uuid))
+	if err != nil {
+		return err
+	}
+	if !types.CanWrite(role) {
+		return errors.New("client does not have write permission on " + rootDir.AfterPath)
+	}
+	return nil
+}
+
+// splitReaders splits clients into writers (or owners) and readers of the root directory of file
+func (ss *SyncService) splitReaders(ctx context.Context, filePath string, UUIDs []string) ([]string, []string) {
+	file, err := ss.syncRepository.GetFileByPath(ctx, filePath)
+	if err != nil {
+		return UUIDs, nil
+	}
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, file.RootDirKey)
+	if err != nil {
+		return UUIDs, nil
+	}
+
+	writerUUIDs, readerUUIDs := []string{}, []string{}
+	for _, UUID := range UUIDs {
+		role, err := ss.GetRequesterRole(ctx, rootDir, types.ClientMember(UUID))
+		if err == nil && role == types.RootRoleReader {
+			readerUUIDs = append(readerUUIDs, UUID)
+			continue
+		}
+		writerUUIDs = append(writerUUIDs, UUID)
+	}
+	return writerUUIDs, readerUUIDs
+}
+
+// lockPaths locks mutexes by hash value of file paths in ascending order, so that locks of several paths do not deadlock.
+// Using hash value is to reduce the number of mutex. It returns function which unlocks them.
+func (ss *SyncService) lockPaths(afterPaths []string) func() {
+	indexes := []byte{}
+	for _, afterPath := range afterPaths {
+		hash := sha1.Sum([]byte(afterPath))
+		index := hash[0] % ss.lockNum
+		if !slices.Contains(indexes, index) {
+			indexes = append(indexes, index)
+		}
+	}
+	slices.Sort(indexes)
+
+	for _, index := range indexes {
+		ss.pathMut[index].Lock()
+	}
+	return func() {
+		for _, index := range indexes {
+			ss.pathMut[index].Unlock()
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"golang.org/x/exp/slices"
+)
+
+// Upload is uploaded file of UploadFiles, fileContent is read when it is committed
+type Upload struct {
+	Request      *types.FileUploadReq
+	FileMetadata *types.FileMetadata
+	FileContent  io.Reader
+}
+
+// UploadFile commits uploaded file of requester ("user:<name>", "client:<uuid>" or admin) as new version of the file.
+// Conflicted file is not overwritten as PLEASESYNC does not overwrite it, and if ExpectedVersion is set,
+// the file must still be at that version (0 if the file must not exist), so that changes of other devices are not lost.
+// History is recorded with requester as editor, and MUSTSYNC is called to all clients of root directory.
+func (ss *SyncService) UploadFile(ctx context.Context, requester string, request *types.FileUploadReq, fileMetadata *types.FileMetadata, fileContent io.Reader) (*types.FileUploadRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.UploadFile")
+	defer span.End()
+
+	uploadResList, err := ss.UploadFiles(ctx, requester, []Upload{{Request: request, FileMetadata: fileMetadata, FileContent: fileContent}})
+	if err != nil {
+		return nil, err
+	}
+	return &uploadResList[0], nil
+}
+
+// UploadFiles commits uploaded files as UploadFile does. Every file is checked while paths of all files are locked,
+// and files are committed only if all of them can be committed, so that failure of one file does not leave others committed.
+func (ss *SyncService) UploadFiles(ctx context.Context, requester string, uploads []Upload) ([]types.FileUploadRes, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.UploadFiles")
+	defer span.End()
+
+	afterPaths := []string{}
+	for _, upload := range uploads {
+		if slices.Contains(afterPaths, upload.Request.AfterPath) {
+			return nil, ErrInvalidPath
+		}
+		afterPaths = append(afterPaths, upload.Request.AfterPath)
+	}
+
+	// version of file can not be changed by other upload between the check and the commit
+	unlock := ss.lockPaths(afterPaths)
+	defer unlock()
+
+	for _, upload := range uploads {
+		err := ss.CheckUpload(ctx, requester, upload.Request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	uploadResList := []types.FileUploadRes{}
+	for _, upload := range uploads {
+		log.Println("quics: UploadFile: ", upload.Request.AfterPath, " by ", requester)
+
+		file, err := ss.commitFile(ctx, requester, upload.Request.AfterPath, upload.FileMetadata, upload.FileContent)
+		if err != nil {
+			err = errors.New("[SyncService.UploadFiles] commit file: " + err.Error())
+			return nil, err
+		}
+
+		uploadResList = append(uploadResList, types.FileUploadRes{
+			AfterPath: file.AfterPath,
+			Timestamp: file.LatestSyncTimestamp,
+			Hash:      file.LatestHash,
+			Size:      file.Metadata.Size,
+		})
+	}
+
+	return uploadResList, nil
+}
+
+// CheckUpload checks requester can upload the file of request, so that contents are not received for upload which fails.
+// It returns ErrInvalidPath, ErrRootDirNotFound, ErrPermissionDenied, ErrFileConflicted, ErrContentsPending or ErrVersionMismatch.
+func (ss *SyncService) CheckUpload(ctx context.Context, requester string, request *types.FileUploadReq) error {
+	afterPath := request.AfterPath
+	if !strings.HasPrefix(afterPath, "/") || path.Clean(afterPath) != afterPath || strings.Count(afterPath, "/") < 2 {
+		return ErrInvalidPath
+	}
+	rootDirName, _ := utils.GetNamesByAfterPath(afterPath)
+	rootDir, err := ss.syncRepository.GetRootDirByPath(ctx, "/"+rootDirName)
+	if err == ss.syncRepository.ErrKeyNotFound() {
+		return ErrRootDirNotFound
+	}
+	if err != nil {
+		return errors.New("[SyncService.CheckUpload] get rootDir data by path: " + err.Error())
+	}
+
+	role, err := ss.GetRequesterRole(ctx, rootDir, requester)
+	if err != nil {
+		return errors.New("[SyncService.CheckUpload] get requester role: " + err.Error())
+	}
+	if !types.CanWrite(role) {
+		return ErrPermissionDenied
+	}
+
+	err = ss.checkUploadPath(ctx, rootDir, afterPath)
+	if err != nil {
+		return err
+	}
+
+	file, err := ss.syncRepository.GetFileByPath(ctx, afterPath)
+	if err != nil && err != ss.syncRepository.ErrKeyNotFound() {
+		return errors.New("[SyncService.CheckUpload] get file data by path: " + err.Error())
+	}
+	if err == nil && !reflect.ValueOf(file.Conflict).IsZero() {
+		return ErrFileConflicted
+	}
+	if err == nil && !file.ContentsExisted {
+		return ErrContentsPending
+	}
+	if request.ExpectedVersion != nil {
+		// removed file is at its version as existing file, but it is handled as not existing file for version 0
+		version := uint64(0)
+		if err == nil && file.LatestHash != "" {
+			version = file.LatestSyncTimestamp
+		}
+		if version != *request.ExpectedVersion {
+			return fmt.Errorf("%w (expected: %d, latest: %d)", ErrVersionMismatch, *request.ExpectedVersion, version)
+		}
+	}
+
+	return nil
+}
+
+// checkUploadPath checks file can be written at the path, which must not be root directory, directory or under file
+func (ss *SyncService) checkUploadPath(ctx context.Context, rootDir *types.RootDirectory, afterPath string) error {
+	if afterPath == rootDir.AfterPath {
+		return ErrInvalidPath
+	}
+
+	for dir := path.Dir(afterPath); dir != rootDir.AfterPath; dir = path.Dir(dir) {
+		file, err := ss.syncRepository.GetFileByPath(ctx, dir)
+		if err == nil && file.LatestHash != "" {
+			return ErrInvalidPath
+		}
+	}
+
+	files, err := ss.syncRepository.GetAllFiles(ctx, afterPath+"/")
+	if err != nil {
+		return errors.New("[SyncService.checkUploadPath] get files in directory: " + err.Error())
+	}
+	for _, file := range files {
+		if file.LatestHash != "" {
+			return ErrInvalidPath
+		}
+	}
+	return nil
+}
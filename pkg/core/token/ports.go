@@ -0,0 +1,22 @@
+package token
+
+import (
+	"context"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type Repository interface {
+	SaveToken(ctx context.Context, apiToken *types.APIToken) error
+	GetToken(ctx context.Context, id string) (*types.APIToken, error)
+	GetAllTokens(ctx context.Context) ([]types.APIToken, error)
+	ErrKeyNotFound() error
+}
+
+type Service interface {
+	CreateToken(ctx context.Context, createdBy string, request *types.APITokenReq) (*types.APITokenRes, error)
+	GetAllTokens(ctx context.Context) ([]types.APIToken, error)
+	RevokeToken(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, token string) (*types.APIToken, error)
+	RecordUsage(ctx context.Context, id string, endpoint string) error
+}
@@ -0,0 +1,210 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	// token is "qis_<id>_<secret>", id is used to find the token without scanning all tokens
+	tokenPrefix  = "qis_"
+	idLength     = 8
+	secretLength = 32
+
+	// session tokens (e.g., of dashboard login) live only for a browser session, so they expire even if logout is missed
+	sessionTokenTTL = 12 * time.Hour
+)
+
+type TokenService struct {
+	mu              sync.Mutex
+	tokenRepository Repository
+}
+
+func NewService(tokenRepository Repository) Service {
+	return &TokenService{
+		tokenRepository: tokenRepository,
+	}
+}
+
+// CreateToken creates new API token and returns it, only the hash of token is stored
+func (ts *TokenService) CreateToken(ctx context.Context, createdBy string, request *types.APITokenReq) (*types.APITokenRes, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.CreateToken")
+	defer span.End()
+
+	log.Println("quics: create API token (name: ", request.Name, ", scope: ", request.Scope, ", expire sec: ", request.ExpireSec, ", session: ", request.Session, ")")
+
+	if request.Name == "" {
+		return nil, errors.New("[TokenService.CreateToken] name is required")
+	}
+
+	scope := request.Scope
+	if scope == "" {
+		scope = types.TokenScopeRead
+	}
+	if scope != types.TokenScopeRead && scope != types.TokenScopeAdmin {
+		return nil, errors.New("[TokenService.CreateToken] unknown scope: " + scope)
+	}
+
+	id, err := randomHex(idLength)
+	if err != nil {
+		return nil, errors.New("[TokenService.CreateToken] generate id: " + err.Error())
+	}
+	secret, err := randomHex(secretLength)
+	if err != nil {
+		return nil, errors.New("[TokenService.CreateToken] generate secret: " + err.Error())
+	}
+	token := tokenPrefix + id + "_" + secret
+
+	ttl := time.Duration(request.ExpireSec) * time.Second
+	if request.Session && (ttl <= 0 || ttl > sessionTokenTTL) {
+		ttl = sessionTokenTTL
+	}
+
+	apiToken := &types.APIToken{
+		ID:        id,
+		Name:      request.Name,
+		Scope:     scope,
+		TokenHash: hashToken(token),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		Session:   request.Session,
+		Usage:     map[string]types.EndpointUsage{},
+	}
+	if ttl > 0 {
+		apiToken.ExpiresAt = apiToken.CreatedAt.Add(ttl)
+	}
+	err = ts.tokenRepository.SaveToken(ctx, apiToken)
+	if err != nil {
+		return nil, errors.New("[TokenService.CreateToken] save token: " + err.Error())
+	}
+
+	return &types.APITokenRes{
+		ID:        id,
+		Name:      apiToken.Name,
+		Scope:     scope,
+		Token:     token,
+		ExpiresAt: apiToken.ExpiresAt,
+	}, nil
+}
+
+// GetAllTokens gets all API tokens with endpoints called by them
+func (ts *TokenService) GetAllTokens(ctx context.Context) ([]types.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.GetAllTokens")
+	defer span.End()
+
+	apiTokens, err := ts.tokenRepository.GetAllTokens(ctx)
+	if err != nil {
+		return nil, errors.New("[TokenService.GetAllTokens] get all tokens: " + err.Error())
+	}
+
+	return apiTokens, nil
+}
+
+// RevokeToken revokes API token, revoked token is kept to show its usage
+func (ts *TokenService) RevokeToken(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "TokenService.RevokeToken")
+	defer span.End()
+
+	log.Println("quics: revoke API token (id: ", id, ")")
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	apiToken, err := ts.tokenRepository.GetToken(ctx, id)
+	if err != nil {
+		return errors.New("[TokenService.RevokeToken] get token: " + err.Error())
+	}
+
+	apiToken.Revoked = true
+	err = ts.tokenRepository.SaveToken(ctx, apiToken)
+	if err != nil {
+		return errors.New("[TokenService.RevokeToken] save token: " + err.Error())
+	}
+
+	return nil
+}
+
+// Authenticate checks token and returns stored API token
+func (ts *TokenService) Authenticate(ctx context.Context, token string) (*types.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.Authenticate")
+	defer span.End()
+
+	parts := strings.Split(strings.TrimPrefix(token, tokenPrefix), "_")
+	if !strings.HasPrefix(token, tokenPrefix) || len(parts) != 2 {
+		return nil, errors.New("[TokenService.Authenticate] token is malformed")
+	}
+
+	apiToken, err := ts.tokenRepository.GetToken(ctx, parts[0])
+	if err != nil {
+		return nil, errors.New("[TokenService.Authenticate] token is not correct")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiToken.TokenHash), []byte(hashToken(token))) != 1 {
+		return nil, errors.New("[TokenService.Authenticate] token is not correct")
+	}
+	if apiToken.Revoked {
+		return nil, errors.New("[TokenService.Authenticate] token is revoked")
+	}
+	if !apiToken.ExpiresAt.IsZero() && time.Now().After(apiToken.ExpiresAt) {
+		return nil, errors.New("[TokenService.Authenticate] token is expired")
+	}
+
+	return apiToken, nil
+}
+
+// RecordUsage records that endpoint ("<method> <path>") is called with API token
+func (ts *TokenService) RecordUsage(ctx context.Context, id string, endpoint string) error {
+	ctx, span := tracing.Start(ctx, "TokenService.RecordUsage")
+	defer span.End()
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	apiToken, err := ts.tokenRepository.GetToken(ctx, id)
+	if err != nil {
+		return errors.New("[TokenService.RecordUsage] get token: " + err.Error())
+	}
+
+	now := time.Now()
+	if apiToken.Usage == nil {
+		apiToken.Usage = map[string]types.EndpointUsage{}
+	}
+	usage := apiToken.Usage[endpoint]
+	usage.Count++
+	usage.LastCalledAt = now
+	apiToken.Usage[endpoint] = usage
+	apiToken.LastUsedAt = now
+
+	err = ts.tokenRepository.SaveToken(ctx, apiToken)
+	if err != nil {
+		return errors.New("[TokenService.RecordUsage] save token: " + err.Error())
+	}
+
+	return nil
+}
+
+func randomHex(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken returns SHA-256 hash of token, tokens have enough entropy so that slow hash is not needed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	accessKeyPrefix    = "QIS"
+	accessKeyIDLength  = 8
+	secretKeyLength    = 20
+	lastUsedAtInterval = time.Minute
+)
+
+// CreateAccessKey creates new S3 access key of user account, the secret key is only returned here
+func (us *UserService) CreateAccessKey(ctx context.Context, name string) (*types.AccessKeyRes, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateAccessKey")
+	defer span.End()
+
+	log.Println("quics: create access key (user: ", name, ")")
+
+	user, err := us.userRepository.GetUserByName(ctx, name)
+	if err != nil {
+		err = errors.New("[UserService.CreateAccessKey] get user by name: " + err.Error())
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.New("[UserService.CreateAccessKey] user is disabled")
+	}
+
+	id, err := randomHex(accessKeyIDLength)
+	if err != nil {
+		return nil, errors.New("[UserService.CreateAccessKey] generate access key ID: " + err.Error())
+	}
+	secret, err := randomHex(secretKeyLength)
+	if err != nil {
+		return nil, errors.New("[UserService.CreateAccessKey] generate secret key: " + err.Error())
+	}
+
+	accessKey := &types.AccessKey{
+		ID:        accessKeyPrefix + strings.ToUpper(id),
+		SecretKey: secret,
+		User:      user.Name,
+		CreatedAt: time.Now(),
+	}
+	err = us.userRepository.SaveAccessKey(ctx, accessKey)
+	if err != nil {
+		return nil, errors.New("[UserService.CreateAccessKey] save access key: " + err.Error())
+	}
+
+	return &types.AccessKeyRes{
+		AccessKeyID:     accessKey.ID,
+		SecretAccessKey: accessKey.SecretKey,
+		User:            accessKey.User,
+	}, nil
+}
+
+// GetAllAccessKeys gets all S3 access keys, secret keys are not shown in json
+func (us *UserService) GetAllAccessKeys(ctx context.Context) ([]types.AccessKey, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllAccessKeys")
+	defer span.End()
+
+	accessKeys, err := us.userRepository.GetAllAccessKeys(ctx)
+	if err != nil {
+		err = errors.New("[UserService.GetAllAccessKeys] get all access keys: " + err.Error())
+		return nil, err
+	}
+
+	return accessKeys, nil
+}
+
+// RevokeAccessKey revokes S3 access key, revoked key is kept to show when it was used
+func (us *UserService) RevokeAccessKey(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeAccessKey")
+	defer span.End()
+
+	log.Println("quics: revoke access key (ID: ", id, ")")
+
+	accessKey, err := us.userRepository.GetAccessKey(ctx, id)
+	if err != nil {
+		err = errors.New("[UserService.RevokeAccessKey] get access key: " + err.Error())
+		return err
+	}
+
+	accessKey.Revoked = true
+	err = us.userRepository.SaveAccessKey(ctx, accessKey)
+	if err != nil {
+		err = errors.New("[UserService.RevokeAccessKey] save access key: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// AuthenticateAccessKey returns S3 access key and its user, the caller verifies signature with the secret key.
+// It fails if the key is revoked or the user is disabled.
+func (us *UserService) AuthenticateAccessKey(ctx context.Context, id string) (*types.AccessKey, *types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AuthenticateAccessKey")
+	defer span.End()
+
+	accessKey, err := us.userRepository.GetAccessKey(ctx, id)
+	if err != nil {
+		err = errors.New("[UserService.AuthenticateAccessKey] get access key: " + err.Error())
+		return nil, nil, err
+	}
+	if accessKey.Revoked {
+		return nil, nil, errors.New("[UserService.AuthenticateAccessKey] access key is revoked")
+	}
+
+	user, err := us.userRepository.GetUserByName(ctx, accessKey.User)
+	if err != nil {
+		err = errors.New("[UserService.AuthenticateAccessKey] get user by name: " + err.Error())
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errors.New("[UserService.AuthenticateAccessKey] user is disabled")
+	}
+
+	// last use is not saved on every request, which would write database on every S3 call
+	if time.Since(accessKey.LastUsedAt) > lastUsedAtInterval {
+		accessKey.LastUsedAt = time.Now()
+		err = us.userRepository.SaveAccessKey(ctx, accessKey)
+		if err != nil {
+			log.Println("quics err: ", errors.New("[UserService.AuthenticateAccessKey] save access key: "+err.Error()))
+		}
+	}
+
+	return accessKey, user, nil
+}
+
+func randomHex(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"context"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type Repository interface {
+	SaveUser(ctx context.Context, user *types.User) error
+	GetUserByName(ctx context.Context, name string) (*types.User, error)
+	GetAllUsers(ctx context.Context) ([]types.User, error)
+	SaveAccessKey(ctx context.Context, accessKey *types.AccessKey) error
+	GetAccessKey(ctx context.Context, id string) (*types.AccessKey, error)
+	GetAllAccessKeys(ctx context.Context) ([]types.AccessKey, error)
+	ErrKeyNotFound() error
+}
+
+type Service interface {
+	AddUser(ctx context.Context, request *types.UserReq) error
+	GetAllUsers(ctx context.Context) ([]types.User, error)
+	DisableUser(ctx context.Context, name string) error
+	Authenticate(ctx context.Context, name string, password string) (*types.User, error)
+
+	CreateAccessKey(ctx context.Context, name string) (*types.AccessKeyRes, error)
+	GetAllAccessKeys(ctx context.Context) ([]types.AccessKey, error)
+	RevokeAccessKey(ctx context.Context, id string) error
+	AuthenticateAccessKey(ctx context.Context, id string) (*types.AccessKey, *types.User, error)
+}
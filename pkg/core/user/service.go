@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+type UserService struct {
+	userRepository Repository
+}
+
+func NewService(userRepository Repository) Service {
+	return &UserService{
+		userRepository: userRepository,
+	}
+}
+
+// AddUser creates new user account with hashed password
+func (us *UserService) AddUser(ctx context.Context, request *types.UserReq) error {
+	ctx, span := tracing.Start(ctx, "UserService.AddUser")
+	defer span.End()
+
+	log.Println("quics: add user (name: ", request.Name, ", role: ", request.Role, ")")
+
+	if request.Name == "" || request.Password == "" {
+		return errors.New("[UserService.AddUser] name and password are required")
+	}
+
+	role := request.Role
+	if role == "" {
+		role = types.RoleMember
+	}
+	if role != types.RoleAdmin && role != types.RoleMember {
+		return errors.New("[UserService.AddUser] unknown role: " + role)
+	}
+
+	_, err := us.userRepository.GetUserByName(ctx, request.Name)
+	if err == nil {
+		return errors.New("[UserService.AddUser] user is already exists")
+	} else if err != us.userRepository.ErrKeyNotFound() {
+		err = errors.New("[UserService.AddUser] get user by name: " + err.Error())
+		return err
+	}
+
+	passwordHash, err := utils.HashPassword(request.Password)
+	if err != nil {
+		err = errors.New("[UserService.AddUser] hash password: " + err.Error())
+		return err
+	}
+
+	user := &types.User{
+		Name:         request.Name,
+		PasswordHash: passwordHash,
+		Role:         role,
+	}
+	err = us.userRepository.SaveUser(ctx, user)
+	if err != nil {
+		err = errors.New("[UserService.AddUser] save user: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetAllUsers gets all user accounts
+func (us *UserService) GetAllUsers(ctx context.Context) ([]types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+
+	users, err := us.userRepository.GetAllUsers(ctx)
+	if err != nil {
+		err = errors.New("[UserService.GetAllUsers] get all users: " + err.Error())
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// DisableUser disables user account, so that clients can not be registered under the user anymore.
+// Certificates of clients of the user are refused while the user is disabled, and the caller closes their connections.
+func (us *UserService) DisableUser(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "UserService.DisableUser")
+	defer span.End()
+
+	log.Println("quics: disable user (name: ", name, ")")
+
+	user, err := us.userRepository.GetUserByName(ctx, name)
+	if err != nil {
+		err = errors.New("[UserService.DisableUser] get user by name: " + err.Error())
+		return err
+	}
+
+	user.Disabled = true
+	err = us.userRepository.SaveUser(ctx, user)
+	if err != nil {
+		err = errors.New("[UserService.DisableUser] save user: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate checks name and password of user account
+func (us *UserService) Authenticate(ctx context.Context, name string, password string) (*types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Authenticate")
+	defer span.End()
+
+	user, err := us.userRepository.GetUserByName(ctx, name)
+	if err != nil {
+		err = errors.New("[UserService.Authenticate] get user by name: " + err.Error())
+		return nil, err
+	}
+
+	if !utils.ComparePassword(user.PasswordHash, password) {
+		return nil, errors.New("[UserService.Authenticate] password is not correct")
+	}
+	if user.Disabled {
+		return nil, errors.New("[UserService.Authenticate] user is disabled")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package fs
+
+import "syscall"
+
+// getFreeSpace returns available bytes of the file system where path is located
+func getFreeSpace(path string) (uint64, error) {
+	stat := syscall.Statfs_t{}
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
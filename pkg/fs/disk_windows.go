@@ -0,0 +1,10 @@
+//go:build windows
+
+package fs
+
+import "errors"
+
+// getFreeSpace is not supported on windows yet
+func getFreeSpace(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
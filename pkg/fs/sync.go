@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"context"
 	"crypto/sha1"
 	"errors"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"regexp"
 	"sync"
 
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 	"github.com/quic-s/quics/pkg/utils"
 )
@@ -36,7 +38,10 @@ func NewSyncDir(syncDir string) *SyncDir {
 }
 
 // SyncFileToLatestDir creates/updates sync file to latest directory
-func (s *SyncDir) SaveFileToLatestDir(afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+func (s *SyncDir) SaveFileToLatestDir(ctx context.Context, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+	_, span := tracing.Start(ctx, "SyncDir.SaveFileToLatestDir")
+	defer span.End()
+
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
 	h := sha1.New()
@@ -57,7 +62,10 @@ func (s *SyncDir) SaveFileToLatestDir(afterPath string, fileMetadata *types.File
 	return nil
 }
 
-func (s *SyncDir) GetFileFromLatestDir(afterPath string) (*types.FileMetadata, io.Reader, error) {
+func (s *SyncDir) GetFileFromLatestDir(ctx context.Context, afterPath string) (*types.FileMetadata, io.Reader, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFileFromLatestDir")
+	defer span.End()
+
 	latestFilePath := filepath.Join(s.SyncDir, afterPath)
 
 	file, err := os.Open(latestFilePath)
@@ -75,7 +83,10 @@ func (s *SyncDir) GetFileFromLatestDir(afterPath string) (*types.FileMetadata, i
 	return types.NewFileMetadataFromOSFileInfo(fileInfo), file, nil
 }
 
-func (s *SyncDir) DeleteFileFromLatestDir(afterPath string) error {
+func (s *SyncDir) DeleteFileFromLatestDir(ctx context.Context, afterPath string) error {
+	_, span := tracing.Start(ctx, "SyncDir.DeleteFileFromLatestDir")
+	defer span.End()
+
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
 	h := sha1.New()
@@ -116,7 +127,10 @@ func (s *SyncDir) DeleteFileFromLatestDir(afterPath string) error {
 	return nil
 }
 
-func (s *SyncDir) SaveFileToConflictDir(uuid string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+func (s *SyncDir) SaveFileToConflictDir(ctx context.Context, uuid string, afterPath string, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+	_, span := tracing.Start(ctx, "SyncDir.SaveFileToConflictDir")
+	defer span.End()
+
 	err := fileMetadata.WriteFileWithInfo(utils.GetConflictFileNameByAfterPath(afterPath, uuid), fileContent)
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -126,7 +140,10 @@ func (s *SyncDir) SaveFileToConflictDir(uuid string, afterPath string, fileMetad
 	return nil
 }
 
-func (s *SyncDir) GetFileFromConflictDir(afterPath string, uuid string) (*types.FileMetadata, io.Reader, error) {
+func (s *SyncDir) GetFileFromConflictDir(ctx context.Context, afterPath string, uuid string) (*types.FileMetadata, io.Reader, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFileFromConflictDir")
+	defer span.End()
+
 	file, err := os.Open(utils.GetConflictFileNameByAfterPath(afterPath, uuid))
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -142,7 +159,10 @@ func (s *SyncDir) GetFileFromConflictDir(afterPath string, uuid string) (*types.
 	return types.NewFileMetadataFromOSFileInfo(fileInfo), file, nil
 }
 
-func (s *SyncDir) GetFileInfoFromConflictDir(afterPath string, uuid string) (*types.FileMetadata, error) {
+func (s *SyncDir) GetFileInfoFromConflictDir(ctx context.Context, afterPath string, uuid string) (*types.FileMetadata, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFileInfoFromConflictDir")
+	defer span.End()
+
 	fileInfo, err := os.Stat(utils.GetConflictFileNameByAfterPath(afterPath, uuid))
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -152,7 +172,10 @@ func (s *SyncDir) GetFileInfoFromConflictDir(afterPath string, uuid string) (*ty
 	return types.NewFileMetadataFromOSFileInfo(fileInfo), nil
 }
 
-func (s *SyncDir) DeleteFilesFromConflictDir(afterPath string) error {
+func (s *SyncDir) DeleteFilesFromConflictDir(ctx context.Context, afterPath string) error {
+	_, span := tracing.Start(ctx, "SyncDir.DeleteFilesFromConflictDir")
+	defer span.End()
+
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
 	h := sha1.New()
@@ -209,7 +232,10 @@ func (s *SyncDir) DeleteFilesFromConflictDir(afterPath string) error {
 }
 
 // SyncFileToHistoryDir creates/updates sync file to history directory
-func (s *SyncDir) SaveFileToHistoryDir(afterPath string, timestamp uint64, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+func (s *SyncDir) SaveFileToHistoryDir(ctx context.Context, afterPath string, timestamp uint64, fileMetadata *types.FileMetadata, fileContent io.Reader) error {
+	_, span := tracing.Start(ctx, "SyncDir.SaveFileToHistoryDir")
+	defer span.End()
+
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
 	h := sha1.New()
@@ -231,7 +257,10 @@ func (s *SyncDir) SaveFileToHistoryDir(afterPath string, timestamp uint64, fileM
 	return nil
 }
 
-func (s *SyncDir) GetFileFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error) {
+func (s *SyncDir) GetFileFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, io.Reader, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFileFromHistoryDir")
+	defer span.End()
+
 	file, err := os.Open(utils.GetHistoryFileNameByAfterPath(afterPath, timestamp))
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -247,7 +276,10 @@ func (s *SyncDir) GetFileFromHistoryDir(afterPath string, timestamp uint64) (*ty
 	return types.NewFileMetadataFromOSFileInfo(fileInfo), file, nil
 }
 
-func (s *SyncDir) GetFileInfoFromHistoryDir(afterPath string, timestamp uint64) (*types.FileMetadata, error) {
+func (s *SyncDir) GetFileInfoFromHistoryDir(ctx context.Context, afterPath string, timestamp uint64) (*types.FileMetadata, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFileInfoFromHistoryDir")
+	defer span.End()
+
 	fileInfo, err := os.Stat(utils.GetHistoryFileNameByAfterPath(afterPath, timestamp))
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -256,3 +288,32 @@ func (s *SyncDir) GetFileInfoFromHistoryDir(afterPath string, timestamp uint64)
 
 	return types.NewFileMetadataFromOSFileInfo(fileInfo), nil
 }
+
+// CheckWritable checks sync directory is writable by creating and removing temporary file
+func (s *SyncDir) CheckWritable(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "SyncDir.CheckWritable")
+	defer span.End()
+
+	file, err := os.CreateTemp(s.SyncDir, ".quics-ready-*")
+	if err != nil {
+		log.Println("quics err: ", err)
+		return err
+	}
+	file.Close()
+
+	err = os.Remove(file.Name())
+	if err != nil {
+		log.Println("quics err: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetFreeSpace gets available disk space of sync directory in bytes
+func (s *SyncDir) GetFreeSpace(ctx context.Context) (uint64, error) {
+	_, span := tracing.Start(ctx, "SyncDir.GetFreeSpace")
+	defer span.End()
+
+	return getFreeSpace(s.SyncDir)
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const (
+	MeterName = "github.com/quic-s/quics"
+
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+
+	AttrTransactionName = attribute.Key("quics.transaction.name")
+	AttrLockoutKind     = attribute.Key("quics.lockout.kind")
+)
+
+var (
+	authFailures metric.Int64Counter
+	lockouts     metric.Int64Counter
+)
+
+// instruments are created with global meter provider,
+// they are delegated to the provider which is set in Init
+func init() {
+	meter := otel.Meter(MeterName)
+
+	var err error
+	authFailures, err = meter.Int64Counter("quics.auth.failures",
+		metric.WithDescription("Number of failed authentication attempts"))
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+	lockouts, err = meter.Int64Counter("quics.auth.lockouts",
+		metric.WithDescription("Number of lockouts caused by failed authentication attempts"))
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+}
+
+// Init sets the global meter provider with the exporter configured in qis.env
+// and returns the function to flush and stop it
+func Init() (func(context.Context) error, error) {
+	exporterName := config.GetViperEnvVariables("METRICS_EXPORTER")
+	if exporterName == "" {
+		exporterName = config.DefaultMetricsExporter
+	}
+
+	var exporter sdkmetric.Exporter
+	closeFuncs := []func() error{}
+	switch exporterName {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		endpoint := config.GetViperEnvVariables("METRICS_OTLP_ENDPOINT")
+		if endpoint == "" {
+			endpoint = config.DefaultMetricsOTLPEndpoint
+		}
+
+		options := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+		if config.GetViperEnvVariables("METRICS_OTLP_INSECURE") != "false" {
+			options = append(options, otlpmetrichttp.WithInsecure())
+		}
+
+		otlpExporter, err := otlpmetrichttp.New(context.Background(), options...)
+		if err != nil {
+			err = errors.New("[metrics.Init] create otlp exporter: " + err.Error())
+			return nil, err
+		}
+		exporter = otlpExporter
+	case ExporterFile:
+		filePath := config.GetViperEnvVariables("METRICS_FILE_PATH")
+		if filePath == "" {
+			filePath = filepath.Join(utils.GetQuicsDirPath(), config.DefaultMetricsFileName)
+		}
+
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			err = errors.New("[metrics.Init] open metrics file: " + err.Error())
+			return nil, err
+		}
+		closeFuncs = append(closeFuncs, file.Close)
+
+		fileExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(file))
+		if err != nil {
+			err = errors.New("[metrics.Init] create file exporter: " + err.Error())
+			return nil, err
+		}
+		exporter = fileExporter
+	default:
+		return nil, errors.New("[metrics.Init] unknown exporter: " + exporterName)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("quics"),
+	))
+	if err != nil {
+		err = errors.New("[metrics.Init] create resource: " + err.Error())
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(time.Minute))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(provider)
+	log.Println("quics: metrics enabled with ", exporterName, " exporter")
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		for _, closeFunc := range closeFuncs {
+			closeFunc()
+		}
+		return err
+	}, nil
+}
+
+// AddAuthFailure counts failed authentication attempt of transaction
+func AddAuthFailure(ctx context.Context, transactionName string) {
+	authFailures.Add(ctx, 1, metric.WithAttributes(AttrTransactionName.String(transactionName)))
+}
+
+// AddLockout counts lockout of source IP or client UUID (kind is "ip" or "uuid")
+func AddLockout(ctx context.Context, transactionName string, kind string) {
+	lockouts.Add(ctx, 1, metric.WithAttributes(
+		AttrTransactionName.String(transactionName),
+		AttrLockoutKind.String(kind),
+	))
+}
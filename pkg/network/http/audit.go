@@ -0,0 +1,115 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type AuditHandler struct {
+	auditService audit.Service
+	auth         *Authenticator
+}
+
+func NewAuditHandler(auditService audit.Service, auth *Authenticator) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		auth:         auth,
+	}
+}
+
+func (ah *AuditHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/audit", ah.GetEvents)
+	mux.HandleFunc("/api/v1/audit/verify", ah.Verify)
+}
+
+// GetEvents returns audit events filtered by query (e.g., ?since=24h&actor=<UUID>&path=/rootDir)
+// since is RFC3339 time or duration before now
+func (ah *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		since := time.Time{}
+		if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+			var err error
+			since, err = parseSince(sinceParam)
+			if err != nil {
+				http.Error(w, "since should be RFC3339 time or duration (e.g., 24h)", http.StatusBadRequest)
+				return
+			}
+		}
+
+		auditEvents, err := ah.auditService.GetEvents(r.Context(), since, r.URL.Query().Get("actor"), r.URL.Query().Get("path"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(auditEvents)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// Verify checks hash chain of audit log
+func (ah *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		verifyRes, err := ah.auditService.Verify(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(verifyRes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func parseSince(since string) (time.Time, error) {
+	duration, err := time.ParseDuration(since)
+	if err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	return time.Parse(time.RFC3339, since)
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/server"
+	"github.com/quic-s/quics/pkg/core/token"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+type Authenticator struct {
+	serverService server.Service
+	userService   user.Service
+	tokenService  token.Service
+	auditService  audit.Service
+}
+
+func NewAuthenticator(serverService server.Service, userService user.Service, tokenService token.Service, auditService audit.Service) *Authenticator {
+	return &Authenticator{
+		serverService: serverService,
+		userService:   userService,
+		tokenService:  tokenService,
+		auditService:  auditService,
+	}
+}
+
+// Authenticate returns requester for root directory ACL.
+// Admin API token, admin user and server password are returned as admin, other users as "user:<name>".
+// Server password is only accepted with user name admin, and failed basic auth is locked out per source IP and user name.
+func (a *Authenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if _, ok := bearerToken(r); ok {
+		if !a.Authorize(w, r, types.TokenScopeAdmin) {
+			return "", false
+		}
+		return types.AdminMember, true
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"quics\"")
+		writeError(w, r, http.StatusUnauthorized, "authorization is required")
+		return "", false
+	}
+
+	ip := remoteIP(r)
+	lockoutService := a.serverService.GetLockoutService()
+	err := lockoutService.CheckUser(r.Context(), ip, username)
+	if err != nil {
+		log.Println("quics err: ", err)
+		writeError(w, r, http.StatusTooManyRequests, "too many failed attempts, try again later")
+		return "", false
+	}
+
+	user, err := a.userService.Authenticate(r.Context(), username, password)
+	if err == nil {
+		lockoutService.RecordUserSuccess(r.Context(), username)
+		if user.Role == types.RoleAdmin {
+			return types.AdminMember, true
+		}
+		return types.UserMember(user.Name), true
+	}
+
+	if username != types.AdminMember || a.serverService.VerifyPassword(r.Context(), password) != nil {
+		lockoutService.RecordUserFailure(r.Context(), "rest", ip, username)
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"quics\"")
+		writeError(w, r, http.StatusUnauthorized, "user name or password is not correct")
+		return "", false
+	}
+	lockoutService.RecordUserSuccess(r.Context(), username)
+
+	return types.AdminMember, true
+}
+
+// Authorize checks bearer API token of request has the scope (read or admin)
+// and writes 401 or 403 response if it is not authorized.
+// Authorized call is recorded to the token and audit log.
+func (a *Authenticator) Authorize(w http.ResponseWriter, r *http.Request, scope string) bool {
+	_, ok := a.authorizeToken(w, r, scope)
+	return ok
+}
+
+// AuthorizeToken is Authorize which also returns requester of the token ("token:<ID>"),
+// so that changes made with admin API token are recorded by the token
+func (a *Authenticator) AuthorizeToken(w http.ResponseWriter, r *http.Request, scope string) (string, bool) {
+	apiToken, ok := a.authorizeToken(w, r, scope)
+	if !ok {
+		return "", false
+	}
+	return types.TokenMember(apiToken.ID), true
+}
+
+// AuthorizeAdmin checks admin API token, or basic auth of admin user account or server password.
+// It is only used to create API tokens, so that the first token can be created.
+func (a *Authenticator) AuthorizeAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if _, ok := bearerToken(r); ok {
+		apiToken, ok := a.authorizeToken(w, r, types.TokenScopeAdmin)
+		if !ok {
+			return "", false
+		}
+		return types.TokenMember(apiToken.ID), true
+	}
+
+	requester, ok := a.Authenticate(w, r)
+	if !ok {
+		return "", false
+	}
+	if requester != types.AdminMember {
+		writeError(w, r, http.StatusForbidden, "admin user is required")
+		return "", false
+	}
+	a.recordAdminCall(r, requester)
+
+	return requester, true
+}
+
+func (a *Authenticator) authorizeToken(w http.ResponseWriter, r *http.Request, scope string) (*types.APIToken, bool) {
+	bearer, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer realm=\"quics\"")
+		writeError(w, r, http.StatusUnauthorized, "API token is required")
+		return nil, false
+	}
+
+	apiToken, err := a.tokenService.Authenticate(r.Context(), bearer)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer realm=\"quics\", error=\"invalid_token\"")
+		writeError(w, r, http.StatusUnauthorized, "API token is not correct")
+		return nil, false
+	}
+	if scope == types.TokenScopeAdmin && apiToken.Scope != types.TokenScopeAdmin {
+		writeError(w, r, http.StatusForbidden, "API token does not have admin scope")
+		return nil, false
+	}
+
+	err = a.tokenService.RecordUsage(r.Context(), apiToken.ID, r.Method+" "+r.URL.Path)
+	if err != nil {
+		log.Println("quics err: ", err)
+	}
+	a.recordAdminCall(r, types.TokenMember(apiToken.ID))
+
+	return apiToken, true
+}
+
+func (a *Authenticator) recordAdminCall(r *http.Request, actor string) {
+	ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+	a.auditService.Record(ctx, types.AuditAdminCall, actor, r.URL.Path, r.Method+" "+r.URL.RequestURI())
+}
+
+// remoteIP returns IP address of request without port
+func remoteIP(r *http.Request) string {
+	return utils.GetIPFromHostPort(r.RemoteAddr)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer ")), true
+}
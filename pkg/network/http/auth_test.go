@@ -0,0 +1,213 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/server"
+	"github.com/quic-s/quics/pkg/core/token"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+var errNotFound = errors.New("key not found")
+
+type fakeTokenRepository struct {
+	tokens map[string]types.APIToken
+}
+
+func (r *fakeTokenRepository) SaveToken(ctx context.Context, apiToken *types.APIToken) error {
+	r.tokens[apiToken.ID] = *apiToken
+	return nil
+}
+
+func (r *fakeTokenRepository) GetToken(ctx context.Context, id string) (*types.APIToken, error) {
+	apiToken, ok := r.tokens[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &apiToken, nil
+}
+
+func (r *fakeTokenRepository) GetAllTokens(ctx context.Context) ([]types.APIToken, error) {
+	apiTokens := []types.APIToken{}
+	for _, apiToken := range r.tokens {
+		apiTokens = append(apiTokens, apiToken)
+	}
+	return apiTokens, nil
+}
+
+func (r *fakeTokenRepository) ErrKeyNotFound() error {
+	return errNotFound
+}
+
+type fakeAuditService struct {
+	audit.Service
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, action string, actor string, path string, detail string) {
+}
+
+type fakeServerService struct {
+	server.Service
+	password       string
+	lockoutService lockout.Service
+}
+
+func (s *fakeServerService) VerifyPassword(ctx context.Context, password string) error {
+	if password != s.password {
+		return errors.New("password is not correct")
+	}
+	return nil
+}
+
+func (s *fakeServerService) GetLockoutService() lockout.Service {
+	return s.lockoutService
+}
+
+type fakeUserService struct {
+	user.Service
+}
+
+func (s *fakeUserService) Authenticate(ctx context.Context, name string, password string) (*types.User, error) {
+	return nil, errNotFound
+}
+
+// fakeLockoutService counts failures of login names and never locks out
+type fakeLockoutService struct {
+	lockout.Service
+	failures map[string]int
+}
+
+func (s *fakeLockoutService) CheckUser(ctx context.Context, ip string, name string) error {
+	return nil
+}
+
+func (s *fakeLockoutService) RecordUserFailure(ctx context.Context, protocol string, ip string, name string) {
+	s.failures[name]++
+}
+
+func (s *fakeLockoutService) RecordUserSuccess(ctx context.Context, name string) {}
+
+func newTestAuthenticator() (*Authenticator, token.Service, *fakeTokenRepository, *fakeLockoutService) {
+	tokenRepository := &fakeTokenRepository{tokens: map[string]types.APIToken{}}
+	tokenService := token.NewService(tokenRepository)
+	lockoutService := &fakeLockoutService{failures: map[string]int{}}
+	serverService := &fakeServerService{password: "server password", lockoutService: lockoutService}
+	auth := NewAuthenticator(serverService, &fakeUserService{}, tokenService, &fakeAuditService{})
+	return auth, tokenService, tokenRepository, lockoutService
+}
+
+func authorize(auth *Authenticator, bearer string, scope string) int {
+	r := httptest.NewRequest("GET", "/api/v2/status", nil)
+	r.Header.Set("Authorization", "Bearer "+bearer)
+	w := httptest.NewRecorder()
+	if auth.Authorize(w, r, scope) {
+		return http.StatusOK
+	}
+	return w.Code
+}
+
+func TestAuthorizeScope(t *testing.T) {
+	auth, tokenService, _, _ := newTestAuthenticator()
+	readToken, err := tokenService.CreateToken(context.Background(), types.AdminMember, &types.APITokenReq{Name: "read", Scope: types.TokenScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminToken, err := tokenService.CreateToken(context.Background(), types.AdminMember, &types.APITokenReq{Name: "admin", Scope: types.TokenScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		bearer string
+		scope  string
+		want   int
+	}{
+		{"read token on read API", readToken.Token, types.TokenScopeRead, http.StatusOK},
+		{"read token on admin API", readToken.Token, types.TokenScopeAdmin, http.StatusForbidden},
+		{"admin token on read API", adminToken.Token, types.TokenScopeRead, http.StatusOK},
+		{"admin token on admin API", adminToken.Token, types.TokenScopeAdmin, http.StatusOK},
+		{"wrong secret", adminToken.Token + "0", types.TokenScopeRead, http.StatusUnauthorized},
+		{"malformed token", "token", types.TokenScopeRead, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := authorize(auth, tt.bearer, tt.scope); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeRevokedToken(t *testing.T) {
+	auth, tokenService, _, _ := newTestAuthenticator()
+	apiToken, err := tokenService.CreateToken(context.Background(), types.AdminMember, &types.APITokenReq{Name: "admin", Scope: types.TokenScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tokenService.RevokeToken(context.Background(), apiToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := authorize(auth, apiToken.Token, types.TokenScopeRead); got != http.StatusUnauthorized {
+		t.Fatalf("revoked token: got %d, want %d", got, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthorizeExpiredToken(t *testing.T) {
+	auth, tokenService, tokenRepository, _ := newTestAuthenticator()
+	apiToken, err := tokenService.CreateToken(context.Background(), types.AdminMember, &types.APITokenReq{Name: "dashboard:admin", Scope: types.TokenScopeAdmin, Session: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiToken.ExpiresAt.IsZero() || apiToken.ExpiresAt.After(time.Now().Add(12*time.Hour)) {
+		t.Fatalf("session token expires at %v, want within 12 hours", apiToken.ExpiresAt)
+	}
+	if got := authorize(auth, apiToken.Token, types.TokenScopeAdmin); got != http.StatusOK {
+		t.Fatalf("session token before expiry: got %d, want %d", got, http.StatusOK)
+	}
+
+	stored := tokenRepository.tokens[apiToken.ID]
+	stored.ExpiresAt = time.Now().Add(-time.Second)
+	tokenRepository.tokens[apiToken.ID] = stored
+	if got := authorize(auth, apiToken.Token, types.TokenScopeRead); got != http.StatusUnauthorized {
+		t.Fatalf("expired token: got %d, want %d", got, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthenticateServerPassword(t *testing.T) {
+	auth, _, _, lockoutService := newTestAuthenticator()
+
+	tests := []struct {
+		username string
+		password string
+		want     bool
+	}{
+		{types.AdminMember, "server password", true},
+		{"someone", "server password", false},
+		{types.AdminMember, "wrong password", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v2/files", nil)
+		r.SetBasicAuth(tt.username, tt.password)
+		w := httptest.NewRecorder()
+
+		requester, ok := auth.Authenticate(w, r)
+		if ok != tt.want {
+			t.Fatalf("basic auth of %s with %s: got %v, want %v", tt.username, tt.password, ok, tt.want)
+		}
+		if ok && requester != types.AdminMember {
+			t.Fatalf("basic auth of %s: got requester %s, want %s", tt.username, requester, types.AdminMember)
+		}
+	}
+	if lockoutService.failures["someone"] != 1 || lockoutService.failures[types.AdminMember] != 1 {
+		t.Fatalf("failures are not recorded to lockout: %v", lockoutService.failures)
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/quic-s/quics/pkg/config"
+)
+
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// DashboardHandler serves admin dashboard, which is a single page app embedded in the binary.
+// The app calls REST API with admin API token, so it does not need any authorization to be served.
+type DashboardHandler struct {
+	assets http.Handler
+}
+
+func NewDashboardHandler() *DashboardHandler {
+	assets, _ := fs.Sub(dashboardAssets, "dashboard")
+	return &DashboardHandler{
+		assets: http.StripPrefix("/dashboard/", http.FileServer(http.FS(assets))),
+	}
+}
+
+func (dh *DashboardHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/dashboard/", dh.ServeDashboard)
+}
+
+// ServeDashboard serves assets of dashboard, scripts are only loaded from the server and the page can not be framed
+func (dh *DashboardHandler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET", "HEAD":
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Cache-Control", "no-cache")
+		dh.assets.ServeHTTP(w, r)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "HEAD")
+	}
+}
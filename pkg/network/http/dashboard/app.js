@@ -0,0 +1,614 @@
+'use strict';
+
+// Admin dashboard of quics server.
+// It calls REST API with admin API token, which is given at login or created for admin account and revoked at logout.
+
+const SESSION_KEY = 'quics.dashboard';
+const MAX_ACTIVITY = 200;
+// token of password login expires after this, server does not accept longer lifetime for session tokens
+const SESSION_TTL_SEC = 12 * 60 * 60;
+
+const state = {
+  session: null,
+  stream: null,
+  connected: new Set(),
+  activity: [],
+};
+
+// ---------- helpers ----------
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+// el creates element with attributes and children, strings are added as text so that data is never parsed as HTML
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key.startsWith('on')) {
+      node.addEventListener(key.slice(2), value);
+    } else if (value !== undefined && value !== null && value !== false) {
+      node.setAttribute(key, value === true ? '' : value);
+    }
+  }
+  for (const child of children.flat()) {
+    if (child !== undefined && child !== null) {
+      node.append(child instanceof Node ? child : String(child));
+    }
+  }
+  return node;
+}
+
+function button(label, onclick, className) {
+  return el('button', { type: 'button', class: className || 'link', onclick }, label);
+}
+
+function formatTime(value) {
+  if (!value || value.startsWith('0001-01-01')) {
+    return '-';
+  }
+  return new Date(value).toLocaleString();
+}
+
+function formatSize(size) {
+  const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+  let i = 0;
+  while (size >= 1024 && i < units.length - 1) {
+    size /= 1024;
+    i++;
+  }
+  return (i === 0 ? size : size.toFixed(1)) + ' ' + units[i];
+}
+
+function short(value, length) {
+  if (!value) {
+    return '-';
+  }
+  return value.length > length ? value.slice(0, length) + '…' : value;
+}
+
+function showError(err) {
+  $('error').textContent = err ? err.message || String(err) : '';
+}
+
+function sleep(ms) {
+  return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+// ---------- api ----------
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+// request calls REST API with API token of session, errors of /api/v2 are JSON and others are text
+async function request(method, url, options) {
+  options = options || {};
+  const headers = Object.assign({}, options.headers);
+  if (!headers.Authorization && state.session) {
+    headers.Authorization = 'Bearer ' + state.session.token;
+  }
+  let body;
+  if (options.json !== undefined) {
+    headers['Content-Type'] = 'application/json';
+    body = JSON.stringify(options.json);
+  }
+
+  const res = await fetch(url, { method, headers, body, credentials: 'omit' });
+  if (!res.ok) {
+    const text = await res.text();
+    let message = text.trim() || res.statusText;
+    try {
+      message = JSON.parse(text).Message || message;
+    } catch (e) {
+      // plain text error of /api/v1
+    }
+    if (res.status === 401 && state.session && !options.headers) {
+      endSession();
+    }
+    throw new APIError(res.status, res.status + ': ' + message);
+  }
+  if (options.raw) {
+    return res;
+  }
+  if (res.status === 204) {
+    return null;
+  }
+  const type = res.headers.get('Content-Type') || '';
+  return type.includes('application/json') ? res.json() : null;
+}
+
+function query(params) {
+  const search = new URLSearchParams();
+  for (const [key, value] of Object.entries(params)) {
+    if (value !== undefined && value !== null && value !== '' && value !== false) {
+      search.set(key, value);
+    }
+  }
+  return search.toString();
+}
+
+async function download(url, name) {
+  const res = await request('GET', url, { raw: true });
+  const blob = await res.blob();
+  const link = el('a', { href: URL.createObjectURL(blob), download: name });
+  document.body.append(link);
+  link.click();
+  link.remove();
+  setTimeout(() => URL.revokeObjectURL(link.href), 10000);
+}
+
+// run runs action of button and shows its error, view is reloaded after action
+function run(action) {
+  return async () => {
+    showError(null);
+    try {
+      await action();
+      await render();
+    } catch (err) {
+      showError(err);
+    }
+  };
+}
+
+// ---------- session ----------
+
+async function login(form) {
+  const user = form.user.value.trim();
+  const password = form.password.value;
+  const token = form.token.value.trim();
+
+  if (token) {
+    state.session = { token, name: 'API token' };
+    try {
+      await request('GET', '/api/v2/status');
+    } catch (err) {
+      state.session = null;
+      throw err;
+    }
+  } else {
+    if (!password) {
+      throw new Error('password or API token is required');
+    }
+    // admin account (or server password) creates admin token for this session, it is revoked at logout
+    const created = await request('POST', '/api/v1/tokens/create', {
+      // server password is only accepted with user name admin
+      headers: { Authorization: 'Basic ' + btoa(unescape(encodeURIComponent((user || 'admin') + ':' + password))) },
+      json: { Name: 'dashboard:' + (user || 'admin'), Scope: 'admin', ExpireSec: SESSION_TTL_SEC, Session: true },
+    });
+    state.session = { token: created.Token, tokenID: created.ID, name: user || 'admin' };
+  }
+
+  sessionStorage.setItem(SESSION_KEY, JSON.stringify(state.session));
+  startSession();
+}
+
+async function logout() {
+  const session = state.session;
+  if (session && session.tokenID) {
+    try {
+      await request('POST', '/api/v1/tokens/revoke?' + query({ id: session.tokenID }));
+    } catch (err) {
+      // token is already revoked
+    }
+  }
+  endSession();
+}
+
+function startSession() {
+  $('login').hidden = true;
+  $('app').hidden = false;
+  $('whoami').textContent = state.session.name;
+  if (!location.hash) {
+    location.hash = '#overview';
+  }
+  loadActivity();
+  render();
+}
+
+function endSession() {
+  if (state.stream) {
+    state.stream.abort();
+  }
+  state.session = null;
+  state.stream = null;
+  state.activity = [];
+  sessionStorage.removeItem(SESSION_KEY);
+  $('app').hidden = true;
+  $('login').hidden = false;
+}
+
+// ---------- views ----------
+
+const views = {
+  overview: renderOverview,
+  clients: renderClients,
+  directories: renderDirectories,
+  files: renderFiles,
+  conflicts: renderConflicts,
+  shares: renderShares,
+};
+
+function currentView() {
+  const view = location.hash.slice(1);
+  return views[view] ? view : 'overview';
+}
+
+async function render() {
+  const view = currentView();
+  for (const section of document.querySelectorAll('[data-view]')) {
+    section.hidden = section.dataset.view !== view;
+  }
+  for (const link of document.querySelectorAll('nav a')) {
+    link.classList.toggle('active', link.getAttribute('href') === '#' + view);
+  }
+  try {
+    await views[view]();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function loadStatus() {
+  const status = await request('GET', '/api/v2/status');
+  state.connected = new Set(status.ConnectedClients || []);
+  return status;
+}
+
+async function renderOverview() {
+  const status = await loadStatus();
+  const card = (label, value) => el('div', { class: 'card' }, el('strong', {}, value), el('span', { class: 'muted' }, label));
+  $('status').replaceChildren(
+    card('Version', status.Version || '-'),
+    card('Uptime', status.Uptime),
+    card('Connected clients', state.connected.size),
+    card('Pending syncs', status.PendingSyncNum),
+    card('Started', formatTime(status.StartTime)),
+  );
+  renderActivity();
+}
+
+// ---------- activity ----------
+
+async function loadActivity() {
+  try {
+    const events = await request('GET', '/api/v1/audit?' + query({ since: '1h' }));
+    state.activity = (events || []).slice(-MAX_ACTIVITY).reverse();
+    renderActivity();
+  } catch (err) {
+    showError(err);
+  }
+  streamActivity();
+}
+
+// streamActivity reads server-sent events of /api/v2/events with fetch, because EventSource can not send API token
+async function streamActivity() {
+  while (state.session) {
+    const controller = new AbortController();
+    state.stream = controller;
+    try {
+      const res = await fetch('/api/v2/events', {
+        headers: { Authorization: 'Bearer ' + state.session.token },
+        signal: controller.signal,
+      });
+      if (res.status === 401) {
+        endSession();
+        return;
+      }
+      if (!res.ok) {
+        throw new Error(res.statusText);
+      }
+
+      const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+      let buffer = '';
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) {
+          break;
+        }
+        buffer += value;
+        let end;
+        while ((end = buffer.indexOf('\n\n')) >= 0) {
+          const data = buffer
+            .slice(0, end)
+            .split('\n')
+            .filter((line) => line.startsWith('data: '))
+            .map((line) => line.slice(6))
+            .join('\n');
+          buffer = buffer.slice(end + 2);
+          if (data) {
+            addActivity(JSON.parse(data));
+          }
+        }
+      }
+    } catch (err) {
+      if (controller.signal.aborted) {
+        return;
+      }
+    }
+    // reconnect after server restart or network failure
+    await sleep(5000);
+  }
+}
+
+function addActivity(event) {
+  if (state.activity.some((known) => known.Seq === event.Seq)) {
+    return;
+  }
+  state.activity.unshift(event);
+  state.activity.length = Math.min(state.activity.length, MAX_ACTIVITY);
+  if (currentView() === 'overview') {
+    renderActivity();
+  }
+}
+
+function renderActivity() {
+  const hideCalls = $('hide-calls').checked;
+  const rows = state.activity
+    .filter((event) => !hideCalls || event.Action !== 'admin.call')
+    .map((event) =>
+      el('tr', {},
+        el('td', {}, formatTime(event.Timestamp)),
+        el('td', {}, event.Action),
+        el('td', {}, event.Actor || '-'),
+        el('td', {}, event.IP || '-'),
+        el('td', {}, event.Path || '-'),
+        el('td', {}, event.Detail || ''),
+      ));
+  $('activity').replaceChildren(...rows);
+}
+
+// ---------- clients ----------
+
+async function renderClients(after) {
+  const page = await request('GET', '/api/v2/clients?' + query({ limit: 100, after }));
+  const rows = page.Items.map((client) => {
+    const presence = client.Presence || {};
+    return el('tr', {},
+      el('td', {}, client.UUID),
+      el('td', { class: presence.Online ? 'online' : 'offline' }, presence.Online ? 'online' : 'offline'),
+      el('td', {}, formatTime(presence.LastSeen)),
+      el('td', {}, presence.Address || client.Ip || '-'),
+      el('td', {}, presence.Version || '-'),
+      el('td', {}, client.OwnerUser || '-'),
+      el('td', {}, (client.Root || []).map((root) => root.AfterPath).join(', ') || '-'),
+      el('td', {}, short(client.CertFingerprint, 16), client.CertRevoked ? ' (revoked)' : ''),
+      el('td', {},
+        client.CertFingerprint && !client.CertRevoked
+          ? button('Revoke', run(async () => {
+            if (confirm('Revoke certificate of ' + client.UUID + '? The client is disconnected.')) {
+              await request('POST', '/api/v2/clients/revoke?' + query({ uuid: client.UUID }));
+            }
+          }))
+          : null),
+    );
+  });
+  appendPage('clients', rows, after, page.Next, renderClients);
+}
+
+// appendPage shows first page or appends next page of table, and shows button of the next page
+function appendPage(name, rows, after, next, load) {
+  if (after) {
+    $(name).append(...rows);
+  } else {
+    $(name).replaceChildren(...rows);
+  }
+  const nextButton = $(name + '-next');
+  nextButton.hidden = !next;
+  nextButton.onclick = () => load(next).catch(showError);
+}
+
+// ---------- directories ----------
+
+async function renderDirectories(after) {
+  const page = await request('GET', '/api/v2/directories?' + query({ limit: 100, after }));
+  const rows = page.Items.map((dir) => {
+    const members = Object.entries(dir.ACL || {}).sort().map(([member, role]) =>
+      el('div', {},
+        member + ' (' + role + ') ',
+        button('Revoke', run(async () => {
+          if (confirm('Revoke ' + member + ' from ' + dir.AfterPath + '?')) {
+            await request('DELETE', '/api/v2/members?' + query({ path: dir.AfterPath, member }));
+          }
+        }))));
+    return el('tr', {},
+      el('td', {}, dir.AfterPath),
+      el('td', {}, dir.OwnerUser ? dir.Owner + ' (' + dir.OwnerUser + ')' : dir.Owner || '-'),
+      el('td', {}, (dir.UUIDs || []).join(', ') || '-'),
+      el('td', {}, members.length ? members : el('span', { class: 'muted' }, dir.Restricted ? 'no members' : 'open to all clients')),
+    );
+  });
+  appendPage('directories', rows, after, page.Next, renderDirectories);
+}
+
+async function invite(form) {
+  await request('POST', '/api/v2/members', {
+    json: { AfterPath: form.path.value.trim(), Member: form.member.value.trim(), Role: form.role.value },
+  });
+  form.reset();
+}
+
+// ---------- files ----------
+
+async function renderFiles() {
+  const select = $('files-root');
+  if (!select.options.length) {
+    const page = await request('GET', '/api/v2/directories?' + query({ limit: 1000 }));
+    select.replaceChildren(
+      el('option', { value: '' }, 'All root directories'),
+      ...page.Items.map((dir) => el('option', { value: dir.AfterPath }, dir.AfterPath)));
+  }
+  await browseFiles();
+}
+
+async function browseFiles(after) {
+  const form = $('files-form');
+  const root = form.root.value;
+  const prefix = form.prefix.value.trim();
+  const page = await request('GET', '/api/v2/files?' + query({
+    limit: 100,
+    after,
+    root,
+    prefix,
+    conflicted: form.conflicted.checked,
+  }));
+
+  renderBreadcrumbs(prefix || (root ? root + '/' : ''));
+  const rows = page.Items.map((file) =>
+    el('tr', {},
+      el('td', {}, pathLinks(file.AfterPath), ' ', button('History', () => showHistory(file.AfterPath).catch(showError))),
+      el('td', {}, file.LatestHash ? file.LatestSyncTimestamp : file.LatestSyncTimestamp + ' (deleted)'),
+      el('td', {}, file.Metadata && !file.Metadata.IsDir ? formatSize(file.Metadata.Size) : '-'),
+      el('td', {}, formatTime(file.Metadata && file.Metadata.ModTime)),
+      el('td', {}, file.LatestEditClient || '-'),
+    ));
+  appendPage('files', rows, after, page.Next, browseFiles);
+}
+
+// pathLinks shows folders of path as links which browse the folder
+function pathLinks(path) {
+  const parts = path.split('/').slice(1);
+  const links = [];
+  parts.forEach((part, i) => {
+    const folder = '/' + parts.slice(0, i + 1).join('/') + '/';
+    links.push('/');
+    links.push(i < parts.length - 1 ? button(part, () => browseFolder(folder)) : el('strong', {}, part));
+  });
+  return links;
+}
+
+function renderBreadcrumbs(prefix) {
+  const crumbs = [button('All', () => browseFolder(''))];
+  if (prefix) {
+    crumbs.push(' ', pathLinks(prefix.replace(/\/$/, '/.')).slice(0, -2));
+  }
+  $('breadcrumbs').replaceChildren(...crumbs.flat());
+}
+
+function browseFolder(folder) {
+  const form = $('files-form');
+  form.prefix.value = folder;
+  $('history').hidden = true;
+  browseFiles().catch(showError);
+}
+
+async function showHistory(path) {
+  const page = await request('GET', '/api/v2/histories?' + query({ prefix: path + '_', limit: 1000 }));
+  const histories = page.Items.filter((history) => history.AfterPath === path).sort((a, b) => b.Timestamp - a.Timestamp);
+  const name = path.split('/').pop();
+  $('history-path').textContent = path;
+  $('histories').replaceChildren(...histories.map((history) =>
+    el('tr', {},
+      el('td', {}, history.Timestamp),
+      el('td', {}, formatTime(history.File && history.File.ModTime)),
+      el('td', {}, history.UUID || '-'),
+      el('td', {}, history.Hash ? formatSize(history.File.Size) : '(deleted)'),
+      el('td', {}, short(history.Hash, 12)),
+      el('td', {}, history.Hash
+        ? button('Download', () => download('/api/v2/files/download?' + query({ path, version: history.Timestamp }), name).catch(showError))
+        : null),
+    )));
+  $('history').hidden = false;
+}
+
+// ---------- conflicts ----------
+
+async function renderConflicts() {
+  const conflicts = await request('GET', '/api/v2/conflicts');
+  if (!conflicts.length) {
+    $('conflicts').replaceChildren(el('p', { class: 'muted' }, 'There is no open conflict.'));
+    return;
+  }
+  $('conflicts').replaceChildren(...conflicts.map((conflict) => {
+    const name = conflict.AfterPath.split('/').pop();
+    const rows = conflict.Candidates.map((candidate) =>
+      el('tr', {},
+        el('td', {}, candidate.Side),
+        el('td', {}, candidate.Timestamp),
+        el('td', {}, candidate.Hash ? formatSize(candidate.Size) : '(deleted)'),
+        el('td', {}, formatTime(candidate.ModTime)),
+        el('td', {}, short(candidate.Hash, 12)),
+        el('td', {}, candidate.Uploaded ? 'uploaded' : 'waiting for upload'),
+        el('td', {},
+          candidate.Uploaded
+            ? button('Download', () => download('/api/v2/conflicts/download?' + query({ path: conflict.AfterPath, side: candidate.Side }), name).catch(showError))
+            : null,
+          ' ',
+          candidate.Uploaded || candidate.Side === 'server'
+            ? button('Keep this', run(async () => {
+              if (confirm('Resolve ' + conflict.AfterPath + ' with ' + candidate.Side + '? Devices receive it with FORCESYNC.')) {
+                await request('POST', '/api/v2/conflicts/resolve', { json: { AfterPath: conflict.AfterPath, Side: candidate.Side } });
+              }
+            }))
+            : null),
+      ));
+    return el('div', { class: 'conflict' },
+      el('h3', {}, conflict.AfterPath),
+      el('table', {},
+        el('thead', {}, el('tr', {}, ['Side', 'Version', 'Size', 'Modified', 'Hash', 'State', ''].map((title) => el('th', {}, title)))),
+        el('tbody', {}, rows)));
+  }));
+}
+
+// ---------- shares ----------
+
+async function renderShares() {
+  const links = await request('GET', '/api/v1/shares');
+  $('shares').replaceChildren(...(links || []).map((link) =>
+    el('tr', {},
+      el('td', {}, short(link.ID, 12)),
+      el('td', {}, link.Owner || '-'),
+      el('td', {}, link.AfterPath),
+      el('td', {}, link.Drop ? 'drop' : link.IsDir ? 'directory' : 'file'),
+      el('td', {}, link.Count + ' / ' + (link.MaxCount || '∞')),
+      el('td', {}, formatTime(link.ExpiresAt)),
+      el('td', {}, formatTime(link.LastUsedAt)),
+      el('td', {}, button('Revoke', run(async () => {
+        if (confirm('Revoke link of ' + link.AfterPath + '?')) {
+          await request('POST', '/api/v1/shares/revoke', { json: { IDs: [link.ID] } });
+        }
+      }))),
+    )));
+}
+
+// ---------- start ----------
+
+document.addEventListener('DOMContentLoaded', () => {
+  $('login-form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    $('login-error').textContent = '';
+    try {
+      await login(event.target);
+      event.target.reset();
+    } catch (err) {
+      $('login-error').textContent = err.message;
+    }
+  });
+  $('logout').addEventListener('click', logout);
+  $('hide-calls').addEventListener('change', renderActivity);
+  $('invite-form').addEventListener('submit', (event) => {
+    event.preventDefault();
+    run(() => invite(event.target))();
+  });
+  $('files-form').addEventListener('submit', (event) => {
+    event.preventDefault();
+    $('history').hidden = true;
+    showError(null);
+    browseFiles().catch(showError);
+  });
+  window.addEventListener('hashchange', () => {
+    showError(null);
+    render();
+  });
+
+  const saved = sessionStorage.getItem(SESSION_KEY);
+  if (saved) {
+    state.session = JSON.parse(saved);
+    startSession();
+  } else {
+    $('login').hidden = false;
+  }
+});
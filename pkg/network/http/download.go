@@ -0,0 +1,126 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// serveFile writes file with Range, If-Range, If-None-Match and If-Modified-Since support.
+// ETag is weak ETag of the hash of key (path of file) and file metadata, which is the same as sync hash of file when key is its path.
+// Content-Type is detected from the file name, or from the content when the extension is unknown.
+func serveFile(w http.ResponseWriter, r *http.Request, key string, fileName string, fileInfo *types.FileMetadata, fileContent io.Reader) {
+	if closer, ok := fileContent.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", fileName))
+	w.Header().Set("ETag", fileETag(key, fileInfo))
+
+	readSeeker, ok := fileContent.(io.ReadSeeker)
+	if ok {
+		http.ServeContent(w, r, fileName, fileInfo.ModTime, readSeeker)
+		return
+	}
+
+	// content which can not be seeked is sent as a whole
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprint(fileInfo.Size))
+	n, err := io.Copy(w, fileContent)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n != fileInfo.Size {
+		http.Error(w, "file is modified", http.StatusInternalServerError)
+	}
+}
+
+// fileETag returns ETag of file, which is the hash of key (path of file) and file metadata.
+// It is weak because contents of the same size and modification time have the same hash,
+// so If-Range is matched with Last-Modified instead.
+func fileETag(key string, fileInfo *types.FileMetadata) string {
+	return "W/\"" + utils.MakeHashFromFileMetadata(key, fileInfo) + "\""
+}
+
+// isNotModified checks the response of conditional request is 304 Not Modified, which has no body.
+// It follows the checks of http.ServeContent: If-None-Match is used, or If-Modified-Since if it is not given.
+func isNotModified(r *http.Request, etag string, modTime time.Time) bool {
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modTime.IsZero() || modTime.Equal(time.Unix(0, 0)) {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// isRangeRequest checks request may continue a download with range (e.g., resumed download or seeking video).
+// Range from the first byte starts a new download, and range with If-Range which does not match is sent as a whole.
+func isRangeRequest(r *http.Request, etag string, modTime time.Time) bool {
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" || strings.HasPrefix(rangeHeader, "bytes=0-") {
+		return false
+	}
+
+	// weak ETag never matches If-Range, as http.ServeContent sends the whole file for it
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	if ifRange == etag && !strings.HasPrefix(etag, "W/") {
+		return true
+	}
+	ifRangeTime, err := http.ParseTime(ifRange)
+	return err == nil && modTime.Truncate(time.Second).Equal(ifRangeTime)
+}
+
+// contentDisposition returns Content-Disposition header with file name, name which has non-ASCII
+// or special characters is also sent as RFC 5987 filename* and the fallback is used by old clients
+func contentDisposition(dispositionType string, fileName string) string {
+	fallback := strings.Builder{}
+	encoded := strings.Builder{}
+	for _, b := range []byte(fileName) {
+		// multi-byte character is replaced with one "_" in fallback
+		if b < 0x20 || b >= 0x7f || b == '"' || b == '\\' {
+			if b < 0x80 || b >= 0xc0 {
+				fallback.WriteByte('_')
+			}
+		} else {
+			fallback.WriteByte(b)
+		}
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	header := dispositionType + "; filename=\"" + fallback.String() + "\""
+	if fallback.String() != fileName {
+		header += "; filename*=UTF-8''" + encoded.String()
+	}
+	return header
+}
+
+// isAttrChar checks the byte can be written in RFC 5987 ext-value without percent encoding
+func isAttrChar(b byte) bool {
+	if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
@@ -0,0 +1,231 @@
+package http
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// CreateDropLink creates upload-only link into the folder of root directory
+func (sh *SharingHandler) CreateDropLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := sh.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.DropReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		shareRes, err := sh.sharingService.CreateDropLink(ctx, requester, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(shareRes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// Drop shows upload form of drop link in query (e.g., ?id=<link ID>) with GET,
+// and uploads files with POST of multipart form or with PUT of file content (e.g., ?id=<link ID>&name=<file name>).
+// Upload password of link is sent as password of basic auth, user name is ignored.
+func (sh *SharingHandler) Drop(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+
+	id := r.URL.Query().Get("id")
+	_, password, _ := r.BasicAuth()
+	ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+	ctx = sharing.WithUserAgent(ctx, r.UserAgent())
+
+	switch r.Method {
+	case "GET":
+		dropInfo, err := sh.sharingService.GetDropInfo(ctx, id, password)
+		if err != nil {
+			writeShareError(w, err)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			writeJSON(w, dropInfo)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = dropTemplate.Execute(w, map[string]any{"Info": dropInfo})
+		if err != nil {
+			log.Println("quics err: [SharingHandler.Drop] write upload form: ", err)
+		}
+	case "POST", "PUT":
+		dropInfo, err := sh.sharingService.GetDropInfo(ctx, id, password)
+		if err != nil {
+			writeShareError(w, err)
+			return
+		}
+
+		uploadRes := &types.DropUploadRes{Files: []string{}}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "multipart/form-data" {
+			reader, err := r.MultipartReader()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if part.FileName() == "" {
+					continue
+				}
+
+				name, err := sh.uploadDropFile(r, id, password, part.FileName(), dropInfo.MaxSize, part)
+				if err != nil {
+					writeShareError(w, err)
+					return
+				}
+				uploadRes.Files = append(uploadRes.Files, name)
+			}
+
+			if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+				dropInfo, err = sh.sharingService.GetDropInfo(ctx, id, password)
+				if err != nil {
+					// link is used up by this upload
+					dropInfo = nil
+				}
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				err = dropTemplate.Execute(w, map[string]any{"Info": dropInfo, "Uploaded": uploadRes.Files})
+				if err != nil {
+					log.Println("quics err: [SharingHandler.Drop] write upload form: ", err)
+				}
+				return
+			}
+		} else {
+			name, err := sh.uploadDropFile(r, id, password, r.URL.Query().Get("name"), dropInfo.MaxSize, r.Body)
+			if err != nil {
+				writeShareError(w, err)
+				return
+			}
+			uploadRes.Files = append(uploadRes.Files, name)
+		}
+
+		writeJSON(w, uploadRes)
+	}
+}
+
+// uploadDropFile writes uploaded file to temporary file to know its size and commits it.
+// Only max size + 1 bytes are read, so that too large file is not written to disk.
+func (sh *SharingHandler) uploadDropFile(r *http.Request, id string, password string, fileName string, maxSize uint64, fileContent io.Reader) (string, error) {
+	if maxSize > 0 {
+		fileContent = io.LimitReader(fileContent, int64(maxSize)+1)
+	}
+
+	tempFile, err := os.CreateTemp(utils.GetQuicsDirPath(), "drop-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	size, err := io.Copy(tempFile, fileContent)
+	if err != nil {
+		return "", err
+	}
+	if maxSize > 0 && uint64(size) > maxSize {
+		return "", sharing.ErrFileTooLarge
+	}
+	_, err = tempFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+	return sh.sharingService.UploadDropFile(ctx, id, password, fileName, size, tempFile)
+}
+
+// writeJSON writes data as JSON response
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	n, err := w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n != len(response) {
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+var dropTemplate = template.Must(template.New("drop").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Upload files - quics</title>
+</head>
+<body>
+{{if .Uploaded}}<p>Uploaded: {{range $i, $name := .Uploaded}}{{if $i}}, {{end}}{{$name}}{{end}}</p>{{end}}
+{{with .Info}}
+<h1>Upload files to {{.Name}}</h1>
+{{if .Note}}<p>{{.Note}}</p>{{end}}
+<p>{{.RemainingCnt}} file(s) can be uploaded{{if .MaxSize}}, up to {{.MaxSize}} bytes each{{end}}.</p>
+{{if not .ExpiresAt.IsZero}}<p>Expires at {{.ExpiresAt.Format "2006-01-02 15:04:05 MST"}}</p>{{end}}
+<form method="post" action="?id={{.ID}}" enctype="multipart/form-data">
+<input type="file" name="file" multiple>
+<input type="submit" value="Upload">
+</form>
+{{else}}
+<p>No more files can be uploaded with this link.</p>
+{{end}}
+</body>
+</html>
+`))
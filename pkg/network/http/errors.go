@@ -0,0 +1,40 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const apiV2Prefix = "/api/v2/"
+
+// writeError writes error as JSON error object for /api/v2, and as plain text for /api/v1 as before
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if !strings.HasPrefix(r.URL.Path, apiV2Prefix) {
+		http.Error(w, message, status)
+		return
+	}
+
+	code := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	if code == "" {
+		code = "error"
+	}
+	response, _ := json.Marshal(&types.ErrorRes{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+// writeMethodNotAllowed writes 405 with methods which the endpoint allows
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, r, http.StatusMethodNotAllowed, "method "+r.Method+" is not allowed")
+}
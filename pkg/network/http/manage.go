@@ -0,0 +1,70 @@
+package http
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// ListLinks shows sharing links with their download statistics (e.g., ?owner=<owner>&path=<path>).
+// Admin can see all links, and other users can only see their own links.
+func (sh *SharingHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		requester, ok := sh.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		request := &types.ListShareReq{
+			Owner:     r.URL.Query().Get("owner"),
+			AfterPath: r.URL.Query().Get("path"),
+		}
+		listShareRes, err := sh.sharingService.ListLinks(r.Context(), requester, request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, listShareRes.Links)
+	}
+}
+
+// RevokeLinks revokes sharing links matching all conditions of request body (IDs, owner, path and root directory)
+func (sh *SharingHandler) RevokeLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := sh.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.RevokeShareReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		revokeShareRes, err := sh.sharingService.RevokeLinks(ctx, requester, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, revokeShareRes)
+	}
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
@@ -16,11 +15,13 @@ import (
 
 type ServerHandler struct {
 	ServerService server.Service
+	auth          *Authenticator
 }
 
-func NewServerHandler(serverService server.Service) *ServerHandler {
+func NewServerHandler(serverService server.Service, auth *Authenticator) *ServerHandler {
 	return &ServerHandler{
 		ServerService: serverService,
+		auth:          auth,
 	}
 }
 
@@ -37,12 +38,26 @@ func (sh *ServerHandler) SetupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/server/remove/directories", sh.RemoveDir)
 	mux.HandleFunc("/api/v1/server/remove/files", sh.RemoveFile)
 	mux.HandleFunc("/api/v1/server/download/files", sh.DownloadFile)
+	mux.HandleFunc("/api/v1/server/status", sh.ShowStatus)
+	mux.HandleFunc("/api/v1/server/members/invite", sh.InviteMember)
+	mux.HandleFunc("/api/v1/server/members/revoke", sh.RevokeMember)
+	mux.HandleFunc("/api/v1/server/clients/revoke", sh.RevokeClient)
+	mux.HandleFunc("/api/v1/server/lockouts", sh.ShowLockouts)
+	mux.HandleFunc("/api/v1/server/lockouts/clear", sh.ClearLockout)
+	mux.HandleFunc("/api/v1/server/cert", sh.ShowCertificate)
+	mux.HandleFunc("/api/v1/server/cert/renew", sh.RenewCertificate)
+	mux.HandleFunc("/healthz", sh.Healthz)
+	mux.HandleFunc("/readyz", sh.Readyz)
 }
 
 func (sh *ServerHandler) StopRestServer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		err := sh.ServerService.StopServer()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -55,6 +70,10 @@ func (sh *ServerHandler) ListenProtocol(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		err := sh.ServerService.ListenProtocol()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -67,26 +86,25 @@ func (sh *ServerHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		body := &types.Server{}
 
-		buf := make([]byte, r.ContentLength)
-		n, err := r.Body.Read(buf)
-		if err != nil && err != io.EOF {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if int64(n) != r.ContentLength {
-			http.Error(w, "failed to read request body", http.StatusInternalServerError)
-			return
-		}
 
 		err = utils.UnmarshalRequestBody(buf, body)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		err = sh.ServerService.SetPassword(body)
+		err = sh.ServerService.SetPassword(r.Context(), body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -98,7 +116,11 @@ func (sh *ServerHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
-		err := sh.ServerService.ResetPassword()
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		err := sh.ServerService.ResetPassword(r.Context())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -110,9 +132,13 @@ func (sh *ServerHandler) ShowClientLogs(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
 		uuid := r.URL.Query().Get("uuid")
 
-		clients, err := sh.ServerService.ShowClient(uuid)
+		clients, err := sh.ServerService.ShowClient(r.Context(), uuid)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -141,9 +167,13 @@ func (sh *ServerHandler) ShowDirLogs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		dirs, err := sh.ServerService.ShowDir(afterPath)
+		dirs, err := sh.ServerService.ShowDir(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -172,9 +202,13 @@ func (sh *ServerHandler) ShowFileLogs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		files, err := sh.ServerService.ShowFile(afterPath)
+		files, err := sh.ServerService.ShowFile(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -203,9 +237,13 @@ func (sh *ServerHandler) ShowHistoryLogs(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		histories, err := sh.ServerService.ShowHistory(afterPath)
+		histories, err := sh.ServerService.ShowHistory(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -234,9 +272,13 @@ func (sh *ServerHandler) RemoveClient(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		err := sh.ServerService.RemoveClient(afterPath)
+		err := sh.ServerService.RemoveClient(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -248,9 +290,13 @@ func (sh *ServerHandler) RemoveDir(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		err := sh.ServerService.RemoveDir(afterPath)
+		err := sh.ServerService.RemoveDir(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -262,9 +308,13 @@ func (sh *ServerHandler) RemoveFile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 
-		err := sh.ServerService.RemoveFile(afterPath)
+		err := sh.ServerService.RemoveFile(r.Context(), afterPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -276,6 +326,10 @@ func (sh *ServerHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
 		afterPath := r.URL.Query().Get("afterpath")
 		timestamp, err := strconv.Atoi(r.URL.Query().Get("timestamp"))
 		if err != nil {
@@ -283,24 +337,280 @@ func (sh *ServerHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		fileInfo, fileContent, err := sh.ServerService.DownloadFile(afterPath, uint64(timestamp))
+		fileInfo, fileContent, err := sh.ServerService.DownloadFile(r.Context(), afterPath, uint64(timestamp))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		_, fileName := filepath.Split(afterPath)
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
-		w.Header().Set("Content-Length", fmt.Sprint(fileInfo.Size))
+		serveFile(w, r, afterPath, fileName, fileInfo, fileContent)
+	}
+}
 
-		n, err := io.Copy(w, fileContent)
+func (sh *ServerHandler) ShowStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		status, err := sh.ServerService.GetStatus(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(status)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if n != fileInfo.Size {
-			http.Error(w, "file is modified", http.StatusInternalServerError)
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RevokeClient revokes certificate of client in query (e.g., ?uuid=<UUID>)
+func (sh *ServerHandler) RevokeClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		uuid := r.URL.Query().Get("uuid")
+		if uuid == "" {
+			http.Error(w, "uuid is required", http.StatusBadRequest)
+			return
+		}
+
+		err := sh.ServerService.RevokeClient(r.Context(), uuid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (sh *ServerHandler) ShowLockouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		lockouts, err := sh.ServerService.GetAllLockouts(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(lockouts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ClearLockout clears lockout of key in query (e.g., ?key=ip:127.0.0.1), or all lockouts with ?all=true
+func (sh *ServerHandler) ClearLockout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		key := r.URL.Query().Get("key")
+		if key == "" && r.URL.Query().Get("all") != "true" {
+			http.Error(w, "key or all is required", http.StatusBadRequest)
+			return
+		}
+
+		err := sh.ServerService.ClearLockout(r.Context(), key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (sh *ServerHandler) ShowCertificate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !sh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		certificateInfo, err := sh.ServerService.GetCertificateInfo(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(certificateInfo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (sh *ServerHandler) RenewCertificate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !sh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		certificateInfo, err := sh.ServerService.RenewCertificate(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(certificateInfo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (sh *ServerHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := sh.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.RootMemberReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_, err = sh.ServerService.InviteMember(r.Context(), requester, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (sh *ServerHandler) RevokeMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := sh.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.RootMemberReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_, err = sh.ServerService.RevokeMember(r.Context(), requester, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// Healthz is liveness probe, it always returns ok while the process is serving http
+func (sh *ServerHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}
+}
+
+// Readyz is readiness probe, it returns 503 when any readiness check fails
+func (sh *ServerHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		readiness := sh.ServerService.CheckReadiness(r.Context())
+
+		response, err := json.Marshal(readiness)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !readiness.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
 		}
+		w.Write(response)
 	}
 }
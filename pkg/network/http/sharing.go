@@ -1,56 +1,191 @@
 package http
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
 	"io"
 	"log"
 	"net/http"
-	"path/filepath"
+	"strings"
 
 	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/sharing"
 )
 
 type SharingHandler struct {
 	sharingService sharing.Service
+	auth           *Authenticator
 }
 
-func NewSharingHandler(sharingService sharing.Service) *SharingHandler {
+func NewSharingHandler(sharingService sharing.Service, auth *Authenticator) *SharingHandler {
 	return &SharingHandler{
 		sharingService: sharingService,
+		auth:           auth,
 	}
 }
 
 func (sh *SharingHandler) SetupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/download/files", sh.DownloadFile)
+	mux.HandleFunc("/api/v1/drop", sh.Drop)
+	mux.HandleFunc("/api/v1/shares", sh.ListLinks)
+	mux.HandleFunc("/api/v1/shares/revoke", sh.RevokeLinks)
+	mux.HandleFunc("/api/v1/shares/drop", sh.CreateDropLink)
 }
 
+// DownloadFile downloads shared file of link in query (e.g., ?id=<link ID>).
+// For directory link, file in the directory is downloaded with ?id=<link ID>&file=<path in directory>,
+// the directory is downloaded as archive with &format=zip or &format=tar.gz,
+// and index of the directory is shown as HTML (or JSON with &format=json) when file is not given.
+// Download password of link is sent as password of basic auth, user name is ignored.
 func (sh *SharingHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
 	switch r.Method {
 	case "GET":
-		uuid := r.URL.Query().Get("uuid")
-		afterPath := r.URL.Query().Get("file")
+		id := r.URL.Query().Get("id")
+		format := r.URL.Query().Get("format")
+		_, password, _ := r.BasicAuth()
 
-		fileInfo, fileContent, err := sh.sharingService.DownloadFile(uuid, afterPath)
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		ctx = sharing.WithUserAgent(ctx, r.UserAgent())
+		switch format {
+		case sharing.ArchiveZip, sharing.ArchiveTarGz:
+			sh.downloadDir(ctx, w, id, password, format)
+			return
+		case "html", "json":
+			sh.showDir(ctx, w, id, password, format)
+			return
+		}
+
+		filePath := r.URL.Query().Get("file")
+		fileInfo, fileContent, err := sh.sharingService.DownloadFile(ctx, id, password, filePath, r.Header.Get("Range") != "")
+		if errors.Is(err, sharing.ErrIsDirectory) {
+			format = "html"
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				format = "json"
+			}
+			sh.showDir(ctx, w, id, password, format)
+			return
+		}
 		if err != nil {
-			log.Println("quics err: [SharingHandler.DownloadFile] download file: ", err)
-			http.Error(w, "can not download file (no such file or link may already be expired)", http.StatusInternalServerError)
+			writeShareError(w, err)
 			return
 		}
 
-		_, fileName := filepath.Split(afterPath)
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
-		w.Header().Set("Content-Length", fmt.Sprint(fileInfo.Size))
+		// every response which sends the file is counted, except 304 and range request which continues counted download
+		key := id + "/" + filePath
+		etag := fileETag(key, fileInfo)
+		if !isNotModified(r, etag, fileInfo.ModTime) {
+			_, seekable := fileContent.(io.ReadSeeker)
+			resume := seekable && isRangeRequest(r, etag, fileInfo.ModTime)
+			err = sh.sharingService.CountDownload(ctx, id, filePath, resume)
+			if err != nil {
+				if closer, ok := fileContent.(io.Closer); ok {
+					closer.Close()
+				}
+				writeShareError(w, err)
+				return
+			}
+		}
+
+		serveFile(w, r, key, fileInfo.Name, fileInfo, fileContent)
+	}
+}
+
+// downloadDir streams shared directory as archive, the archive is built while it is sent
+func (sh *SharingHandler) downloadDir(ctx context.Context, w http.ResponseWriter, id string, password string, format string) {
+	sharedDir, err := sh.sharingService.DownloadDir(ctx, id, password)
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
 
-		n, err := io.Copy(w, fileContent)
+	contentType := "application/zip"
+	if format == sharing.ArchiveTarGz {
+		contentType = "application/gzip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", sharedDir.Name+"."+format))
+
+	// header is already sent, so error can only be logged and the archive is truncated
+	err = sh.sharingService.WriteArchive(ctx, sharedDir, format, w)
+	if err != nil {
+		log.Println("quics err: [SharingHandler.DownloadFile] write archive: ", err)
+	}
+}
+
+// showDir shows index of shared directory as HTML or JSON
+func (sh *SharingHandler) showDir(ctx context.Context, w http.ResponseWriter, id string, password string, format string) {
+	sharedDir, err := sh.sharingService.GetSharedDir(ctx, id, password)
+	if err != nil {
+		writeShareError(w, err)
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(sharedDir)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if n != fileInfo.Size {
-			http.Error(w, "file is modified", http.StatusInternalServerError)
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = sharedDirTemplate.Execute(w, sharedDir)
+	if err != nil {
+		log.Println("quics err: [SharingHandler.DownloadFile] write index: ", err)
 	}
 }
+
+// writeShareError writes response of error from sharing service
+func writeShareError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sharing.ErrLinkNotFound):
+		http.Error(w, "no such link or link may already be expired", http.StatusNotFound)
+	case errors.Is(err, sharing.ErrVersionNotFound):
+		http.Error(w, "can not download file (shared version of file no longer exists)", http.StatusGone)
+	case errors.Is(err, sharing.ErrIsNotDirectory), errors.Is(err, sharing.ErrInvalidFileName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sharing.ErrFileTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, sharing.ErrPasswordRequired), errors.Is(err, sharing.ErrPasswordIncorrect):
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"quics share\"")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		log.Println("quics err: [SharingHandler] ", err)
+		http.Error(w, "can not process shared link", http.StatusInternalServerError)
+	}
+}
+
+var sharedDirTemplate = template.Must(template.New("sharedDir").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - quics</title>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{if .Note}}<p>{{.Note}}</p>{{end}}
+{{if not .ExpiresAt.IsZero}}<p>Expires at {{.ExpiresAt.Format "2006-01-02 15:04:05 MST"}}</p>{{end}}
+<p>Download all: <a href="?id={{.ID}}&format=zip">zip</a> | <a href="?id={{.ID}}&format=tar.gz">tar.gz</a></p>
+<table>
+<tr><th>File</th><th>Size</th><th>Modified</th></tr>
+{{range .Files}}<tr><td><a href="?id={{$.ID}}&file={{.Path}}">{{.Path}}</a></td><td>{{.Size}}</td><td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
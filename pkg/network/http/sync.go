@@ -1,20 +1,332 @@
 package http
 
 import (
-	"github.com/gorilla/mux"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
 )
 
 type SyncHandler struct {
 	SyncService sync.Service
+	auth        *Authenticator
 }
 
-func NewSyncHandler(syncService sync.Service) *SyncHandler {
+func NewSyncHandler(syncService sync.Service, auth *Authenticator) *SyncHandler {
 	return &SyncHandler{
 		SyncService: syncService,
+		auth:        auth,
+	}
+}
+
+func (syncHandler *SyncHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/files", syncHandler.UploadFile)
+	mux.HandleFunc("/api/v1/conflicts", syncHandler.ListConflicts)
+	mux.HandleFunc("/api/v1/conflicts/show", syncHandler.ShowConflict)
+	mux.HandleFunc("/api/v1/conflicts/download", syncHandler.DownloadCandidate)
+	mux.HandleFunc("/api/v1/conflicts/resolve", syncHandler.ResolveConflict)
+}
+
+// UploadFile uploads file content with PUT (e.g., ?path=/root/dir/file.txt&version=3),
+// or files of multipart form with POST into the folder in path (e.g., ?path=/root/dir&version=a.txt:3&version=b.txt:0).
+// version is optional timestamp which the file must be at (0 if the file must not exist), otherwise 412 is returned.
+// For multipart form, version is given for each file as <file name>:<timestamp>, and no file is committed if one of them fails.
+// Each file is committed as new version through sync service and sent to clients of root directory with MUSTSYNC.
+func (syncHandler *SyncHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "PUT", "POST":
+		requester, ok := syncHandler.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		serveUpload(w, r, syncHandler.SyncService, requester)
+	default:
+		writeMethodNotAllowed(w, r, "PUT", "POST")
 	}
 }
 
-func (syncHandler *SyncHandler) SetupRoutes(r *mux.Router) {
+// serveUpload commits file of PUT (or POST which is not multipart form) or files of multipart form of UploadFile
+func serveUpload(w http.ResponseWriter, r *http.Request, syncService sync.Service, requester string) {
+	afterPath := r.URL.Query().Get("path")
+	ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method == "PUT" || mediaType != "multipart/form-data" {
+		var expectedVersion *uint64
+		if value := r.URL.Query().Get("version"); value != "" {
+			version, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "version must be timestamp of file")
+				return
+			}
+			expectedVersion = &version
+		}
+		request := &types.FileUploadReq{
+			AfterPath:       afterPath,
+			ExpectedVersion: expectedVersion,
+		}
+
+		// permission is checked before contents are received
+		err := syncService.CheckUpload(ctx, requester, request)
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+		upload, err := spoolUpload(request, r.Body)
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+		defer closeUploads([]sync.Upload{*upload})
+
+		uploadRes, err := syncService.UploadFile(ctx, requester, upload.Request, upload.FileMetadata, upload.FileContent)
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+		w.Header().Set("ETag", "\""+uploadRes.Hash+"\"")
+		writeJSON(w, uploadRes)
+		return
+	}
+
+	versions := map[string]uint64{}
+	for _, value := range r.URL.Query()["version"] {
+		index := strings.LastIndex(value, ":")
+		version, err := strconv.ParseUint(value[index+1:], 10, 64)
+		if index < 0 || err != nil {
+			writeError(w, r, http.StatusBadRequest, "version of multipart form must be <file name>:<timestamp>")
+			return
+		}
+		versions[value[:index]] = version
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	// all files are received before any of them is committed
+	uploads := []sync.Upload{}
+	defer func() {
+		closeUploads(uploads)
+	}()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		// uploader can not choose other folder with file name
+		fileName := path.Base(strings.ReplaceAll(part.FileName(), "\\", "/"))
+		request := &types.FileUploadReq{
+			AfterPath: strings.TrimSuffix(afterPath, "/") + "/" + fileName,
+		}
+		if version, ok := versions[fileName]; ok {
+			request.ExpectedVersion = &version
+		}
+
+		err = syncService.CheckUpload(ctx, requester, request)
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+		upload, err := spoolUpload(request, part)
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+		uploads = append(uploads, *upload)
+	}
+
+	uploadResList, err := syncService.UploadFiles(ctx, requester, uploads)
+	if err != nil {
+		writeUploadError(w, r, err)
+		return
+	}
+
+	writeJSON(w, uploadResList)
+}
+
+// spoolUpload writes uploaded file to temporary file to know its size, it is removed by closeUploads
+func spoolUpload(request *types.FileUploadReq, fileContent io.Reader) (*sync.Upload, error) {
+	tempFile, err := os.CreateTemp(utils.GetQuicsDirPath(), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := io.Copy(tempFile, fileContent)
+	if err == nil {
+		_, err = tempFile.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return nil, err
+	}
+
+	return &sync.Upload{
+		Request: request,
+		FileMetadata: &types.FileMetadata{
+			Name:    path.Base(request.AfterPath),
+			Size:    size,
+			Mode:    os.FileMode(0644),
+			ModTime: time.Now(),
+			IsDir:   false,
+		},
+		FileContent: tempFile,
+	}, nil
+}
 
+// closeUploads closes and removes temporary files of spoolUpload
+func closeUploads(uploads []sync.Upload) {
+	for _, upload := range uploads {
+		if tempFile, ok := upload.FileContent.(*os.File); ok {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}
+}
+
+// ListConflicts returns open conflicts of all root directories, or of root directory in query (e.g., ?root=/rootDir)
+func (syncHandler *SyncHandler) ListConflicts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !syncHandler.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		conflicts, err := syncHandler.SyncService.GetAllConflicts(r.Context(), r.URL.Query().Get("root"))
+		if err != nil {
+			writeConflictError(w, err)
+			return
+		}
+
+		writeJSON(w, conflicts)
+	}
+}
+
+// ShowConflict returns conflicted file in query with its candidates (e.g., ?path=/rootDir/file.txt)
+func (syncHandler *SyncHandler) ShowConflict(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !syncHandler.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		conflict, err := syncHandler.SyncService.GetConflictInfo(r.Context(), r.URL.Query().Get("path"))
+		if err != nil {
+			writeConflictError(w, err)
+			return
+		}
+
+		writeJSON(w, conflict)
+	}
+}
+
+// DownloadCandidate writes contents of candidate of conflicted file (e.g., ?path=/rootDir/file.txt&side=<server|UUID>)
+func (syncHandler *SyncHandler) DownloadCandidate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !syncHandler.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		afterPath := r.URL.Query().Get("path")
+		side := r.URL.Query().Get("side")
+		fileInfo, fileContent, err := syncHandler.SyncService.OpenConflictCandidate(r.Context(), afterPath, side)
+		if err != nil {
+			writeConflictError(w, err)
+			return
+		}
+
+		serveFile(w, r, afterPath+"_"+side, path.Base(afterPath), fileInfo, fileContent)
+	}
+}
+
+// ResolveConflict resolves conflicted file with candidate in request body, clients of root directory receive it with FORCESYNC
+func (syncHandler *SyncHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := syncHandler.auth.AuthorizeToken(w, r, types.TokenScopeAdmin)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.ConflictResolveReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		err = syncHandler.SyncService.ResolveConflict(ctx, requester, body)
+		if err != nil {
+			writeConflictError(w, err)
+			return
+		}
+	}
+}
+
+func writeConflictError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sync.ErrRootDirNotFound), errors.Is(err, sync.ErrConflictNotFound), errors.Is(err, sync.ErrCandidateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, sync.ErrPermissionDenied):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, sync.ErrCandidateNotUploaded):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Println("quics err: [SyncHandler] ", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, sync.ErrInvalidPath):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, sync.ErrRootDirNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, sync.ErrPermissionDenied):
+		writeError(w, r, http.StatusForbidden, err.Error())
+	case errors.Is(err, sync.ErrFileConflicted), errors.Is(err, sync.ErrContentsPending):
+		writeError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, sync.ErrVersionMismatch):
+		writeError(w, r, http.StatusPreconditionFailed, err.Error())
+	default:
+		log.Println("quics err: [SyncHandler] ", err)
+		writeError(w, r, http.StatusInternalServerError, "can not upload file")
+	}
 }
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/token"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+type TokenHandler struct {
+	tokenService token.Service
+	auth         *Authenticator
+}
+
+func NewTokenHandler(tokenService token.Service, auth *Authenticator) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+		auth:         auth,
+	}
+}
+
+func (th *TokenHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/tokens", th.ListTokens)
+	mux.HandleFunc("/api/v1/tokens/create", th.CreateToken)
+	mux.HandleFunc("/api/v1/tokens/revoke", th.RevokeToken)
+}
+
+func (th *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !th.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		apiTokens, err := th.tokenService.GetAllTokens(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(apiTokens)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// CreateToken creates API token, it also accepts basic auth of admin user or server password to create the first token
+func (th *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := th.auth.AuthorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.APITokenReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		apiToken, err := th.tokenService.CreateToken(r.Context(), requester, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(apiToken)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RevokeToken revokes API token in query (e.g., ?id=<token ID>)
+func (th *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !th.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		err := th.tokenService.RevokeToken(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/server"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+type UserHandler struct {
+	serverService server.Service
+	userService   user.Service
+	auth          *Authenticator
+}
+
+func NewUserHandler(serverService server.Service, userService user.Service, auth *Authenticator) *UserHandler {
+	return &UserHandler{
+		serverService: serverService,
+		userService:   userService,
+		auth:          auth,
+	}
+}
+
+func (uh *UserHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/users", uh.ListUsers)
+	mux.HandleFunc("/api/v1/users/add", uh.AddUser)
+	mux.HandleFunc("/api/v1/users/disable", uh.DisableUser)
+	mux.HandleFunc("/api/v1/users/keys", uh.ListAccessKeys)
+	mux.HandleFunc("/api/v1/users/keys/create", uh.CreateAccessKey)
+	mux.HandleFunc("/api/v1/users/keys/revoke", uh.RevokeAccessKey)
+}
+
+func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !uh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		users, err := uh.userService.GetAllUsers(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		response, err := json.Marshal(users)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, err := w.Write(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if n != len(response) {
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (uh *UserHandler) AddUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !uh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := &types.UserReq{}
+		err = utils.UnmarshalRequestBody(buf, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = uh.userService.AddUser(r.Context(), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (uh *UserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !uh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+
+		err := uh.userService.DisableUser(r.Context(), name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// devices of the user keep syncing on live connections until they are closed
+		err = uh.serverService.DisconnectUser(r.Context(), name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// ListAccessKeys shows S3 access keys of all users, secret keys are not shown
+func (uh *UserHandler) ListAccessKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !uh.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		accessKeys, err := uh.userService.GetAllAccessKeys(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, accessKeys)
+	}
+}
+
+// CreateAccessKey creates S3 access key of user (e.g., ?name=<user name>)
+func (uh *UserHandler) CreateAccessKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !uh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+
+		accessKeyRes, err := uh.userService.CreateAccessKey(r.Context(), name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, accessKeyRes)
+	}
+}
+
+// RevokeAccessKey revokes S3 access key (e.g., ?id=<access key ID>)
+func (uh *UserHandler) RevokeAccessKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !uh.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+
+		err := uh.userService.RevokeAccessKey(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,890 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/server"
+	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/core/token"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	// maxRequestBodySize limits JSON request body of /api/v2
+	maxRequestBodySize = 1 << 20
+
+	// defaultPageLimit is used when limit is not given, and limit can not be larger than maxPageLimit
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+
+	// eventHeartbeatInterval is interval of comments sent to idle event stream
+	eventHeartbeatInterval = 30 * time.Second
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// APIHandler serves /api/v2, which has the same features as /api/v1 of server handler with
+// resource paths, JSON error objects (types.ErrorRes) and status codes of each failure
+type APIHandler struct {
+	ServerService  server.Service
+	SyncService    sync.Service
+	SharingService sharing.Service
+	UserService    user.Service
+	TokenService   token.Service
+	AuditService   audit.Service
+	auth           *Authenticator
+}
+
+func NewAPIHandler(serverService server.Service, userService user.Service, tokenService token.Service, auditService audit.Service, auth *Authenticator) *APIHandler {
+	return &APIHandler{
+		ServerService:  serverService,
+		SyncService:    serverService.GetSyncService(),
+		SharingService: serverService.GetSharingService(),
+		UserService:    userService,
+		TokenService:   tokenService,
+		AuditService:   auditService,
+		auth:           auth,
+	}
+}
+
+func (ah *APIHandler) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(apiV2Prefix, ah.NotFound)
+	mux.HandleFunc("/api/v2/openapi.json", ah.OpenAPI)
+	mux.HandleFunc("/api/v2/status", ah.Status)
+	mux.HandleFunc("/api/v2/events", ah.Events)
+	mux.HandleFunc("/api/v2/server/stop", ah.StopServer)
+	mux.HandleFunc("/api/v2/server/listen", ah.ListenProtocol)
+	mux.HandleFunc("/api/v2/server/password", ah.Password)
+	mux.HandleFunc("/api/v2/clients", ah.Clients)
+	mux.HandleFunc("/api/v2/clients/revoke", ah.RevokeClient)
+	mux.HandleFunc("/api/v2/directories", ah.Directories)
+	mux.HandleFunc("/api/v2/files", ah.Files)
+	mux.HandleFunc("/api/v2/files/download", ah.DownloadFile)
+	mux.HandleFunc("/api/v2/files/upload", ah.UploadFile)
+	mux.HandleFunc("/api/v2/histories", ah.Histories)
+	mux.HandleFunc("/api/v2/members", ah.Members)
+	mux.HandleFunc("/api/v2/lockouts", ah.Lockouts)
+	mux.HandleFunc("/api/v2/cert", ah.Certificate)
+	mux.HandleFunc("/api/v2/cert/renew", ah.RenewCertificate)
+	mux.HandleFunc("/api/v2/conflicts", ah.Conflicts)
+	mux.HandleFunc("/api/v2/conflicts/show", ah.ShowConflict)
+	mux.HandleFunc("/api/v2/conflicts/download", ah.DownloadCandidate)
+	mux.HandleFunc("/api/v2/conflicts/resolve", ah.ResolveConflict)
+	mux.HandleFunc("/api/v2/shares", ah.Shares)
+	mux.HandleFunc("/api/v2/shares/revoke", ah.RevokeShares)
+	mux.HandleFunc("/api/v2/shares/drop", ah.DropLinks)
+	mux.HandleFunc("/api/v2/tokens", ah.Tokens)
+	mux.HandleFunc("/api/v2/users", ah.Users)
+	mux.HandleFunc("/api/v2/users/disable", ah.DisableUser)
+	mux.HandleFunc("/api/v2/keys", ah.AccessKeys)
+	mux.HandleFunc("/api/v2/audit", ah.Audit)
+	mux.HandleFunc("/api/v2/audit/verify", ah.VerifyAudit)
+}
+
+// NotFound writes 404 for paths under /api/v2 which do not exist
+func (ah *APIHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "path "+r.URL.Path+" does not exist")
+}
+
+// OpenAPI writes OpenAPI document of /api/v2, it does not require authorization
+func (ah *APIHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET", "HEAD":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "HEAD")
+	}
+}
+
+func (ah *APIHandler) Status(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		status, err := ah.ServerService.GetStatus(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, status)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// Events streams audit events recorded after the request as server-sent events, until the client disconnects.
+// Only the request is authorized and audited, so that watching activity does not add events by itself.
+func (ah *APIHandler) Events(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, r, http.StatusInternalServerError, "streaming is not supported")
+			return
+		}
+
+		events := ah.AuditService.Subscribe(r.Context())
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(eventHeartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case auditEvent, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(&auditEvent)
+				if err != nil {
+					log.Println("quics err: [APIHandler.Events] ", err)
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: audit\ndata: %s\n\n", auditEvent.Seq, data)
+			case <-heartbeat.C:
+				// comment keeps idle connection open through proxies
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			flusher.Flush()
+		}
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+func (ah *APIHandler) StopServer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		err := ah.ServerService.StopServer()
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+func (ah *APIHandler) ListenProtocol(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		err := ah.ServerService.ListenProtocol()
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// Password sets server password with PUT (body is types.PasswordReq), and resets it to default with DELETE
+func (ah *APIHandler) Password(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "PUT":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		body := &types.PasswordReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if body.Password == "" {
+			writeError(w, r, http.StatusBadRequest, "Password is required")
+			return
+		}
+
+		err := ah.ServerService.SetPassword(r.Context(), &types.Server{Password: body.Password})
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		err := ah.ServerService.ResetPassword(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "PUT", "DELETE")
+	}
+}
+
+// Clients shows page of clients (or client of ?uuid=<UUID>) with GET, and removes them (?uuid=<UUID> or ?all=true) with DELETE
+func (ah *APIHandler) Clients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	uuid := r.URL.Query().Get("uuid")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		if uuid != "" {
+			clients, err := ah.ServerService.ShowClient(r.Context(), uuid)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			writeJSON(w, &types.Page[types.Client]{Items: clients})
+			return
+		}
+
+		options, ok := parseListOptions(w, r)
+		if !ok {
+			return
+		}
+		page, err := ah.ServerService.ListClients(r.Context(), options)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, page)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+		if !requireTarget(w, r, "uuid") {
+			return
+		}
+
+		if uuid != "" {
+			_, err := ah.ServerService.ShowClient(r.Context(), uuid)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+		}
+		err := ah.ServerService.RemoveClient(r.Context(), uuid)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "DELETE")
+	}
+}
+
+// RevokeClient revokes certificate of client (?uuid=<UUID>), 409 is returned if certificate is not issued to the client
+func (ah *APIHandler) RevokeClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		uuid := r.URL.Query().Get("uuid")
+		if uuid == "" {
+			writeError(w, r, http.StatusBadRequest, "uuid is required")
+			return
+		}
+		clients, err := ah.ServerService.ShowClient(r.Context(), uuid)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if clients[0].CertFingerprint == "" {
+			writeError(w, r, http.StatusConflict, "certificate is not issued to client")
+			return
+		}
+
+		err = ah.ServerService.RevokeClient(r.Context(), uuid)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// Directories shows page of root directories (or root directory of ?path=/rootDir) with GET, and removes them (?path=/rootDir or ?all=true) with DELETE
+func (ah *APIHandler) Directories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	afterPath := r.URL.Query().Get("path")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		if afterPath != "" {
+			if !validatePath(w, r, afterPath) {
+				return
+			}
+			dirs, err := ah.ServerService.ShowDir(r.Context(), afterPath)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			writeJSON(w, &types.Page[types.RootDirectory]{Items: dirs})
+			return
+		}
+
+		options, ok := parseListOptions(w, r)
+		if !ok {
+			return
+		}
+		page, err := ah.ServerService.ListDirs(r.Context(), options)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, page)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+		if !requireTarget(w, r, "path") {
+			return
+		}
+
+		if afterPath != "" {
+			_, err := ah.ServerService.ShowDir(r.Context(), afterPath)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+		}
+		err := ah.ServerService.RemoveDir(r.Context(), afterPath)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "DELETE")
+	}
+}
+
+// Files shows page of files (or file of ?path=/rootDir/file) with GET, and removes them (?path=/rootDir/file or ?all=true) with DELETE
+func (ah *APIHandler) Files(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	afterPath := r.URL.Query().Get("path")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		if afterPath != "" {
+			if !validatePath(w, r, afterPath) {
+				return
+			}
+			files, err := ah.ServerService.ShowFile(r.Context(), afterPath)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			writeJSON(w, &types.Page[types.File]{Items: files})
+			return
+		}
+
+		options, ok := parseListOptions(w, r)
+		if !ok {
+			return
+		}
+		page, err := ah.ServerService.ListFiles(r.Context(), options)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, page)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+		if !requireTarget(w, r, "path") {
+			return
+		}
+
+		if afterPath != "" {
+			_, err := ah.ServerService.ShowFile(r.Context(), afterPath)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+		}
+		err := ah.ServerService.RemoveFile(r.Context(), afterPath)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "DELETE")
+	}
+}
+
+// DownloadFile writes version of file in history (?path=/rootDir/file&version=<timestamp>)
+func (ah *APIHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET", "HEAD":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		afterPath := r.URL.Query().Get("path")
+		if !validatePath(w, r, afterPath) {
+			return
+		}
+		version, err := strconv.ParseUint(r.URL.Query().Get("version"), 10, 64)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "version must be timestamp of file")
+			return
+		}
+
+		fileInfo, fileContent, err := ah.ServerService.DownloadFile(r.Context(), afterPath, version)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		serveFile(w, r, afterPath, path.Base(afterPath), fileInfo, fileContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "HEAD")
+	}
+}
+
+// Histories shows page of histories of files (or history of ?path=<history key>)
+func (ah *APIHandler) Histories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		if historyKey := r.URL.Query().Get("path"); historyKey != "" {
+			histories, err := ah.ServerService.ShowHistory(r.Context(), historyKey)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			writeJSON(w, &types.Page[types.FileHistory]{Items: histories})
+			return
+		}
+
+		options, ok := parseListOptions(w, r)
+		if !ok {
+			return
+		}
+		page, err := ah.ServerService.ListHistories(r.Context(), options)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, page)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// Members invites member to root directory with POST (body is types.RootMemberReq),
+// and revokes member with DELETE (?path=/rootDir&member=<user:name|client:UUID>)
+func (ah *APIHandler) Members(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		body := &types.RootMemberReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if !validatePath(w, r, body.AfterPath) || !validateMember(w, r, body.Member) {
+			return
+		}
+		if !types.IsValidRootRole(body.Role) {
+			writeError(w, r, http.StatusBadRequest, "Role must be owner, writer or reader")
+			return
+		}
+
+		rootMemberRes, err := ah.ServerService.InviteMember(r.Context(), requester, body)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, rootMemberRes)
+	case "DELETE":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		request := &types.RootMemberReq{
+			AfterPath: r.URL.Query().Get("path"),
+			Member:    r.URL.Query().Get("member"),
+		}
+		if !validatePath(w, r, request.AfterPath) || !validateMember(w, r, request.Member) {
+			return
+		}
+
+		_, err := ah.ServerService.RevokeMember(r.Context(), requester, request)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST", "DELETE")
+	}
+}
+
+// Lockouts shows lockouts with GET, and clears them (?key=<ip:address|uuid:UUID> or ?all=true) with DELETE
+func (ah *APIHandler) Lockouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		lockouts, err := ah.ServerService.GetAllLockouts(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, lockouts)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+		if !requireTarget(w, r, "key") {
+			return
+		}
+
+		err := ah.ServerService.ClearLockout(r.Context(), r.URL.Query().Get("key"))
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "DELETE")
+	}
+}
+
+func (ah *APIHandler) Certificate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		certificateInfo, err := ah.ServerService.GetCertificateInfo(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, certificateInfo)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+func (ah *APIHandler) RenewCertificate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		certificateInfo, err := ah.ServerService.RenewCertificate(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, certificateInfo)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// Conflicts shows open conflicts of all root directories, or of root directory (?root=/rootDir)
+func (ah *APIHandler) Conflicts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		rootDirPath := r.URL.Query().Get("root")
+		if rootDirPath != "" && !validatePath(w, r, rootDirPath) {
+			return
+		}
+
+		conflicts, err := ah.SyncService.GetAllConflicts(r.Context(), rootDirPath)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, conflicts)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// ShowConflict shows candidates of conflicted file (?path=/rootDir/file)
+func (ah *APIHandler) ShowConflict(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		afterPath := r.URL.Query().Get("path")
+		if !validatePath(w, r, afterPath) {
+			return
+		}
+
+		conflict, err := ah.SyncService.GetConflictInfo(r.Context(), afterPath)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, conflict)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// DownloadCandidate writes contents of candidate of conflicted file (?path=/rootDir/file&side=<server|UUID>)
+func (ah *APIHandler) DownloadCandidate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET", "HEAD":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+		afterPath := r.URL.Query().Get("path")
+		side := r.URL.Query().Get("side")
+		if !validatePath(w, r, afterPath) {
+			return
+		}
+		if side == "" {
+			writeError(w, r, http.StatusBadRequest, "side is required")
+			return
+		}
+
+		fileInfo, fileContent, err := ah.SyncService.OpenConflictCandidate(r.Context(), afterPath, side)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		serveFile(w, r, afterPath+"_"+side, path.Base(afterPath), fileInfo, fileContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "HEAD")
+	}
+}
+
+// ResolveConflict resolves conflicted file with candidate in body (types.ConflictResolveReq)
+func (ah *APIHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := ah.auth.AuthorizeToken(w, r, types.TokenScopeAdmin)
+		if !ok {
+			return
+		}
+
+		body := &types.ConflictResolveReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if !validatePath(w, r, body.AfterPath) {
+			return
+		}
+		if body.Side == "" {
+			writeError(w, r, http.StatusBadRequest, "Side is required")
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		err := ah.SyncService.ResolveConflict(ctx, requester, body)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// writeServiceError writes status of error returned by services, unknown errors are logged and written as 500
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, server.ErrClientNotFound), errors.Is(err, server.ErrRootDirNotFound),
+		errors.Is(err, server.ErrFileNotFound), errors.Is(err, server.ErrHistoryNotFound),
+		errors.Is(err, sync.ErrRootDirNotFound), errors.Is(err, sync.ErrConflictNotFound),
+		errors.Is(err, sync.ErrCandidateNotFound), errors.Is(err, os.ErrNotExist):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, sync.ErrPermissionDenied), errors.Is(err, sync.ErrNotOwner):
+		writeError(w, r, http.StatusForbidden, err.Error())
+	case errors.Is(err, sync.ErrCandidateNotUploaded), errors.Is(err, sync.ErrFileConflicted),
+		errors.Is(err, sync.ErrContentsPending):
+		writeError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, sync.ErrInvalidPath), errors.Is(err, server.ErrInvalidListOptions):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		log.Println("quics err: [APIHandler] ", err)
+		writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// decodeBody decodes JSON body into dst, unknown fields and trailing data are rejected
+func decodeBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == io.EOF {
+		writeError(w, r, http.StatusBadRequest, "request body is required")
+		return false
+	}
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "request body is not valid: "+err.Error())
+		return false
+	}
+	if decoder.More() {
+		writeError(w, r, http.StatusBadRequest, "request body must be a single JSON object")
+		return false
+	}
+	return true
+}
+
+// parseListOptions reads page, filter and sort options from query
+// (limit, after, root, prefix, uuid, since, conflicted and sort)
+func parseListOptions(w http.ResponseWriter, r *http.Request) (*types.ListOptions, bool) {
+	query := r.URL.Query()
+	options := &types.ListOptions{
+		Limit:  defaultPageLimit,
+		After:  query.Get("after"),
+		Root:   query.Get("root"),
+		Prefix: query.Get("prefix"),
+		UUID:   query.Get("uuid"),
+		Sort:   query.Get("sort"),
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			writeError(w, r, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageLimit))
+			return nil, false
+		}
+		options.Limit = limit
+	}
+	if options.Root != "" && !validatePath(w, r, options.Root) {
+		return nil, false
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := parseSince(value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "since must be RFC3339 time or duration (e.g., 24h)")
+			return nil, false
+		}
+		options.Since = since
+	}
+	if value := query.Get("conflicted"); value != "" {
+		conflicted, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "conflicted must be true or false")
+			return nil, false
+		}
+		options.Conflicted = conflicted
+	}
+	return options, true
+}
+
+// requireTarget checks query has the key or all=true, so that all data are not removed by mistake
+func requireTarget(w http.ResponseWriter, r *http.Request, key string) bool {
+	value := r.URL.Query().Get(key)
+	all := r.URL.Query().Get("all") == "true"
+	if value == "" && !all {
+		writeError(w, r, http.StatusBadRequest, key+" or all=true is required")
+		return false
+	}
+	if value != "" && all {
+		writeError(w, r, http.StatusBadRequest, key+" and all=true can not be used together")
+		return false
+	}
+	if key == "path" && value != "" {
+		return validatePath(w, r, value)
+	}
+	return true
+}
+
+// validatePath checks path is absolute and clean (e.g., /rootDir/file)
+func validatePath(w http.ResponseWriter, r *http.Request, afterPath string) bool {
+	if afterPath == "" {
+		writeError(w, r, http.StatusBadRequest, "path is required")
+		return false
+	}
+	if afterPath[0] != '/' || path.Clean(afterPath) != afterPath {
+		writeError(w, r, http.StatusBadRequest, "path must be absolute and clean (e.g., /rootDir/file)")
+		return false
+	}
+	return true
+}
+
+func validateMember(w http.ResponseWriter, r *http.Request, member string) bool {
+	if uuid, user := types.ParseMember(member); uuid == "" && user == "" {
+		writeError(w, r, http.StatusBadRequest, "member must be user:<name> or client:<UUID>")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,430 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/types"
+	"golang.org/x/exp/slices"
+)
+
+// UploadFile uploads file with PUT (?path=/rootDir/file&version=<timestamp>), or files of multipart form with POST
+// into the folder (?path=/rootDir/dir&version=<file name>:<timestamp>) in the same way as /api/v1/files
+func (ah *APIHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "PUT", "POST":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+		if !validatePath(w, r, r.URL.Query().Get("path")) {
+			return
+		}
+
+		serveUpload(w, r, ah.SyncService, requester)
+	default:
+		writeMethodNotAllowed(w, r, "PUT", "POST")
+	}
+}
+
+// Shares shows sharing links which requester can manage (?owner=<owner>&path=/rootDir/file)
+func (ah *APIHandler) Shares(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		request := &types.ListShareReq{
+			Owner:     r.URL.Query().Get("owner"),
+			AfterPath: r.URL.Query().Get("path"),
+		}
+		listShareRes, err := ah.SharingService.ListLinks(r.Context(), requester, request)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, listShareRes.Links)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// RevokeShares revokes sharing links matching all conditions of body (types.RevokeShareReq)
+func (ah *APIHandler) RevokeShares(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		body := &types.RevokeShareReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if len(body.IDs) == 0 && body.Owner == "" && body.AfterPath == "" && body.RootDir == "" {
+			writeError(w, r, http.StatusBadRequest, "at least one of IDs, Owner, AfterPath and RootDir is required")
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		revokeShareRes, err := ah.SharingService.RevokeLinks(ctx, requester, body)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, revokeShareRes)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// DropLinks creates drop link into folder of root directory with body (types.DropReq), requester must be writer
+func (ah *APIHandler) DropLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		requester, ok := ah.auth.Authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		body := &types.DropReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if !validatePath(w, r, body.AfterPath) {
+			return
+		}
+		if body.MaxCnt == 0 {
+			writeError(w, r, http.StatusBadRequest, "MaxCnt is required")
+			return
+		}
+
+		ctx := audit.WithSourceIP(r.Context(), remoteIP(r))
+		shareRes, err := ah.SharingService.CreateDropLink(ctx, requester, body)
+		if err != nil {
+			// requester and folder are checked by sharing service, its errors are caused by request
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, shareRes)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// Tokens shows API tokens with GET, creates token with POST (body is types.APITokenReq),
+// and revokes token (?id=<token ID>) with DELETE. POST also accepts basic auth of admin, so that the first token can be created.
+func (ah *APIHandler) Tokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		apiTokens, err := ah.TokenService.GetAllTokens(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, apiTokens)
+	case "POST":
+		requester, ok := ah.auth.AuthorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		body := &types.APITokenReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if body.Name == "" {
+			writeError(w, r, http.StatusBadRequest, "Name is required")
+			return
+		}
+		if body.Scope != "" && body.Scope != types.TokenScopeRead && body.Scope != types.TokenScopeAdmin {
+			writeError(w, r, http.StatusBadRequest, "Scope must be read or admin")
+			return
+		}
+
+		apiToken, err := ah.TokenService.CreateToken(r.Context(), requester, body)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, apiToken)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeError(w, r, http.StatusBadRequest, "id is required")
+			return
+		}
+		apiTokens, err := ah.TokenService.GetAllTokens(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if !slices.ContainsFunc(apiTokens, func(apiToken types.APIToken) bool { return apiToken.ID == id }) {
+			writeError(w, r, http.StatusNotFound, "token "+id+" does not exist")
+			return
+		}
+
+		err = ah.TokenService.RevokeToken(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "POST", "DELETE")
+	}
+}
+
+// Users shows user accounts with GET, and adds user with POST (body is types.UserReq)
+func (ah *APIHandler) Users(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		users, err := ah.UserService.GetAllUsers(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, users)
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		body := &types.UserReq{}
+		if !decodeBody(w, r, body) {
+			return
+		}
+		if body.Name == "" || body.Password == "" {
+			writeError(w, r, http.StatusBadRequest, "Name and Password are required")
+			return
+		}
+		if body.Role != "" && body.Role != types.RoleAdmin && body.Role != types.RoleMember {
+			writeError(w, r, http.StatusBadRequest, "Role must be admin or member")
+			return
+		}
+		user, err := ah.findUser(r, body.Name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if user != nil {
+			writeError(w, r, http.StatusConflict, "user "+body.Name+" already exists")
+			return
+		}
+
+		err = ah.UserService.AddUser(r.Context(), body)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "POST")
+	}
+}
+
+// DisableUser disables user account (?name=<user name>), its logins and access keys can not be used anymore
+func (ah *APIHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeError(w, r, http.StatusBadRequest, "name is required")
+			return
+		}
+		user, err := ah.findUser(r, name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if user == nil {
+			writeError(w, r, http.StatusNotFound, "user "+name+" does not exist")
+			return
+		}
+
+		err = ah.UserService.DisableUser(r.Context(), name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+
+		// devices of the user keep syncing on live connections until they are closed
+		err = ah.ServerService.DisconnectUser(r.Context(), name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "POST")
+	}
+}
+
+// AccessKeys shows S3 access keys with GET, creates key of user (?name=<user name>) with POST,
+// and revokes key (?id=<access key ID>) with DELETE. Secret key is only shown in the response of POST.
+func (ah *APIHandler) AccessKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		accessKeys, err := ah.UserService.GetAllAccessKeys(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, accessKeys)
+	case "POST":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeError(w, r, http.StatusBadRequest, "name is required")
+			return
+		}
+		user, err := ah.findUser(r, name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if user == nil {
+			writeError(w, r, http.StatusNotFound, "user "+name+" does not exist")
+			return
+		}
+		if user.Disabled {
+			writeError(w, r, http.StatusConflict, "user "+name+" is disabled")
+			return
+		}
+
+		accessKeyRes, err := ah.UserService.CreateAccessKey(r.Context(), name)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, accessKeyRes)
+	case "DELETE":
+		if !ah.auth.Authorize(w, r, types.TokenScopeAdmin) {
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeError(w, r, http.StatusBadRequest, "id is required")
+			return
+		}
+		accessKeys, err := ah.UserService.GetAllAccessKeys(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if !slices.ContainsFunc(accessKeys, func(accessKey types.AccessKey) bool { return accessKey.ID == id }) {
+			writeError(w, r, http.StatusNotFound, "access key "+id+" does not exist")
+			return
+		}
+
+		err = ah.UserService.RevokeAccessKey(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w, r, "GET", "POST", "DELETE")
+	}
+}
+
+// Audit shows audit events filtered by query (?since=24h&actor=<actor>&path=/rootDir),
+// since is RFC3339 time or duration before now
+func (ah *APIHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		since := time.Time{}
+		if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+			var err error
+			since, err = parseSince(sinceParam)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "since must be RFC3339 time or duration (e.g., 24h)")
+				return
+			}
+		}
+
+		auditEvents, err := ah.AuditService.GetEvents(r.Context(), since, r.URL.Query().Get("actor"), r.URL.Query().Get("path"))
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, auditEvents)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// VerifyAudit checks hash chain of audit log
+func (ah *APIHandler) VerifyAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Alt-Svc", "h3=\":"+config.GetViperEnvVariables("REST_SERVER_H3_PORT")+"\"")
+	switch r.Method {
+	case "GET":
+		if !ah.auth.Authorize(w, r, types.TokenScopeRead) {
+			return
+		}
+
+		verifyRes, err := ah.AuditService.Verify(r.Context())
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, verifyRes)
+	default:
+		writeMethodNotAllowed(w, r, "GET")
+	}
+}
+
+// findUser returns user account of name, it is nil if user does not exist
+func (ah *APIHandler) findUser(r *http.Request, name string) (*types.User, error) {
+	users, err := ah.UserService.GetAllUsers(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(users, func(user types.User) bool { return user.Name == name })
+	if i < 0 {
+		return nil, nil
+	}
+	return &users[i], nil
+}
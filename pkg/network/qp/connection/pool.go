@@ -2,50 +2,221 @@ package connection
 
 import (
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/types"
 )
 
+// pooledConnection is connection of client with its presence
+type pooledConnection struct {
+	conn     *qp.Connection
+	presence types.ClientPresence
+}
+
+// Pool keeps connections of online clients.
+// Connection is removed when it is closed or when client is not seen for heartbeat timeout.
 type Pool struct {
-	connsMut sync.RWMutex
-	Conns    map[string]*qp.Connection
+	connsMut     sync.RWMutex
+	conns        map[string]*pooledConnection
+	onConnect    func(uuid string)
+	onDisconnect func(uuid string, presence types.ClientPresence)
+	onDelete     func(uuid string)
 }
 
 func NewnPool() *Pool {
 	return &Pool{
 		connsMut: sync.RWMutex{},
-		Conns:    map[string]*qp.Connection{},
+		conns:    map[string]*pooledConnection{},
 	}
 }
 
-func (cp *Pool) UpdateConnection(uuid string, conn *qp.Connection) error {
+// OnConnect sets handler which is called after client is connected
+func (cp *Pool) OnConnect(handler func(uuid string)) {
+	cp.onConnect = handler
+}
+
+// OnDisconnect sets handler which is called with the last presence after connection of client is removed
+func (cp *Pool) OnDisconnect(handler func(uuid string, presence types.ClientPresence)) {
+	cp.onDisconnect = handler
+}
+
+// OnDelete sets handler which is called after client leaves the server with DeleteConnection
+func (cp *Pool) OnDelete(handler func(uuid string)) {
+	cp.onDelete = handler
+}
+
+func (cp *Pool) UpdateConnection(uuid string, conn *qp.Connection, version string) error {
+	now := time.Now()
+	pooled := &pooledConnection{
+		conn: conn,
+		presence: types.ClientPresence{
+			Online:      true,
+			ConnectedAt: now,
+			LastSeen:    now,
+			Version:     version,
+		},
+	}
+	if conn != nil && conn.Conn != nil {
+		pooled.presence.Address = conn.Conn.RemoteAddr().String()
+	}
+
 	cp.connsMut.Lock()
-	defer cp.connsMut.Unlock()
-	cp.Conns[uuid] = conn
+	previous, reused := cp.conns[uuid]
+	reused = reused && previous.conn == conn
+	if reused {
+		pooled.presence.ConnectedAt = previous.presence.ConnectedAt
+	}
+	cp.conns[uuid] = pooled
+	cp.connsMut.Unlock()
+
+	// remove connection as soon as it is closed (e.g., QUIC idle timeout of dead client)
+	if !reused && conn != nil && conn.Conn != nil {
+		go func() {
+			<-conn.Conn.Context().Done()
+			cp.remove(uuid, conn)
+		}()
+	}
+	// transaction on the same connection only updates presence, client is not connected again
+	if !reused && cp.onConnect != nil {
+		go cp.onConnect(uuid)
+	}
 	return nil
 }
 
 func (cp *Pool) GetConnection(uuid string) (*qp.Connection, error) {
-	if conn, exists := cp.Conns[uuid]; exists {
-		return conn, nil
+	cp.connsMut.RLock()
+	defer cp.connsMut.RUnlock()
+
+	if pooled, exists := cp.conns[uuid]; exists {
+		return pooled.conn, nil
 	}
 	return nil, fmt.Errorf("connection does not exist")
 }
 
 func (cp *Pool) GetConnections(uuid []string) ([]*qp.Connection, error) {
+	cp.connsMut.RLock()
+	defer cp.connsMut.RUnlock()
+
 	conns := []*qp.Connection{}
 	for _, value := range uuid {
-		if conn, exists := cp.Conns[value]; exists {
-			conns = append(conns, conn)
+		if pooled, exists := cp.conns[value]; exists {
+			conns = append(conns, pooled.conn)
 		}
 	}
 	return conns, nil
 }
 
+// DeleteConnection removes connection of client which leaves the server (e.g., disconnected or revoked client).
+// The last presence is saved as when the connection ends, and the connection itself is not closed.
 func (cp *Pool) DeleteConnection(uuid string) error {
+	cp.connsMut.RLock()
+	pooled, exists := cp.conns[uuid]
+	cp.connsMut.RUnlock()
+	if exists {
+		cp.remove(uuid, pooled.conn)
+	}
+
+	if cp.onDelete != nil {
+		cp.onDelete(uuid)
+	}
+	return nil
+}
+
+func (cp *Pool) GetAllUUIDs() []string {
+	cp.connsMut.RLock()
+	defer cp.connsMut.RUnlock()
+
+	uuids := make([]string, 0, len(cp.conns))
+	for uuid := range cp.conns {
+		uuids = append(uuids, uuid)
+	}
+	return uuids
+}
+
+// Touch updates last seen time of client, only when conn is the current connection of client
+func (cp *Pool) Touch(uuid string, conn *qp.Connection) {
 	cp.connsMut.Lock()
 	defer cp.connsMut.Unlock()
-	delete(cp.Conns, uuid)
-	return nil
+
+	if pooled, exists := cp.conns[uuid]; exists && pooled.conn == conn {
+		pooled.presence.LastSeen = time.Now()
+	}
+}
+
+// GetPresence returns presence of connected client, false if client is not connected
+func (cp *Pool) GetPresence(uuid string) (types.ClientPresence, bool) {
+	cp.connsMut.RLock()
+	defer cp.connsMut.RUnlock()
+
+	if pooled, exists := cp.conns[uuid]; exists {
+		return pooled.presence, true
+	}
+	return types.ClientPresence{}, false
+}
+
+// IsOnline checks whether client is connected
+func (cp *Pool) IsOnline(uuid string) bool {
+	_, online := cp.GetPresence(uuid)
+	return online
+}
+
+// BackgroundEvictStale closes connections of clients which are not seen for timeout, checking every half of timeout
+func (cp *Pool) BackgroundEvictStale(timeout time.Duration) {
+	go func() {
+		for {
+			time.Sleep(timeout / 2)
+			cp.EvictStale(timeout)
+		}
+	}()
+}
+
+// EvictStale closes and removes connections of clients which are not seen for timeout, and returns their UUIDs
+func (cp *Pool) EvictStale(timeout time.Duration) []string {
+	deadline := time.Now().Add(-timeout)
+	stale := map[string]*qp.Connection{}
+	cp.connsMut.RLock()
+	for uuid, pooled := range cp.conns {
+		if pooled.presence.LastSeen.Before(deadline) {
+			stale[uuid] = pooled.conn
+		}
+	}
+	cp.connsMut.RUnlock()
+
+	uuids := []string{}
+	for uuid, conn := range stale {
+		if !cp.remove(uuid, conn) {
+			continue
+		}
+		log.Println("quics: evict stale connection of ", uuid)
+		uuids = append(uuids, uuid)
+		if conn != nil {
+			err := conn.CloseWithError("heartbeat timeout")
+			if err != nil {
+				log.Println("quics err: ", err)
+			}
+		}
+	}
+	return uuids
+}
+
+// remove removes connection of client if it is still the current connection, and calls disconnect handler
+func (cp *Pool) remove(uuid string, conn *qp.Connection) bool {
+	cp.connsMut.Lock()
+	pooled, exists := cp.conns[uuid]
+	if !exists || pooled.conn != conn {
+		cp.connsMut.Unlock()
+		return false
+	}
+	delete(cp.conns, uuid)
+	cp.connsMut.Unlock()
+
+	if cp.onDisconnect != nil {
+		presence := pooled.presence
+		presence.Online = false
+		cp.onDisconnect(uuid, presence)
+	}
+	return true
 }
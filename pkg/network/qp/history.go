@@ -1,12 +1,17 @@
 package qp
 
 import (
+	"context"
 	"log"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/history"
 	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type HistoryHandler struct {
@@ -21,7 +26,7 @@ func NewHistoryHandler(service history.Service, sharingService sharing.Service)
 	}
 }
 
-func (hh *HistoryHandler) ShowHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (hh *HistoryHandler) ShowHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -35,8 +40,16 @@ func (hh *HistoryHandler) ShowHistory(conn *qp.Connection, stream *qp.Stream, tr
 		log.Println("quics err: [", transactionName, "] decode request: ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
-	response, err := hh.historyService.ShowHistory(request)
+	response, err := hh.historyService.ShowHistory(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] while historyService: ", err)
 		return err
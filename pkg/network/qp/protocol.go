@@ -1,24 +1,38 @@
 package qp
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/ca"
 	"github.com/quic-s/quics/pkg/network/qp/connection"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ClientVerifier verifies that client certificate is issued to the client and not revoked
+type ClientVerifier interface {
+	VerifyClientCertificate(ctx context.Context, uuid string, fingerprint string) error
+}
+
 type Protocol struct {
 	udpaddr            string
 	tlsConf            *tls.Config
 	initialTransaction func(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error
 	Proto              *qp.QP
 	Pool               *connection.Pool
+	verifier           ClientVerifier
+	listening          atomic.Bool
 }
 
-func New(ip string, port int, pool *connection.Pool) (*Protocol, error) {
+func New(ip string, port int, pool *connection.Pool, authority *ca.CA, identity *ca.Identity, verifier ClientVerifier) (*Protocol, error) {
 	// initialize protocol server
 	proto, err := qp.New(qp.LOG_LEVEL_ERROR)
 	if err != nil {
@@ -26,31 +40,31 @@ func New(ip string, port int, pool *connection.Pool) (*Protocol, error) {
 		return nil, err
 	}
 
-	// initialize certificate for connection
-	cert, err := qp.GetCertificate("", "")
-	if err != nil {
-		log.Println("quics err: ", err)
-		return nil, err
-	}
-
 	// initialize tls config for connection with quics protocol
+	// certificate is shared with rest server and reloaded when it is renewed,
+	// client certificate is optional for registration, other transactions require it
 	tlsConfig := &tls.Config{
-		Certificates: cert,
-		NextProtos:   []string{"quic-s"},
+		GetCertificate: identity.GetCertificate,
+		ClientAuth:     tls.VerifyClientCertIfGiven,
+		ClientCAs:      authority.CertPool(),
+		NextProtos:     []string{"quic-s"},
+	}
+
+	protocol := &Protocol{
+		udpaddr:  ":6122",
+		tlsConf:  tlsConfig,
+		Proto:    proto,
+		Pool:     pool,
+		verifier: verifier,
 	}
 
-	err = proto.RecvTransactionHandleFunc(types.PING, ping)
+	err = proto.RecvTransactionHandleFunc(types.PING, protocol.ping)
 	if err != nil {
 		log.Println("quics err: ", err)
 		return nil, err
 	}
 
-	return &Protocol{
-		udpaddr: ":6122",
-		tlsConf: tlsConfig,
-		Proto:   proto,
-		Pool:    pool,
-	}, nil
+	return protocol, nil
 }
 
 // Start starts quics protocol server
@@ -58,7 +72,9 @@ func (p *Protocol) Start() error {
 	errChan := make(chan error)
 	go func() {
 		// listen quics protocol with client
+		p.listening.Store(true)
 		err := p.Proto.ListenWithTransaction(p.udpaddr, p.tlsConf, p.initialTransaction)
+		p.listening.Store(false)
 		if err != nil {
 			log.Println("quics err: ", err)
 			errChan <- err
@@ -75,6 +91,7 @@ func (p *Protocol) Start() error {
 }
 
 func (p *Protocol) Close() error {
+	p.listening.Store(false)
 	err := p.Proto.Close()
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -83,12 +100,17 @@ func (p *Protocol) Close() error {
 	return nil
 }
 
+// IsListening checks whether quics protocol server is accepting connections
+func (p *Protocol) IsListening() bool {
+	return p.listening.Load()
+}
+
 func (p *Protocol) RecvTransactionHandleFunc(transactionName string, handleFunc func(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error) error {
 	if transactionName == types.REGISTERCLIENT {
 		p.initialTransaction = handleFunc
 		return nil
 	}
-	err := p.Proto.RecvTransactionHandleFunc(transactionName, handleFunc)
+	err := p.Proto.RecvTransactionHandleFunc(transactionName, p.requireClientCertificate(handleFunc))
 	if err != nil {
 		log.Println("quics err: ", err)
 		return err
@@ -96,7 +118,60 @@ func (p *Protocol) RecvTransactionHandleFunc(transactionName string, handleFunc
 	return nil
 }
 
-func ping(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+// ErrRequesterMismatch is returned when UUID of request is not the client which certificate is issued to
+var ErrRequesterMismatch = errors.New("UUID of request does not match client certificate")
+
+// checkRequester checks UUID of request is the authenticated UUID (common name of client certificate) of connection,
+// so that client with valid certificate can not send transaction as another client.
+// Handlers wrapped by requireClientCertificate must call it after decoding request.
+func checkRequester(conn *qp.Connection, uuid string) error {
+	if conn == nil || conn.Conn == nil {
+		return errors.New("client certificate is required")
+	}
+	return matchRequester(conn.Conn.ConnectionState().TLS.PeerCertificates, uuid)
+}
+
+// matchRequester checks uuid is common name of the leaf certificate of peer
+func matchRequester(peerCertificates []*x509.Certificate, uuid string) error {
+	if len(peerCertificates) == 0 {
+		return errors.New("client certificate is required")
+	}
+	if peerCertificates[0].Subject.CommonName != uuid {
+		return ErrRequesterMismatch
+	}
+	return nil
+}
+
+// requireClientCertificate wraps handleFunc to be called only with valid client certificate,
+// the certificate is mapped to the client by its fingerprint and UUID (common name)
+func (p *Protocol) requireClientCertificate(handleFunc func(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error) func(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+	return func(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+		peerCertificates := conn.Conn.ConnectionState().TLS.PeerCertificates
+		if len(peerCertificates) == 0 {
+			log.Println("quics err: [", transactionName, "] client certificate is required")
+			return errors.New("client certificate is required")
+		}
+
+		cert := peerCertificates[0]
+		err := p.verifier.VerifyClientCertificate(context.Background(), cert.Subject.CommonName, ca.Fingerprint(cert.Raw))
+		if err != nil {
+			log.Println("quics err: [", transactionName, "] ", err)
+			return err
+		}
+
+		err = handleFunc(conn, stream, transactionName, transactionID)
+		if err != nil {
+			return err
+		}
+
+		// successful transaction is also a heartbeat of client
+		p.Pool.Touch(cert.Subject.CommonName, conn)
+		return nil
+	}
+}
+
+// ping answers heartbeat of client and updates its last seen time
+func (p *Protocol) ping(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	data, err := stream.RecvBMessage()
 	if err != nil {
 		log.Println("quics err: ", err)
@@ -109,6 +184,9 @@ func ping(conn *qp.Connection, stream *qp.Stream, transactionName string, transa
 		return err
 	}
 
+	_, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	defer func() { tracing.EndWithError(span, err) }()
+
 	log.Println("quics: Ping received from ", request.UUID)
 	response, err := request.Encode()
 	if err != nil {
@@ -121,5 +199,8 @@ func ping(conn *qp.Connection, stream *qp.Stream, transactionName string, transa
 		log.Println("quics err: ", err)
 		return err
 	}
+
+	// only the connection of the client can update its presence
+	p.Pool.Touch(request.UUID, conn)
 	return nil
 }
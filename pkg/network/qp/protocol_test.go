@@ -0,0 +1,32 @@
+package qp
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+)
+
+func TestMatchRequester(t *testing.T) {
+	certificates := []*x509.Certificate{{Subject: pkix.Name{CommonName: "client-a"}}}
+
+	err := matchRequester(certificates, "client-b")
+	if !errors.Is(err, ErrRequesterMismatch) {
+		t.Fatalf("request as another client: got %v, want %v", err, ErrRequesterMismatch)
+	}
+
+	err = matchRequester(certificates, "")
+	if !errors.Is(err, ErrRequesterMismatch) {
+		t.Fatalf("request without UUID: got %v, want %v", err, ErrRequesterMismatch)
+	}
+
+	err = matchRequester(nil, "client-a")
+	if err == nil {
+		t.Fatal("request without certificate: got nil error")
+	}
+
+	err = matchRequester(certificates, "client-a")
+	if err != nil {
+		t.Fatalf("request as certificate owner: got %v", err)
+	}
+}
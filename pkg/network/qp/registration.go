@@ -1,13 +1,18 @@
 package qp
 
 import (
+	"context"
 	"errors"
 	"log"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/registration"
 	"github.com/quic-s/quics/pkg/network/qp/connection"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RegistrationHandler struct {
@@ -21,7 +26,7 @@ func NewRegistrationHandler(service registration.Service) *RegistrationHandler {
 }
 
 // register client
-func (rh *RegistrationHandler) RegisterClient(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (rh *RegistrationHandler) RegisterClient(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 	data, err := stream.RecvBMessage()
 	if err != nil {
@@ -34,8 +39,12 @@ func (rh *RegistrationHandler) RegisterClient(conn *qp.Connection, stream *qp.St
 		return err
 	}
 
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
 	// call registration service
-	response, err := rh.registrationService.RegisterClient(request, conn)
+	response, err := rh.registrationService.RegisterClient(ctx, request, conn)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -65,8 +74,8 @@ func NewRegistrationAdapter(pool *connection.Pool) *RegistrationAdapter {
 	}
 }
 
-func (ra *RegistrationAdapter) UpdateClientConnection(uuid string, conn *qp.Connection) error {
-	err := ra.Pool.UpdateConnection(uuid, conn)
+func (ra *RegistrationAdapter) UpdateClientConnection(uuid string, conn *qp.Connection, version string) error {
+	err := ra.Pool.UpdateConnection(uuid, conn, version)
 	if err != nil {
 		err = errors.New("RegistrationAdapter.UpdateClientConnection: " + err.Error())
 		return err
@@ -82,3 +91,24 @@ func (ra *RegistrationAdapter) DeleteConnection(uuid string) error {
 	}
 	return nil
 }
+
+func (ra *RegistrationAdapter) CloseConnection(uuid string, message string) error {
+	conn, err := ra.Pool.GetConnection(uuid)
+	if err != nil {
+		err = errors.New("RegistrationAdapter.CloseConnection: " + err.Error())
+		return err
+	}
+
+	err = ra.Pool.DeleteConnection(uuid)
+	if err != nil {
+		err = errors.New("RegistrationAdapter.CloseConnection: " + err.Error())
+		return err
+	}
+
+	err = conn.CloseWithError(message)
+	if err != nil {
+		err = errors.New("RegistrationAdapter.CloseConnection: " + err.Error())
+		return err
+	}
+	return nil
+}
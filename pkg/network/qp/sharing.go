@@ -1,11 +1,16 @@
 package qp
 
 import (
+	"context"
 	"log"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/sharing"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type SharingHandler struct {
@@ -18,7 +23,7 @@ func NewSharingHandler(sharingService sharing.Service) *SharingHandler {
 	}
 }
 
-func (sh *SharingHandler) StartSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SharingHandler) StartSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -32,8 +37,16 @@ func (sh *SharingHandler) StartSharing(conn *qp.Connection, stream *qp.Stream, t
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
-	response, err := sh.sharingService.CreateLink(request)
+	response, err := sh.sharingService.CreateLink(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -55,7 +68,7 @@ func (sh *SharingHandler) StartSharing(conn *qp.Connection, stream *qp.Stream, t
 	return nil
 }
 
-func (sh *SharingHandler) StopSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SharingHandler) StopSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -69,8 +82,151 @@ func (sh *SharingHandler) StopSharing(conn *qp.Connection, stream *qp.Stream, tr
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, err := sh.sharingService.DeleteLink(ctx, request)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	data, err = response.Encode()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	err = stream.SendBMessage(data)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	log.Println("quics: [", transactionName, "] transaction finished")
+	return nil
+}
+
+func (sh *SharingHandler) StartDrop(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
+	log.Println("quics: receive ", transactionName, " transaction")
+
+	data, err := stream.RecvBMessage()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	request := &types.DropReq{}
+	if err := request.Decode(data); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, err := sh.sharingService.CreateDropLink(ctx, types.ClientMember(request.UUID), request)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	data, err = response.Encode()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	err = stream.SendBMessage(data)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	log.Println("quics: [", transactionName, "] transaction finished")
+	return nil
+}
+
+func (sh *SharingHandler) ListSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
+	log.Println("quics: receive ", transactionName, " transaction")
+
+	data, err := stream.RecvBMessage()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	request := &types.ListShareReq{}
+	if err := request.Decode(data); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, err := sh.sharingService.ListLinks(ctx, types.ClientMember(request.UUID), request)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	data, err = response.Encode()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	err = stream.SendBMessage(data)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	log.Println("quics: [", transactionName, "] transaction finished")
+	return nil
+}
+
+func (sh *SharingHandler) RevokeSharing(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
+	log.Println("quics: receive ", transactionName, " transaction")
+
+	data, err := stream.RecvBMessage()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	request := &types.RevokeShareReq{}
+	if err := request.Decode(data); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
-	response, err := sh.sharingService.DeleteLink(request)
+	response, err := sh.sharingService.RevokeLinks(ctx, types.ClientMember(request.UUID), request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -1,15 +1,19 @@
 package qp
 
 import (
+	"context"
 	"crypto/sha1"
 	"io"
 	"log"
 	stdsync "sync"
 
 	"github.com/quic-s/quics/pkg/network/qp/connection"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
 
 	qp "github.com/quic-s/quics-protocol"
+	"github.com/quic-s/quics/pkg/core/audit"
 	"github.com/quic-s/quics/pkg/core/sync"
 	"github.com/quic-s/quics/pkg/types"
 )
@@ -35,7 +39,7 @@ func NewSyncHandler(service sync.Service) *SyncHandler {
 }
 
 // register root directory
-func (sh *SyncHandler) RegisterRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) RegisterRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -48,9 +52,17 @@ func (sh *SyncHandler) RegisterRootDir(conn *qp.Connection, stream *qp.Stream, t
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// Register root directory of client to database
-	response, err := sh.syncService.RegisterRootDir(request)
+	response, err := sh.syncService.RegisterRootDir(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -69,7 +81,7 @@ func (sh *SyncHandler) RegisterRootDir(conn *qp.Connection, stream *qp.Stream, t
 
 	// do fullscan in goroutine
 	go func() {
-		_, err := sh.syncService.Rescan(&types.RescanReq{
+		_, err := sh.syncService.Rescan(ctx, &types.RescanReq{
 			UUID: request.UUID,
 		})
 		if err != nil {
@@ -82,7 +94,7 @@ func (sh *SyncHandler) RegisterRootDir(conn *qp.Connection, stream *qp.Stream, t
 }
 
 // sync root directory
-func (sh *SyncHandler) SyncRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) SyncRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -96,9 +108,17 @@ func (sh *SyncHandler) SyncRootDir(conn *qp.Connection, stream *qp.Stream, trans
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// get root directory path of requested data
-	rootDirRegisterRes, err := sh.syncService.SyncRootDir(request)
+	rootDirRegisterRes, err := sh.syncService.SyncRootDir(ctx, request, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -118,7 +138,7 @@ func (sh *SyncHandler) SyncRootDir(conn *qp.Connection, stream *qp.Stream, trans
 
 	// do fullscan in goroutine
 	go func() {
-		_, err := sh.syncService.Rescan(&types.RescanReq{
+		_, err := sh.syncService.Rescan(ctx, &types.RescanReq{
 			UUID: request.UUID,
 		})
 		if err != nil {
@@ -131,7 +151,7 @@ func (sh *SyncHandler) SyncRootDir(conn *qp.Connection, stream *qp.Stream, trans
 }
 
 // get root directory list
-func (sh *SyncHandler) GetRemoteDirs(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) GetRemoteDirs(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -144,8 +164,16 @@ func (sh *SyncHandler) GetRemoteDirs(conn *qp.Connection, stream *qp.Stream, tra
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
-	rootDirs, err := sh.syncService.GetRootDirList()
+	rootDirs, err := sh.syncService.GetRootDirList(ctx)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -166,7 +194,7 @@ func (sh *SyncHandler) GetRemoteDirs(conn *qp.Connection, stream *qp.Stream, tra
 	return nil
 }
 
-func (sh *SyncHandler) DisconnectRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) DisconnectRootDir(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -180,9 +208,17 @@ func (sh *SyncHandler) DisconnectRootDir(conn *qp.Connection, stream *qp.Stream,
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// get root directory path of requested data
-	disconnectRootDirRes, err := sh.syncService.DisconnectRootDir(request)
+	disconnectRootDirRes, err := sh.syncService.DisconnectRootDir(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -203,9 +239,101 @@ func (sh *SyncHandler) DisconnectRootDir(conn *qp.Connection, stream *qp.Stream,
 	return nil
 }
 
+// invite member transaction
+// it is used when owner of root directory wants to invite member
+func (sh *SyncHandler) InviteMember(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
+	log.Println("quics: receive ", transactionName, " transaction")
+
+	data, err := stream.RecvBMessage()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	request := &types.RootMemberReq{}
+	if err := request.Decode(data); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	rootMemberRes, err := sh.syncService.InviteMember(ctx, types.ClientMember(request.UUID), request)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	response, err := rootMemberRes.Encode()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	err = stream.SendBMessage(response)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	log.Println("quics: [", transactionName, "] transaction finished")
+	return nil
+}
+
+// revoke member transaction
+// it is used when owner of root directory wants to revoke member
+func (sh *SyncHandler) RevokeMember(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
+	log.Println("quics: receive ", transactionName, " transaction")
+
+	data, err := stream.RecvBMessage()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	request := &types.RootMemberReq{}
+	if err := request.Decode(data); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	rootMemberRes, err := sh.syncService.RevokeMember(ctx, types.ClientMember(request.UUID), request)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	response, err := rootMemberRes.Encode()
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	err = stream.SendBMessage(response)
+	if err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+	log.Println("quics: [", transactionName, "] transaction finished")
+	return nil
+}
+
 // please sync transaction
 // it is used when client wants to sync file
-func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	// -> return file metadata to client
@@ -221,6 +349,14 @@ func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transa
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, pleaseSyncReq.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, pleaseSyncReq.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
@@ -231,7 +367,7 @@ func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transa
 	sh.pathMut[uint8(hash[0]%sh.lockNum)].Lock()
 	defer sh.pathMut[uint8(hash[0]%sh.lockNum)].Unlock()
 
-	pleaseSyncRes, err := sh.syncService.UpdateFileWithoutContents(pleaseSyncReq)
+	pleaseSyncRes, err := sh.syncService.UpdateFileWithoutContents(ctx, pleaseSyncReq)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -264,6 +400,10 @@ func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transa
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, pleaseTakeReq.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
 	fileMetedata := &types.FileMetadata{
 		Name:    fileInfo.Name,
 		Size:    fileInfo.Size,
@@ -272,7 +412,7 @@ func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transa
 		IsDir:   fileInfo.IsDir,
 	}
 
-	pleaseTakeRes, err := sh.syncService.UpdateFileWithContents(pleaseTakeReq, fileMetedata, fileContent)
+	pleaseTakeRes, err := sh.syncService.UpdateFileWithContents(ctx, pleaseTakeReq, fileMetedata, fileContent)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -297,7 +437,7 @@ func (sh *SyncHandler) PleaseSync(conn *qp.Connection, stream *qp.Stream, transa
 
 // get conflict list transaction
 // it is used when client wants to get conflict status list
-func (sh *SyncHandler) AskConflictList(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) AskConflictList(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 	data, err := stream.RecvBMessage()
 	if err != nil {
@@ -310,9 +450,17 @@ func (sh *SyncHandler) AskConflictList(conn *qp.Connection, stream *qp.Stream, t
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// get root directory path of requested data
-	askConflictListRes, err := sh.syncService.GetConflictList(request)
+	askConflictListRes, err := sh.syncService.GetConflictList(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -335,7 +483,7 @@ func (sh *SyncHandler) AskConflictList(conn *qp.Connection, stream *qp.Stream, t
 
 // choose one transaction
 // it is used when client wants to choose one of conflict files
-func (sh *SyncHandler) ChooseOne(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) ChooseOne(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -349,6 +497,14 @@ func (sh *SyncHandler) ChooseOne(conn *qp.Connection, stream *qp.Stream, transac
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
+
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// lock mutex by hash value of file path
 	// using hash value is to reduce the number of mutex
@@ -360,7 +516,7 @@ func (sh *SyncHandler) ChooseOne(conn *qp.Connection, stream *qp.Stream, transac
 	defer sh.pathMut[uint8(hash[0]%sh.lockNum)].Unlock()
 
 	// get root directory path of requested data
-	pleaseFileRes, err := sh.syncService.ChooseOne(request)
+	pleaseFileRes, err := sh.syncService.ChooseOne(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -383,7 +539,7 @@ func (sh *SyncHandler) ChooseOne(conn *qp.Connection, stream *qp.Stream, transac
 
 // rescan transaction
 // it is used when client wants to rescan (fullscan)
-func (sh *SyncHandler) Rescan(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) Rescan(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -397,8 +553,16 @@ func (sh *SyncHandler) Rescan(conn *qp.Connection, stream *qp.Stream, transactio
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
 
-	rescanRes, err := sh.syncService.Rescan(request)
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	rescanRes, err := sh.syncService.Rescan(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -421,7 +585,7 @@ func (sh *SyncHandler) Rescan(conn *qp.Connection, stream *qp.Stream, transactio
 
 // rollback transaction
 // it is used when client wants to rollback file to specific version
-func (sh *SyncHandler) RollbackFileByHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) RollbackFileByHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 
 	data, err := stream.RecvBMessage()
@@ -435,8 +599,16 @@ func (sh *SyncHandler) RollbackFileByHistory(conn *qp.Connection, stream *qp.Str
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
 
-	response, err := sh.syncService.RollbackFileByHistory(request)
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, err := sh.syncService.RollbackFileByHistory(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -460,7 +632,7 @@ func (sh *SyncHandler) RollbackFileByHistory(conn *qp.Connection, stream *qp.Str
 
 // conflict download transaction
 // it is used when client wants to download conflict files
-func (sh *SyncHandler) ConflictDownload(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) ConflictDownload(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 	data, err := stream.RecvBMessage()
 	if err != nil {
@@ -473,8 +645,16 @@ func (sh *SyncHandler) ConflictDownload(conn *qp.Connection, stream *qp.Stream,
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
 
-	response, err := sh.syncService.GetStagingNum(request)
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, err := sh.syncService.GetStagingNum(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -497,7 +677,7 @@ func (sh *SyncHandler) ConflictDownload(conn *qp.Connection, stream *qp.Stream,
 		return nil
 	}
 
-	requests, err := sh.syncService.GetConflictFiles(request)
+	requests, err := sh.syncService.GetConflictFiles(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -533,7 +713,7 @@ func (sh *SyncHandler) ConflictDownload(conn *qp.Connection, stream *qp.Stream,
 
 // download history transaction
 // it is used when client wants to download specific history(version) file
-func (sh *SyncHandler) DownloadHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) error {
+func (sh *SyncHandler) DownloadHistory(conn *qp.Connection, stream *qp.Stream, transactionName string, transactionID []byte) (err error) {
 	log.Println("quics: receive ", transactionName, " transaction")
 	data, err := stream.RecvBMessage()
 	if err != nil {
@@ -546,8 +726,16 @@ func (sh *SyncHandler) DownloadHistory(conn *qp.Connection, stream *qp.Stream, t
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
 	}
+	if err = checkRequester(conn, request.UUID); err != nil {
+		log.Println("quics err: [", transactionName, "] ", err)
+		return err
+	}
 
-	response, filePath, err := sh.syncService.DownloadHistory(request)
+	ctx, span := tracing.StartTransaction(context.Background(), transactionName, transactionID, request.Trace, trace.SpanKindServer)
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(conn.Conn.RemoteAddr()))
+	defer func() { tracing.EndWithError(span, err) }()
+
+	response, filePath, err := sh.syncService.DownloadHistory(ctx, request)
 	if err != nil {
 		log.Println("quics err: [", transactionName, "] ", err)
 		return err
@@ -579,15 +767,22 @@ func NewSyncAdapter(pool *connection.Pool) *SyncAdapter {
 	}
 }
 
+// IsOnline checks whether client is connected to server
+func (sa *SyncAdapter) IsOnline(uuid string) bool {
+	return sa.Pool.IsOnline(uuid)
+}
+
 type Transaction struct {
 	transactionName string
 	wg              *stdsync.WaitGroup
 	stream          *qp.Stream
+	ctx             context.Context
+	span            trace.Span
 }
 
 // OpenTransaction opens transaction
 // this method is called when server wants to open transaction (server-push)
-func (sa *SyncAdapter) OpenTransaction(transactionName string, uuid string) (sync.Transaction, error) {
+func (sa *SyncAdapter) OpenTransaction(ctx context.Context, transactionName string, uuid string) (sync.Transaction, error) {
 	log.Println("quics: open ", transactionName, " transaction")
 	// get connection from pool by uuid
 	conn, err := sa.Pool.GetConnection(uuid)
@@ -612,6 +807,10 @@ func (sa *SyncAdapter) OpenTransaction(transactionName string, uuid string) (syn
 			// set stream to transaction
 			transaction.stream = stream
 
+			// start span of this transaction to send its trace context with requests
+			transaction.ctx, transaction.span = tracing.StartTransaction(ctx, transactionName, transactionID, nil, trace.SpanKindClient)
+			transaction.span.SetAttributes(tracing.AttrClientUUID.String(uuid))
+
 			// send nil to error channel
 			// this would be followed after set stream
 			errChan <- nil
@@ -635,6 +834,7 @@ func (sa *SyncAdapter) OpenTransaction(transactionName string, uuid string) (syn
 }
 
 func (t *Transaction) Close() error {
+	t.span.End()
 	t.wg.Done()
 	log.Println("quics: close ", t.transactionName, " transaction")
 	return nil
@@ -643,6 +843,8 @@ func (t *Transaction) Close() error {
 // send and receive mustsync request and response
 // using on CallMustSync method in sync service
 func (t *Transaction) RequestMustSync(mustSyncReq *types.MustSyncReq) (*types.MustSyncRes, error) {
+	mustSyncReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := mustSyncReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -672,6 +874,8 @@ func (t *Transaction) RequestMustSync(mustSyncReq *types.MustSyncReq) (*types.Mu
 // send and receive giveyou request and response
 // using on CallMustSync method in sync service
 func (t *Transaction) RequestGiveYou(giveYouReq *types.GiveYouReq, historyFilePath string) (*types.GiveYouRes, error) {
+	giveYouReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := giveYouReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -703,6 +907,8 @@ func (t *Transaction) RequestGiveYou(giveYouReq *types.GiveYouReq, historyFilePa
 // send and receive forcesync request and response
 // using on CallForceSync method in sync service
 func (t *Transaction) RequestForceSync(mustSyncReq *types.MustSyncReq, historyFilePath string) (*types.MustSyncRes, error) {
+	mustSyncReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := mustSyncReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -734,6 +940,8 @@ func (t *Transaction) RequestForceSync(mustSyncReq *types.MustSyncReq, historyFi
 // send and receive askallmeta request and response
 // using on FullScan method in sync service
 func (t *Transaction) RequestAskAllMeta(askAllMetaReq *types.AskAllMetaReq) (*types.AskAllMetaRes, error) {
+	askAllMetaReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := askAllMetaReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -764,6 +972,8 @@ func (t *Transaction) RequestAskAllMeta(askAllMetaReq *types.AskAllMetaReq) (*ty
 // send and receive needsync request and response
 // using when server wants to get PleaseSync request from client
 func (t *Transaction) RequestNeedSync(needSyncReq *types.NeedSyncReq) (*types.NeedSyncRes, error) {
+	needSyncReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := needSyncReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -794,6 +1004,8 @@ func (t *Transaction) RequestNeedSync(needSyncReq *types.NeedSyncReq) (*types.Ne
 // send needcontent request and receive file metadata and file contents
 // using on FullScan method in sync service when file contents are needed
 func (t *Transaction) RequestNeedContent(needContentReq *types.NeedContentReq) (*types.NeedContentRes, *types.FileMetadata, io.Reader, error) {
+	needContentReq.Trace = tracing.Inject(t.ctx)
+
 	request, err := needContentReq.Encode()
 	if err != nil {
 		log.Println("quics err: [", t.transactionName, "] ", err)
@@ -0,0 +1,443 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+	iso8601Format   = "20060102T150405Z"
+	maxClockSkew    = 15 * time.Minute
+	maxPresignTime  = 7 * 24 * time.Hour
+
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingPayloadTrailer  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	emptySHA256              = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// now returns current time to check request time of signature, it is replaced in tests
+var now = time.Now
+
+// credential is authenticated requester of S3 request with the key to verify signed payload
+type credential struct {
+	user        *types.User
+	requester   string
+	signingKey  []byte
+	amzDate     string
+	scope       string
+	signature   string
+	payloadHash string
+}
+
+// signature is parsed Authorization header or presigned query of signature version 4
+type signature struct {
+	accessKeyID   string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	amzDate       string
+	payloadHash   string
+	presigned     bool
+	expires       time.Duration
+}
+
+// authenticate verifies signature version 4 of request with secret key of access key
+func (h *Handler) authenticate(ctx context.Context, r *http.Request) (*credential, error) {
+	sig, err := parseSignature(r)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTime, err := time.Parse(iso8601Format, sig.amzDate)
+	if err != nil || !strings.HasPrefix(sig.amzDate, sig.date) {
+		return nil, errAuthHeaderMalformed
+	}
+	if sig.presigned {
+		if now().After(requestTime.Add(sig.expires)) {
+			return nil, errExpiredPresignRequest
+		}
+		if requestTime.Sub(now()) > maxClockSkew {
+			return nil, errRequestTimeTooSkewed
+		}
+	} else if d := now().Sub(requestTime); d > maxClockSkew || d < -maxClockSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+
+	accessKey, user, err := h.userService.AuthenticateAccessKey(ctx, sig.accessKeyID)
+	if err != nil {
+		return nil, errInvalidAccessKeyID
+	}
+
+	scope := sig.date + "/" + sig.region + "/" + sig.service + "/aws4_request"
+	signingKey := hmacSHA256([]byte("AWS4"+accessKey.SecretKey), sig.date)
+	signingKey = hmacSHA256(signingKey, sig.region)
+	signingKey = hmacSHA256(signingKey, sig.service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		canonicalQuery(r.URL.Query(), sig.presigned),
+		canonicalHeaders(r, sig.signedHeaders),
+		strings.Join(sig.signedHeaders, ";"),
+		sig.payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		sig.amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(sig.signature)) != 1 {
+		return nil, errSignatureDoesNotMatch
+	}
+
+	requester := types.UserMember(user.Name)
+	if user.Role == types.RoleAdmin {
+		requester = types.AdminMember
+	}
+	return &credential{
+		user:        user,
+		requester:   requester,
+		signingKey:  signingKey,
+		amzDate:     sig.amzDate,
+		scope:       scope,
+		signature:   sig.signature,
+		payloadHash: sig.payloadHash,
+	}, nil
+}
+
+func parseSignature(r *http.Request) (*signature, error) {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != "" {
+		return parsePresignedQuery(query)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, errMissingAuth
+	}
+	if !strings.HasPrefix(authorization, signV4Algorithm+" ") {
+		return nil, errAuthHeaderMalformed
+	}
+
+	sig := &signature{
+		amzDate:     r.Header.Get("X-Amz-Date"),
+		payloadHash: r.Header.Get("X-Amz-Content-Sha256"),
+	}
+	for _, field := range strings.Split(strings.TrimPrefix(authorization, signV4Algorithm+" "), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, errAuthHeaderMalformed
+		}
+		switch name {
+		case "Credential":
+			err := sig.parseCredential(value)
+			if err != nil {
+				return nil, err
+			}
+		case "SignedHeaders":
+			sig.signedHeaders = strings.Split(value, ";")
+		case "Signature":
+			sig.signature = value
+		}
+	}
+	if sig.accessKeyID == "" || len(sig.signedHeaders) == 0 || sig.signature == "" || sig.amzDate == "" {
+		return nil, errAuthHeaderMalformed
+	}
+	if sig.payloadHash == "" {
+		// S3 requires the header, it can only be omitted when request has no body
+		if r.ContentLength != 0 {
+			return nil, errMissingContentSHA256
+		}
+		sig.payloadHash = emptySHA256
+	}
+
+	return sig, nil
+}
+
+func parsePresignedQuery(query url.Values) (*signature, error) {
+	if query.Get("X-Amz-Algorithm") != signV4Algorithm {
+		return nil, errAuthHeaderMalformed
+	}
+
+	sig := &signature{
+		signedHeaders: strings.Split(query.Get("X-Amz-SignedHeaders"), ";"),
+		signature:     query.Get("X-Amz-Signature"),
+		amzDate:       query.Get("X-Amz-Date"),
+		payloadHash:   unsignedPayload,
+		presigned:     true,
+	}
+	err := sig.parseCredential(query.Get("X-Amz-Credential"))
+	if err != nil {
+		return nil, err
+	}
+	if payloadHash := query.Get("X-Amz-Content-Sha256"); payloadHash != "" {
+		sig.payloadHash = payloadHash
+	}
+
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > maxPresignTime {
+		return nil, errAuthHeaderMalformed
+	}
+	sig.expires = time.Duration(expires) * time.Second
+
+	if sig.signature == "" || sig.amzDate == "" || query.Get("X-Amz-SignedHeaders") == "" {
+		return nil, errAuthHeaderMalformed
+	}
+	return sig, nil
+}
+
+// parseCredential parses "<access key ID>/<date>/<region>/s3/aws4_request", region is not checked
+func (sig *signature) parseCredential(value string) error {
+	parts := strings.Split(value, "/")
+	if len(parts) != 5 || parts[3] != "s3" || parts[4] != "aws4_request" {
+		return errAuthHeaderMalformed
+	}
+	sig.accessKeyID = parts[0]
+	sig.date = parts[1]
+	sig.region = parts[2]
+	sig.service = parts[3]
+	return nil
+}
+
+func canonicalQuery(query url.Values, presigned bool) string {
+	params := []string{}
+	for key, values := range query {
+		if presigned && key == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func canonicalHeaders(r *http.Request, signedHeaders []string) string {
+	builder := strings.Builder{}
+	for _, name := range signedHeaders {
+		values := []string{}
+		switch name {
+		case "host":
+			values = append(values, r.Host)
+		case "content-length":
+			values = append(values, strconv.FormatInt(r.ContentLength, 10))
+		default:
+			for _, value := range r.Header.Values(name) {
+				values = append(values, strings.Join(strings.Fields(value), " "))
+			}
+		}
+		builder.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+	return builder.String()
+}
+
+// uriEncode encodes all characters except unreserved characters as signature version 4 does
+func uriEncode(value string, encodeSlash bool) string {
+	builder := strings.Builder{}
+	for _, b := range []byte(value) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' || (b == '/' && !encodeSlash) {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{b})))
+		}
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// payload returns body of request which is verified with payload hash of signature while it is read.
+// aws-chunked body is decoded, and signatures of its chunks are verified.
+func (c *credential) payload(r *http.Request) io.Reader {
+	switch c.payloadHash {
+	case unsignedPayload:
+		return r.Body
+	case streamingPayload, streamingPayloadTrailer:
+		return &chunkedReader{reader: bufio.NewReader(r.Body), credential: c, previous: c.signature}
+	case streamingUnsignedTrailer:
+		return &chunkedReader{reader: bufio.NewReader(r.Body)}
+	default:
+		return &hashReader{reader: r.Body, hash: sha256.New(), expected: c.payloadHash}
+	}
+}
+
+// hashReader returns error at the end of body if SHA-256 of body is not expected one
+type hashReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func (hr *hashReader) Read(p []byte) (int, error) {
+	n, err := hr.reader.Read(p)
+	hr.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(hr.hash.Sum(nil)) != hr.expected {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+// chunkedReader decodes aws-chunked body ("<hex size>[;chunk-signature=<signature>]\r\n<data>\r\n" ... "0...\r\n<trailers>\r\n").
+// Signature of each chunk is verified when credential is set, trailing checksums are ignored.
+type chunkedReader struct {
+	reader     *bufio.Reader
+	credential *credential
+	previous   string
+
+	remaining int64
+	signature string
+	hash      hash.Hash
+	done      bool
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	if cr.done {
+		return 0, io.EOF
+	}
+	if cr.remaining == 0 {
+		err := cr.readChunkHeader()
+		if err != nil {
+			return 0, err
+		}
+		if cr.done {
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err := cr.reader.Read(p)
+	cr.remaining -= int64(n)
+	if cr.hash != nil {
+		cr.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return n, err
+	}
+
+	if cr.remaining == 0 {
+		err = cr.endChunk()
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (cr *chunkedReader) readChunkHeader() error {
+	line, err := cr.readLine()
+	if err != nil {
+		return err
+	}
+	sizeHex, extension, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil || size < 0 {
+		return errors.New("malformed chunk size")
+	}
+	cr.remaining = size
+	cr.signature = strings.TrimPrefix(extension, "chunk-signature=")
+	if cr.credential != nil {
+		cr.hash = sha256.New()
+	}
+
+	if size > 0 {
+		return nil
+	}
+
+	// last chunk has no data, it is followed by trailers and empty line
+	err = cr.verifyChunk()
+	if err != nil {
+		return err
+	}
+	for {
+		line, err := cr.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			break
+		}
+	}
+	cr.done = true
+	return nil
+}
+
+func (cr *chunkedReader) endChunk() error {
+	line, err := cr.readLine()
+	if err != nil {
+		return err
+	}
+	if line != "" {
+		return errors.New("malformed chunk end")
+	}
+	return cr.verifyChunk()
+}
+
+func (cr *chunkedReader) verifyChunk() error {
+	if cr.credential == nil {
+		return nil
+	}
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-PAYLOAD",
+		cr.credential.amzDate,
+		cr.credential.scope,
+		cr.previous,
+		emptySHA256,
+		hex.EncodeToString(cr.hash.Sum(nil)),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(cr.credential.signingKey, stringToSign))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(cr.signature)) != 1 {
+		return errSignatureDoesNotMatch
+	}
+	cr.previous = cr.signature
+	return nil
+}
+
+func (cr *chunkedReader) readLine() (string, error) {
+	line, err := cr.reader.ReadString('\n')
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
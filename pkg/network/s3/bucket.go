@@ -0,0 +1,351 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const maxListKeys = 1000
+
+// listBuckets lists root directories which requester can read
+func (h *Handler) listBuckets(ctx context.Context, w http.ResponseWriter, cred *credential) error {
+	rootDirList, err := h.syncService.GetRootDirList(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := &listAllMyBucketsResult{
+		Xmlns:   xmlns,
+		Owner:   owner{ID: cred.user.Name, DisplayName: cred.user.Name},
+		Buckets: []bucket{},
+	}
+	for _, rootDirPath := range rootDirList.RootDirList {
+		rootDir, err := h.syncService.GetRootDirByPath(ctx, rootDirPath)
+		if err != nil || getRole(cred, rootDir) == "" {
+			continue
+		}
+		// creation time of root directory is not stored
+		result.Buckets = append(result.Buckets, bucket{Name: strings.TrimPrefix(rootDir.AfterPath, "/")})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Name < result.Buckets[j].Name
+	})
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// getBucketLocation returns empty location (us-east-1), region of signature is not checked
+func (h *Handler) getBucketLocation(ctx context.Context, w http.ResponseWriter, cred *credential, bucketName string) error {
+	_, err := h.getBucket(ctx, cred, bucketName, false)
+	if err != nil {
+		return err
+	}
+
+	writeXML(w, http.StatusOK, &locationConstraint{Xmlns: xmlns})
+	return nil
+}
+
+// listObjects lists latest files of root directory as ListObjects (v1) or ListObjectsV2 (v2)
+func (h *Handler) listObjects(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, v2 bool) error {
+	rootDir, err := h.getBucket(ctx, cred, bucketName, false)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+	if err != nil {
+		return err
+	}
+	if encodingType != "" && encodingType != "url" {
+		return errInvalidArgument
+	}
+	encode := func(value string) string {
+		if encodingType == "url" {
+			return url.QueryEscape(value)
+		}
+		return value
+	}
+
+	result := &listBucketResult{
+		Xmlns:        xmlns,
+		Name:         bucketName,
+		Prefix:       encode(prefix),
+		Delimiter:    encode(delimiter),
+		MaxKeys:      maxKeys,
+		EncodingType: encodingType,
+		Contents:     []object{},
+	}
+
+	// marker is the last key or common prefix which is listed in previous page
+	marker := ""
+	if v2 {
+		continuationToken := query.Get("continuation-token")
+		if continuationToken != "" {
+			decoded, err := base64.RawURLEncoding.DecodeString(continuationToken)
+			if err != nil {
+				return errInvalidArgument
+			}
+			marker = string(decoded)
+			result.ContinuationToken = continuationToken
+		} else {
+			marker = query.Get("start-after")
+			result.StartAfter = encode(marker)
+		}
+	} else {
+		marker = query.Get("marker")
+		encoded := encode(marker)
+		result.Marker = &encoded
+	}
+
+	count := 0
+	last := ""
+	lastPrefix := ""
+	for _, file := range h.getObjects(ctx, rootDir, prefix) {
+		key := toKey(rootDir, file.AfterPath)
+		if key <= marker {
+			continue
+		}
+
+		rolledUp, ok := rollUp(key, prefix, delimiter)
+		if ok && (rolledUp == lastPrefix || rolledUp <= marker) {
+			continue
+		}
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		count++
+
+		if ok {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{encode(rolledUp)})
+			lastPrefix = rolledUp
+			last = rolledUp
+			continue
+		}
+		result.Contents = append(result.Contents, object{
+			Key:          encode(key),
+			LastModified: s3Time(file.Metadata.ModTime),
+			ETag:         "\"" + file.LatestHash + "\"",
+			Size:         file.Metadata.Size,
+			StorageClass: "STANDARD",
+		})
+		last = key
+	}
+
+	if v2 {
+		result.KeyCount = &count
+		if result.IsTruncated {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+		}
+	} else if result.IsTruncated {
+		result.NextMarker = encode(last)
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// listObjectVersions lists histories of files as versions, version ID is the timestamp of history.
+// History of removed file is listed as delete marker.
+func (h *Handler) listObjectVersions(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string) error {
+	rootDir, err := h.getBucket(ctx, cred, bucketName, false)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	keyMarker := query.Get("key-marker")
+	versionIDMarker := query.Get("version-id-marker")
+	maxKeys, err := parseMaxKeys(query.Get("max-keys"))
+	if err != nil {
+		return err
+	}
+	if encodingType != "" && encodingType != "url" {
+		return errInvalidArgument
+	}
+	encode := func(value string) string {
+		if encodingType == "url" {
+			return url.QueryEscape(value)
+		}
+		return value
+	}
+
+	// versions after version-id-marker are older versions of key-marker
+	var versionMarker uint64
+	if versionIDMarker != "" {
+		versionMarker, err = strconv.ParseUint(versionIDMarker, 10, 64)
+		if err != nil || keyMarker == "" {
+			return errInvalidArgument
+		}
+	}
+
+	result := &listVersionsResult{
+		Xmlns:           xmlns,
+		Name:            bucketName,
+		Prefix:          encode(prefix),
+		Delimiter:       encode(delimiter),
+		KeyMarker:       encode(keyMarker),
+		VersionIdMarker: versionIDMarker,
+		MaxKeys:         maxKeys,
+		EncodingType:    encodingType,
+		Entries:         []versionEntry{},
+	}
+
+	files := []types.File{}
+	for _, file := range h.syncService.GetFilesByRootDir(ctx, rootDir.AfterPath) {
+		if strings.HasPrefix(file.AfterPath, rootDir.AfterPath+"/"+prefix) {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].AfterPath < files[j].AfterPath
+	})
+
+	count := 0
+	lastPrefix := ""
+	nextKeyMarker := ""
+	nextVersionIDMarker := ""
+	for _, file := range files {
+		if result.IsTruncated {
+			break
+		}
+		key := toKey(rootDir, file.AfterPath)
+		if key < keyMarker || (key == keyMarker && versionIDMarker == "") {
+			continue
+		}
+
+		rolledUp, ok := rollUp(key, prefix, delimiter)
+		if ok {
+			if rolledUp == lastPrefix || rolledUp <= keyMarker {
+				continue
+			}
+			if count == maxKeys {
+				result.IsTruncated = true
+				break
+			}
+			count++
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{encode(rolledUp)})
+			lastPrefix = rolledUp
+			nextKeyMarker = rolledUp
+			nextVersionIDMarker = ""
+			continue
+		}
+
+		histories, err := h.historyService.GetFileHistories(ctx, file.AfterPath)
+		if err != nil {
+			return err
+		}
+		for i := len(histories) - 1; i >= 0; i-- {
+			history := histories[i]
+			if key == keyMarker && history.Timestamp >= versionMarker {
+				continue
+			}
+			if count == maxKeys {
+				result.IsTruncated = true
+				break
+			}
+			count++
+
+			entry := versionEntry{
+				Key:          encode(key),
+				VersionId:    strconv.FormatUint(history.Timestamp, 10),
+				IsLatest:     history.Timestamp == file.LatestSyncTimestamp,
+				LastModified: s3Time(historyTime(&history)),
+			}
+			if history.Hash == "" {
+				entry.XMLName.Local = "DeleteMarker"
+			} else {
+				size := history.File.Size
+				entry.XMLName.Local = "Version"
+				entry.ETag = "\"" + history.Hash + "\""
+				entry.Size = &size
+				entry.StorageClass = "STANDARD"
+			}
+			result.Entries = append(result.Entries, entry)
+			nextKeyMarker = key
+			nextVersionIDMarker = entry.VersionId
+		}
+	}
+
+	if result.IsTruncated {
+		result.NextKeyMarker = encode(nextKeyMarker)
+		result.NextVersionIdMarker = nextVersionIDMarker
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// getObjects returns existing files of root directory whose keys start with prefix, sorted by key
+func (h *Handler) getObjects(ctx context.Context, rootDir *types.RootDirectory, prefix string) []types.File {
+	files := []types.File{}
+	for _, file := range h.syncService.GetFilesByRootDir(ctx, rootDir.AfterPath) {
+		if file.LatestHash != "" && strings.HasPrefix(file.AfterPath, rootDir.AfterPath+"/"+prefix) {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].AfterPath < files[j].AfterPath
+	})
+	return files
+}
+
+// rollUp returns common prefix of key, which is the part of key until the first delimiter after prefix
+func rollUp(key string, prefix string, delimiter string) (string, bool) {
+	if delimiter == "" {
+		return "", false
+	}
+	i := strings.Index(key[len(prefix):], delimiter)
+	if i < 0 {
+		return "", false
+	}
+	return key[:len(prefix)+i+len(delimiter)], true
+}
+
+func toKey(rootDir *types.RootDirectory, afterPath string) string {
+	return strings.TrimPrefix(afterPath, rootDir.AfterPath+"/")
+}
+
+func parseMaxKeys(value string) (int, error) {
+	if value == "" {
+		return maxListKeys, nil
+	}
+	maxKeys, err := strconv.Atoi(value)
+	if err != nil || maxKeys < 0 {
+		return 0, errInvalidArgument
+	}
+	if maxKeys > maxListKeys {
+		maxKeys = maxListKeys
+	}
+	return maxKeys, nil
+}
+
+// historyTime returns modification time of history, or the time when it is saved if it is removed file
+func historyTime(history *types.FileHistory) time.Time {
+	if !history.File.ModTime.IsZero() {
+		return history.File.ModTime
+	}
+	// Date is written with time.Time.String(), which has monotonic clock reading at the end
+	date, _, _ := strings.Cut(history.Date, " m=")
+	t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", date)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,207 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/history"
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+// Handler serves S3-compatible API with path-style addressing, each root directory is a bucket and keys are paths in it.
+// Requests are signed with signature version 4 by access keys of user accounts, and users access root directories
+// with their roles. Objects are written, removed and moved with sync service, so that changes have history
+// and clients receive MUSTSYNC, and versions are listed from file history.
+type Handler struct {
+	syncService    sync.Service
+	historyService history.Service
+	userService    user.Service
+	syncDirAdapter sync.SyncDirAdapter
+	uploadDir      string
+}
+
+// NewHandler creates S3 handler, parts of multipart uploads are kept in uploadDir until they are completed
+func NewHandler(syncService sync.Service, historyService history.Service, userService user.Service, syncDirAdapter sync.SyncDirAdapter, uploadDir string) *Handler {
+	return &Handler{
+		syncService:    syncService,
+		historyService: historyService,
+		userService:    userService,
+		syncDirAdapter: syncDirAdapter,
+		uploadDir:      uploadDir,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := audit.WithSourceIP(r.Context(), utils.GetIPFromHostPort(r.RemoteAddr))
+
+	cred, err := h.authenticate(ctx, r)
+	if err != nil {
+		log.Println("quics err: [S3] ", r.Method, " ", r.URL.Path, " from ", utils.GetIPFromHostPort(r.RemoteAddr), ": ", err)
+		writeError(w, r, err)
+		return
+	}
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case bucketName == "":
+		if r.Method != http.MethodGet {
+			err = errNotImplemented
+			break
+		}
+		err = h.listBuckets(ctx, w, cred)
+	case key == "":
+		err = h.serveBucket(ctx, w, r, cred, bucketName, query)
+	default:
+		err = h.serveObject(ctx, w, r, cred, bucketName, key, query)
+	}
+	if err != nil {
+		writeError(w, r, err)
+	}
+}
+
+func (h *Handler) serveBucket(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, query url.Values) error {
+	switch r.Method {
+	case http.MethodGet:
+		switch {
+		case query.Has("location"):
+			return h.getBucketLocation(ctx, w, cred, bucketName)
+		case query.Has("versions"):
+			return h.listObjectVersions(ctx, w, r, cred, bucketName)
+		case query.Has("uploads"):
+			return errNotImplemented
+		case query.Get("list-type") == "2":
+			return h.listObjects(ctx, w, r, cred, bucketName, true)
+		default:
+			return h.listObjects(ctx, w, r, cred, bucketName, false)
+		}
+	case http.MethodHead:
+		_, err := h.getBucket(ctx, cred, bucketName, false)
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodPost:
+		if query.Has("delete") {
+			return h.deleteObjects(ctx, w, r, cred, bucketName)
+		}
+		return errNotImplemented
+	default:
+		// buckets are root directories which are registered by clients
+		return errNotImplemented
+	}
+}
+
+func (h *Handler) serveObject(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string, query url.Values) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if r.Method == http.MethodGet && query.Has("uploadId") {
+			return h.listParts(ctx, w, r, cred, bucketName, key)
+		}
+		return h.getObject(ctx, w, r, cred, bucketName, key)
+	case http.MethodPut:
+		switch {
+		case query.Has("uploadId"):
+			return h.uploadPart(ctx, w, r, cred, bucketName, key)
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			return h.copyObject(ctx, w, r, cred, bucketName, key)
+		default:
+			return h.putObject(ctx, w, r, cred, bucketName, key)
+		}
+	case http.MethodPost:
+		switch {
+		case query.Has("uploads"):
+			return h.createMultipartUpload(ctx, w, cred, bucketName, key)
+		case query.Has("uploadId"):
+			return h.completeMultipartUpload(ctx, w, r, cred, bucketName, key)
+		default:
+			return errNotImplemented
+		}
+	case http.MethodDelete:
+		if query.Has("uploadId") {
+			return h.abortMultipartUpload(ctx, w, r, cred, bucketName, key)
+		}
+		return h.deleteObject(ctx, w, r, cred, bucketName, key)
+	default:
+		return errNotImplemented
+	}
+}
+
+// getBucket returns root directory of bucket which requester can read (or write if write is true).
+// Root directory which requester can not read is not shown as if it does not exist.
+func (h *Handler) getBucket(ctx context.Context, cred *credential, bucketName string, write bool) (*types.RootDirectory, error) {
+	if bucketName == "" || strings.ContainsAny(bucketName, "/\x00") {
+		return nil, errNoSuchBucket
+	}
+	rootDir, err := h.syncService.GetRootDirByPath(ctx, "/"+bucketName)
+	if err != nil {
+		return nil, errNoSuchBucket
+	}
+
+	role := getRole(cred, rootDir)
+	if role == "" {
+		return nil, errNoSuchBucket
+	}
+	if write && !types.CanWrite(role) {
+		return nil, errAccessDenied
+	}
+	return rootDir, nil
+}
+
+// getRole returns the role of requester in root directory, admin is owner of all root directories
+func getRole(cred *credential, rootDir *types.RootDirectory) string {
+	if cred.requester == types.AdminMember {
+		return types.RootRoleOwner
+	}
+	return rootDir.GetRole("", cred.user.Name)
+}
+
+// toAfterPath returns path of object, key must be clean relative path of file
+func toAfterPath(rootDir *types.RootDirectory, key string) (string, error) {
+	if strings.ContainsRune(key, 0) || strings.HasSuffix(key, "/") || path.Clean("/"+key) != "/"+key {
+		return "", errInvalidKey
+	}
+	return rootDir.AfterPath + "/" + key, nil
+}
+
+func writeXML(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	err := xml.NewEncoder(w).Encode(data)
+	if err != nil {
+		log.Println("quics err: [S3] encode response: ", err)
+	}
+}
+
+// writeError writes S3 error response, errors which are not S3 errors are written as internal error
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	s3Err := &s3Error{}
+	if !errors.As(err, &s3Err) {
+		log.Println("quics err: [S3] ", r.Method, " ", r.URL.Path, ": ", err)
+		s3Err = errInternalError
+	}
+
+	// response of HEAD has no body
+	if r.Method == http.MethodHead {
+		w.WriteHeader(s3Err.Status)
+		return
+	}
+	writeXML(w, s3Err.Status, &errorResponse{
+		Code:     s3Err.Code,
+		Message:  s3Err.Message,
+		Resource: r.URL.Path,
+	})
+}
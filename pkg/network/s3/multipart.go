@@ -0,0 +1,352 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxPartNumber    = 10000
+	uploadInfoName   = "upload.json"
+	partNamePrefix   = "part-"
+	uploadIDByteSize = 16
+)
+
+// multipartUpload is stored as upload.json in the directory of upload, parts are stored as part-<number> next to it
+type multipartUpload struct {
+	Bucket    string
+	Key       string
+	Requester string
+	Initiated time.Time
+}
+
+// createMultipartUpload creates directory of upload, the file is committed when upload is completed
+func (h *Handler) createMultipartUpload(ctx context.Context, w http.ResponseWriter, cred *credential, bucketName string, key string) error {
+	_, err := h.getWritablePath(ctx, cred, bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, uploadIDByteSize)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return err
+	}
+	uploadID := hex.EncodeToString(buf)
+
+	uploadInfo, err := json.Marshal(&multipartUpload{
+		Bucket:    bucketName,
+		Key:       key,
+		Requester: cred.requester,
+		Initiated: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(h.uploadPath(uploadID), 0700)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(h.uploadPath(uploadID), uploadInfoName), uploadInfo, 0600)
+	if err != nil {
+		return err
+	}
+
+	writeXML(w, http.StatusOK, &initiateMultipartUploadResult{
+		Xmlns:    xmlns,
+		Bucket:   bucketName,
+		Key:      key,
+		UploadId: uploadID,
+	})
+	return nil
+}
+
+// uploadPart saves part of upload, part which has the same number is overwritten
+func (h *Handler) uploadPart(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	uploadID := r.URL.Query().Get("uploadId")
+	_, err := h.getUpload(ctx, cred, bucketName, key, uploadID)
+	if err != nil {
+		return err
+	}
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		return errInvalidArgument
+	}
+	// UploadPartCopy is not supported
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return errNotImplemented
+	}
+
+	tempFile, err := os.CreateTemp(h.uploadPath(uploadID), "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	md5Hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tempFile, md5Hash), cred.payload(r))
+	if err != nil {
+		return toBodyError(err)
+	}
+	err = verifyContentMD5(r, md5Hash)
+	if err != nil {
+		return err
+	}
+	err = tempFile.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tempFile.Name(), h.partPath(uploadID, partNumber))
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("ETag", "\""+hex.EncodeToString(md5Hash.Sum(nil))+"\"")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// completeMultipartUpload concatenates parts in request body and commits them as new version of file
+func (h *Handler) completeMultipartUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	uploadID := r.URL.Query().Get("uploadId")
+	_, err := h.getUpload(ctx, cred, bucketName, key, uploadID)
+	if err != nil {
+		return err
+	}
+	afterPath, err := h.getWritablePath(ctx, cred, bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(cred.payload(r), 1<<20))
+	if err != nil {
+		return toBodyError(err)
+	}
+	request := &completeMultipartUpload{}
+	err = xml.Unmarshal(body, request)
+	if err != nil || len(request.Parts) == 0 {
+		return errMalformedXML
+	}
+	for i, part := range request.Parts {
+		if i > 0 && part.PartNumber <= request.Parts[i-1].PartNumber {
+			return errInvalidPartOrder
+		}
+	}
+
+	tempFile, err := os.CreateTemp(h.uploadPath(uploadID), "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	// ETag of each part is MD5 of the part, which is checked while parts are concatenated
+	for _, part := range request.Parts {
+		partFile, err := os.Open(h.partPath(uploadID, part.PartNumber))
+		if os.IsNotExist(err) {
+			return errInvalidPart
+		}
+		if err != nil {
+			return err
+		}
+		md5Hash := md5.New()
+		_, err = io.Copy(io.MultiWriter(tempFile, md5Hash), partFile)
+		partFile.Close()
+		if err != nil {
+			return err
+		}
+		if strings.Trim(part.ETag, "\"") != hex.EncodeToString(md5Hash.Sum(nil)) {
+			return errInvalidPart
+		}
+	}
+
+	file, err := h.commit(ctx, cred, afterPath, tempFile)
+	if err != nil {
+		return err
+	}
+	tempFile.Close()
+	err = os.RemoveAll(h.uploadPath(uploadID))
+	if err != nil {
+		log.Println("quics err: [S3] remove multipart upload: ", err)
+	}
+
+	w.Header().Set("X-Amz-Version-Id", strconv.FormatUint(file.LatestSyncTimestamp, 10))
+	writeXML(w, http.StatusOK, &completeMultipartUploadResult{
+		Xmlns:    xmlns,
+		Location: "/" + bucketName + "/" + key,
+		Bucket:   bucketName,
+		Key:      key,
+		ETag:     "\"" + file.LatestHash + "\"",
+	})
+	return nil
+}
+
+// abortMultipartUpload removes upload and its parts
+func (h *Handler) abortMultipartUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	uploadID := r.URL.Query().Get("uploadId")
+	_, err := h.getUpload(ctx, cred, bucketName, key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(h.uploadPath(uploadID))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listParts lists uploaded parts in the order of part number
+func (h *Handler) listParts(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	_, err := h.getUpload(ctx, cred, bucketName, key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	maxParts := maxListKeys
+	if value := query.Get("max-parts"); value != "" {
+		maxParts, err = strconv.Atoi(value)
+		if err != nil || maxParts < 0 {
+			return errInvalidArgument
+		}
+		if maxParts > maxListKeys {
+			maxParts = maxListKeys
+		}
+	}
+	partNumberMarker := 0
+	if value := query.Get("part-number-marker"); value != "" {
+		partNumberMarker, err = strconv.Atoi(value)
+		if err != nil {
+			return errInvalidArgument
+		}
+	}
+
+	entries, err := os.ReadDir(h.uploadPath(uploadID))
+	if err != nil {
+		return err
+	}
+	partNumbers := []int{}
+	for _, entry := range entries {
+		partNumber, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), partNamePrefix))
+		if err != nil || !strings.HasPrefix(entry.Name(), partNamePrefix) || partNumber <= partNumberMarker {
+			continue
+		}
+		partNumbers = append(partNumbers, partNumber)
+	}
+	sort.Ints(partNumbers)
+
+	result := &listPartsResult{
+		Xmlns:            xmlns,
+		Bucket:           bucketName,
+		Key:              key,
+		UploadId:         uploadID,
+		PartNumberMarker: partNumberMarker,
+		MaxParts:         maxParts,
+		Parts:            []part{},
+	}
+	for _, partNumber := range partNumbers {
+		if len(result.Parts) == maxParts {
+			result.IsTruncated = true
+			break
+		}
+		partFile, err := os.Open(h.partPath(uploadID, partNumber))
+		if err != nil {
+			return err
+		}
+		md5Hash := md5.New()
+		_, err = io.Copy(md5Hash, partFile)
+		info, statErr := partFile.Stat()
+		partFile.Close()
+		if err != nil {
+			return err
+		}
+		if statErr != nil {
+			return statErr
+		}
+
+		result.Parts = append(result.Parts, part{
+			PartNumber:   partNumber,
+			LastModified: s3Time(info.ModTime()),
+			ETag:         "\"" + hex.EncodeToString(md5Hash.Sum(nil)) + "\"",
+			Size:         info.Size(),
+		})
+		result.NextPartNumberMarker = partNumber
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// CleanupUploads removes multipart uploads which are not completed nor aborted for maxAge
+func (h *Handler) CleanupUploads(maxAge time.Duration) error {
+	entries, err := os.ReadDir(h.uploadDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.New("[S3.CleanupUploads] read upload directory: " + err.Error())
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		err = os.RemoveAll(filepath.Join(h.uploadDir, entry.Name()))
+		if err != nil {
+			log.Println("quics err: [S3] remove multipart upload: ", err)
+		}
+	}
+	return nil
+}
+
+// getUpload reads upload which is created for the object by the same requester
+func (h *Handler) getUpload(ctx context.Context, cred *credential, bucketName string, key string, uploadID string) (*multipartUpload, error) {
+	_, err := h.getBucket(ctx, cred, bucketName, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := hex.DecodeString(uploadID); err != nil || len(uploadID) != uploadIDByteSize*2 {
+		return nil, errNoSuchUpload
+	}
+
+	uploadInfo, err := os.ReadFile(filepath.Join(h.uploadPath(uploadID), uploadInfoName))
+	if err != nil {
+		return nil, errNoSuchUpload
+	}
+	upload := &multipartUpload{}
+	err = json.Unmarshal(uploadInfo, upload)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Bucket != bucketName || upload.Key != key || upload.Requester != cred.requester {
+		return nil, errNoSuchUpload
+	}
+	return upload, nil
+}
+
+func (h *Handler) uploadPath(uploadID string) string {
+	return filepath.Join(h.uploadDir, uploadID)
+}
+
+func (h *Handler) partPath(uploadID string, partNumber int) string {
+	return filepath.Join(h.uploadPath(uploadID), partNamePrefix+strconv.Itoa(partNumber))
+}
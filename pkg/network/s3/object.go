@@ -0,0 +1,370 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const maxDeleteObjects = 1000
+
+// objectContent is contents of latest file or its version in history
+type objectContent struct {
+	afterPath string
+	versionID string
+	etag      string
+	modTime   time.Time
+	content   io.Reader
+}
+
+func (o *objectContent) Close() error {
+	if closer, ok := o.content.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// getObject writes contents of object with Range and conditional request support, versionId reads version in history
+func (h *Handler) getObject(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	object, err := h.openObject(ctx, cred, bucketName, key, r.URL.Query().Get("versionId"), w)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	readSeeker, ok := object.content.(io.ReadSeeker)
+	if !ok {
+		return errors.New("[S3] content of object can not be seeked")
+	}
+	w.Header().Set("ETag", object.etag)
+	w.Header().Set("X-Amz-Version-Id", object.versionID)
+	http.ServeContent(w, r, path.Base(object.afterPath), object.modTime, readSeeker)
+	return nil
+}
+
+// putObject commits body of request as new version of file
+func (h *Handler) putObject(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	afterPath, err := h.getWritablePath(ctx, cred, bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := h.createTemp("put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	md5Hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tempFile, md5Hash), cred.payload(r))
+	if err != nil {
+		return toBodyError(err)
+	}
+	err = verifyContentMD5(r, md5Hash)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.commit(ctx, cred, afterPath, tempFile)
+	if err != nil {
+		return err
+	}
+
+	// ETag of PUT response is MD5 of body, which clients compare with the uploaded data
+	w.Header().Set("ETag", "\""+hex.EncodeToString(md5Hash.Sum(nil))+"\"")
+	w.Header().Set("X-Amz-Version-Id", strconv.FormatUint(file.LatestSyncTimestamp, 10))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// copyObject commits contents of x-amz-copy-source as new version of file, metadata directive is ignored
+func (h *Handler) copyObject(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	copySource, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return errInvalidArgument
+	}
+	copySource, versionID, _ := strings.Cut(copySource, "?versionId=")
+	sourceBucket, sourceKey, ok := strings.Cut(strings.TrimPrefix(copySource, "/"), "/")
+	if !ok {
+		return errInvalidArgument
+	}
+
+	afterPath, err := h.getWritablePath(ctx, cred, bucketName, key)
+	if err != nil {
+		return err
+	}
+	source, err := h.openObject(ctx, cred, sourceBucket, sourceKey, versionID, w)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	tempFile, err := h.createTemp("put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = io.Copy(tempFile, source.content)
+	if err != nil {
+		return err
+	}
+	file, err := h.commit(ctx, cred, afterPath, tempFile)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("X-Amz-Copy-Source-Version-Id", source.versionID)
+	w.Header().Set("X-Amz-Version-Id", strconv.FormatUint(file.LatestSyncTimestamp, 10))
+	writeXML(w, http.StatusOK, &copyObjectResult{
+		Xmlns:        xmlns,
+		LastModified: s3Time(file.Metadata.ModTime),
+		ETag:         "\"" + file.LatestHash + "\"",
+	})
+	return nil
+}
+
+// deleteObject removes file as new version, removing object which does not exist succeeds as S3 does
+func (h *Handler) deleteObject(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string, key string) error {
+	// versions are history of file, they are not removed one by one
+	if r.URL.Query().Get("versionId") != "" {
+		return errNotImplemented
+	}
+
+	afterPath, err := h.getWritablePath(ctx, cred, bucketName, key)
+	if err != nil {
+		return err
+	}
+	file, err := h.removeObject(ctx, cred, afterPath)
+	if err != nil {
+		return err
+	}
+
+	if file != nil {
+		w.Header().Set("X-Amz-Delete-Marker", "true")
+		w.Header().Set("X-Amz-Version-Id", strconv.FormatUint(file.LatestSyncTimestamp, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// deleteObjects removes objects in request body (DeleteObjects), result of each object is written in response
+func (h *Handler) deleteObjects(ctx context.Context, w http.ResponseWriter, r *http.Request, cred *credential, bucketName string) error {
+	rootDir, err := h.getBucket(ctx, cred, bucketName, true)
+	if err != nil {
+		return err
+	}
+
+	md5Hash := md5.New()
+	body, err := io.ReadAll(io.TeeReader(io.LimitReader(cred.payload(r), 1<<20), md5Hash))
+	if err != nil {
+		return toBodyError(err)
+	}
+	err = verifyContentMD5(r, md5Hash)
+	if err != nil {
+		return err
+	}
+	request := &deleteRequest{}
+	err = xml.Unmarshal(body, request)
+	if err != nil || len(request.Objects) > maxDeleteObjects {
+		return errMalformedXML
+	}
+
+	result := &deleteResult{Xmlns: xmlns}
+	for _, object := range request.Objects {
+		var err error = errNotImplemented
+		if object.VersionId == "" {
+			var afterPath string
+			afterPath, err = toAfterPath(rootDir, object.Key)
+			if err == nil {
+				_, err = h.removeObject(ctx, cred, afterPath)
+			}
+		}
+
+		if err == nil {
+			if !request.Quiet {
+				result.Deleted = append(result.Deleted, deletedObject{Key: object.Key})
+			}
+			continue
+		}
+		s3Err := &s3Error{}
+		if !errors.As(err, &s3Err) {
+			s3Err = errInternalError
+		}
+		result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: s3Err.Code, Message: s3Err.Message})
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// openObject opens latest file, or its version in history if versionID is not empty.
+// Delete marker is shown with x-amz-delete-marker header as S3 does.
+func (h *Handler) openObject(ctx context.Context, cred *credential, bucketName string, key string, versionID string, w http.ResponseWriter) (*objectContent, error) {
+	rootDir, err := h.getBucket(ctx, cred, bucketName, false)
+	if err != nil {
+		return nil, err
+	}
+	afterPath, err := toAfterPath(rootDir, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if versionID == "" {
+		file, err := h.syncService.GetFileByPath(ctx, afterPath)
+		if err != nil || file.LatestHash == "" {
+			return nil, errNoSuchKey
+		}
+		_, content, err := h.syncDirAdapter.GetFileFromLatestDir(ctx, afterPath)
+		if os.IsNotExist(err) {
+			return nil, errNoSuchKey
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &objectContent{
+			afterPath: afterPath,
+			versionID: strconv.FormatUint(file.LatestSyncTimestamp, 10),
+			etag:      "\"" + file.LatestHash + "\"",
+			modTime:   file.Metadata.ModTime,
+			content:   content,
+		}, nil
+	}
+
+	timestamp, err := strconv.ParseUint(versionID, 10, 64)
+	if err != nil {
+		return nil, errNoSuchVersion
+	}
+	histories, err := h.historyService.GetFileHistories(ctx, afterPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range histories {
+		if history.Timestamp != timestamp {
+			continue
+		}
+		if history.Hash == "" {
+			w.Header().Set("X-Amz-Delete-Marker", "true")
+			w.Header().Set("X-Amz-Version-Id", versionID)
+			return nil, errDeleteMarker
+		}
+		_, content, err := h.syncDirAdapter.GetFileFromHistoryDir(ctx, afterPath, timestamp)
+		if err != nil {
+			return nil, errNoSuchVersion
+		}
+		return &objectContent{
+			afterPath: afterPath,
+			versionID: versionID,
+			etag:      "\"" + history.Hash + "\"",
+			modTime:   history.File.ModTime,
+			content:   content,
+		}, nil
+	}
+	return nil, errNoSuchVersion
+}
+
+// getWritablePath returns path of object which requester can write, conflicted file is resolved by clients
+func (h *Handler) getWritablePath(ctx context.Context, cred *credential, bucketName string, key string) (string, error) {
+	rootDir, err := h.getBucket(ctx, cred, bucketName, true)
+	if err != nil {
+		return "", err
+	}
+	afterPath, err := toAfterPath(rootDir, key)
+	if err != nil {
+		return "", err
+	}
+	if h.isConflicted(ctx, afterPath) {
+		return "", errConflicted
+	}
+	return afterPath, nil
+}
+
+// commit commits temporary file as new version of file with sync service
+func (h *Handler) commit(ctx context.Context, cred *credential, afterPath string, tempFile *os.File) (*types.File, error) {
+	info, err := tempFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tempFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	fileMetadata := &types.FileMetadata{
+		Name:    path.Base(afterPath),
+		Size:    info.Size(),
+		Mode:    0644,
+		ModTime: time.Now(),
+	}
+	return h.syncService.CommitFile(ctx, cred.requester, afterPath, fileMetadata, tempFile)
+}
+
+// removeObject removes file with sync service, it returns nil file if the file does not exist
+func (h *Handler) removeObject(ctx context.Context, cred *credential, afterPath string) (*types.File, error) {
+	if h.isConflicted(ctx, afterPath) {
+		return nil, errConflicted
+	}
+	file, err := h.syncService.DeleteFile(ctx, cred.requester, afterPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return file, err
+}
+
+// isConflicted checks the file has conflict which is not resolved yet
+func (h *Handler) isConflicted(ctx context.Context, afterPath string) bool {
+	file, err := h.syncService.GetFileByPath(ctx, afterPath)
+	return err == nil && !reflect.ValueOf(file.Conflict).IsZero()
+}
+
+// createTemp creates temporary file in upload directory, the caller removes it
+func (h *Handler) createTemp(pattern string) (*os.File, error) {
+	err := os.MkdirAll(h.uploadDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(h.uploadDir, pattern)
+}
+
+// verifyContentMD5 compares Content-MD5 header with MD5 of body, the header is optional
+func verifyContentMD5(r *http.Request, md5Hash hash.Hash) error {
+	contentMD5 := r.Header.Get("Content-MD5")
+	if contentMD5 == "" {
+		return nil
+	}
+	expected, err := base64.StdEncoding.DecodeString(contentMD5)
+	if err != nil || len(expected) != md5.Size {
+		return errInvalidDigest
+	}
+	if !bytes.Equal(expected, md5Hash.Sum(nil)) {
+		return errBadDigest
+	}
+	return nil
+}
+
+// toBodyError returns S3 error of reading body, errors of signature and checksum are returned as they are
+func toBodyError(err error) error {
+	s3Err := &s3Error{}
+	if errors.As(err, &s3Err) {
+		return s3Err
+	}
+	return errIncompleteBody
+}
@@ -0,0 +1,157 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/pkg/sftp"
+	"github.com/quic-s/quics/pkg/network/vfs"
+)
+
+// handler serves sftp requests of one connection with the file system, ctx has the session of the connection
+type handler struct {
+	ctx        context.Context
+	fileSystem *vfs.FileSystem
+}
+
+func newHandlers(ctx context.Context, fileSystem *vfs.FileSystem) sftp.Handlers {
+	h := &handler{
+		ctx:        ctx,
+		fileSystem: fileSystem,
+	}
+	return sftp.Handlers{
+		FileGet:  h,
+		FilePut:  h,
+		FileCmd:  h,
+		FileList: h,
+	}
+}
+
+func (h *handler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	file, err := h.fileSystem.OpenFile(h.ctx, r.Filepath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		file.Close()
+		return nil, sftp.ErrSSHFxFailure
+	}
+	return readerAt, nil
+}
+
+// Filewrite opens file which is committed as new version with sync service when client closes it
+func (h *handler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	flag := os.O_WRONLY | os.O_CREATE
+	if r.Pflags().Trunc {
+		flag |= os.O_TRUNC
+	}
+	file, err := h.fileSystem.OpenFile(h.ctx, r.Filepath, flag, 0644)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	writerAt, ok := file.(io.WriterAt)
+	if !ok {
+		file.Close()
+		return nil, sftp.ErrSSHFxFailure
+	}
+	return writerAt, nil
+}
+
+func (h *handler) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		// mode and times are decided by sync service, so they are ignored (e.g., scp -p)
+		return nil
+	case "Rename", "PosixRename":
+		return toStatusError(h.fileSystem.Rename(h.ctx, r.Filepath, r.Target))
+	case "Mkdir":
+		return toStatusError(h.fileSystem.Mkdir(h.ctx, r.Filepath, 0755))
+	case "Rmdir":
+		info, err := h.fileSystem.Stat(h.ctx, r.Filepath)
+		if err != nil {
+			return toStatusError(err)
+		}
+		if !info.IsDir() {
+			return sftp.ErrSSHFxFailure
+		}
+		entries, err := h.readDir(r.Filepath)
+		if err != nil {
+			return toStatusError(err)
+		}
+		if len(entries) > 0 {
+			return sftp.ErrSSHFxFailure
+		}
+		return toStatusError(h.fileSystem.RemoveAll(h.ctx, r.Filepath))
+	case "Remove":
+		info, err := h.fileSystem.Stat(h.ctx, r.Filepath)
+		if err != nil {
+			return toStatusError(err)
+		}
+		if info.IsDir() {
+			return sftp.ErrSSHFxFailure
+		}
+		return toStatusError(h.fileSystem.RemoveAll(h.ctx, r.Filepath))
+	default:
+		// links are not synchronized
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *handler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		entries, err := h.readDir(r.Filepath)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		return listerAt(entries), nil
+	case "Stat", "Lstat":
+		info, err := h.fileSystem.Stat(h.ctx, r.Filepath)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		return listerAt{info}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *handler) readDir(name string) ([]os.FileInfo, error) {
+	dir, err := h.fileSystem.OpenFile(h.ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	return dir.Readdir(-1)
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(entries []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(entries, l[offset:])
+	if n < len(entries) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// toStatusError converts error of file system to status of sftp, errors which are not known are sent as failure
+func toStatusError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrNotExist):
+		return sftp.ErrSSHFxNoSuchFile
+	case errors.Is(err, os.ErrPermission):
+		return sftp.ErrSSHFxPermissionDenied
+	default:
+		return err
+	}
+}
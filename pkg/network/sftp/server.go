@@ -0,0 +1,176 @@
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"github.com/pkg/sftp"
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/network/vfs"
+	"github.com/quic-s/quics/pkg/utils"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxAuthTries is the number of password attempts on one SSH connection
+const maxAuthTries = 3
+
+// Server is embedded SSH server which serves only sftp subsystem.
+// Users log in with their user account, or with the name and password of root directory
+// to access only the root directory. Root directories are listed in "/".
+type Server struct {
+	syncService    sync.Service
+	userService    user.Service
+	lockoutService lockout.Service
+	fileSystem     *vfs.FileSystem
+	hostKey        ssh.Signer
+	listener       net.Listener
+}
+
+// NewServer creates sftp server with host key in hostKeyPath, host key is generated when it does not exist
+func NewServer(syncService sync.Service, userService user.Service, lockoutService lockout.Service, syncDir string, hostKeyPath string) (*Server, error) {
+	hostKey, err := loadHostKey(hostKeyPath)
+	if err != nil {
+		err = errors.New("[sftp.NewServer] load host key: " + err.Error())
+		return nil, err
+	}
+
+	return &Server{
+		syncService:    syncService,
+		userService:    userService,
+		lockoutService: lockoutService,
+		fileSystem:     vfs.NewFileSystem(syncService, syncDir),
+		hostKey:        hostKey,
+	}, nil
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	// locked out source IP is refused before handshake, so that it can not try passwords on new connections
+	err := s.lockoutService.CheckUser(context.Background(), utils.GetIPFromAddr(conn.RemoteAddr()), "")
+	if err != nil {
+		log.Println("quics err: [SFTP] ", err)
+		return
+	}
+
+	// config is made for each connection to keep the session of authenticated user
+	var ctx context.Context
+	config := &ssh.ServerConfig{
+		// each failure is recorded to lockout, fewer attempts on one connection make its backoff effective
+		MaxAuthTries: maxAuthTries,
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			var err error
+			ctx, err = vfs.Authenticate(context.Background(), s.syncService, s.userService, s.lockoutService, "sftp", utils.GetIPFromAddr(meta.RemoteAddr()), meta.User(), string(password))
+			if err != nil {
+				log.Println("quics err: [SFTP] ", meta.User(), " from ", meta.RemoteAddr(), ": ", err)
+				return nil, err
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(s.hostKey)
+
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	ctx = audit.WithSourceIP(ctx, utils.GetIPFromAddr(sshConn.RemoteAddr()))
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channel is supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			log.Println("quics err: [SFTP] accept channel: ", err)
+			continue
+		}
+		go s.serveChannel(ctx, channel, requests)
+	}
+}
+
+// serveChannel serves sftp subsystem, shell and exec are not allowed
+func (s *Server) serveChannel(ctx context.Context, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for request := range requests {
+		if request.Type != "subsystem" || len(request.Payload) < 4 || string(request.Payload[4:]) != "sftp" {
+			request.Reply(false, nil)
+			continue
+		}
+		request.Reply(true, nil)
+
+		server := sftp.NewRequestServer(channel, newHandlers(ctx, s.fileSystem))
+		err := server.Serve()
+		if err != nil && err != io.EOF {
+			log.Println("quics err: [SFTP] ", err)
+		}
+		server.Close()
+		return
+	}
+}
+
+// loadHostKey reads ed25519 host key, or generates and saves it so that clients can trust it after restart
+func loadHostKey(hostKeyPath string) (ssh.Signer, error) {
+	keyPEM, err := os.ReadFile(hostKeyPath)
+	if err == nil {
+		return ssh.ParsePrivateKey(keyPEM)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(key, "quics")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(hostKeyPath, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
@@ -0,0 +1,504 @@
+package vfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"golang.org/x/net/webdav"
+)
+
+// FileSystem is webdav.FileSystem of latest files in sync directory, which is shared by WebDAV and SFTP servers.
+// Requester is taken from context returned by Authenticate. Files are read from sync directory, and written, removed and moved with sync service,
+// so that changes have history, conflicted files are not overwritten and clients receive MUSTSYNC.
+// Root directories can not be created, removed nor moved.
+type FileSystem struct {
+	syncService sync.Service
+	syncDir     string
+}
+
+func NewFileSystem(syncService sync.Service, syncDir string) *FileSystem {
+	return &FileSystem{
+		syncService: syncService,
+		syncDir:     syncDir,
+	}
+}
+
+// Mkdir creates directory in sync directory, directories are not synchronized by themselves
+// so that clients receive it with the first file put in it
+func (fsys *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	afterPath, rootDir, err := fsys.resolve(ctx, name, true)
+	if err != nil {
+		return err
+	}
+	if afterPath == rootDir.AfterPath {
+		return os.ErrExist
+	}
+
+	parent, err := fsys.Stat(ctx, path.Dir(afterPath))
+	if err != nil {
+		return err
+	}
+	if !parent.IsDir() {
+		return os.ErrInvalid
+	}
+
+	err = os.MkdirAll(fsys.localPath(rootDir.AfterPath), 0755)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(fsys.localPath(afterPath), 0755)
+}
+
+func (fsys *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if isRoot(name) {
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+			return nil, os.ErrPermission
+		}
+		return fsys.openRoot(ctx)
+	}
+
+	write := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0
+	afterPath, rootDir, err := fsys.resolve(ctx, name, write)
+	if err != nil {
+		return nil, err
+	}
+
+	if write {
+		if afterPath == rootDir.AfterPath {
+			return nil, os.ErrPermission
+		}
+		return fsys.openUpload(ctx, afterPath, flag)
+	}
+
+	file, err := os.Open(fsys.localPath(afterPath))
+	if os.IsNotExist(err) && afterPath == rootDir.AfterPath {
+		// root directory does not exist in sync directory until its first file is synchronized
+		return &dirFile{info: newDirInfo(path.Base(afterPath))}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (fsys *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	if isRoot(name) {
+		return os.ErrPermission
+	}
+	afterPath, rootDir, err := fsys.resolve(ctx, name, true)
+	if err != nil {
+		return err
+	}
+	if afterPath == rootDir.AfterPath {
+		return os.ErrPermission
+	}
+	actor := getSession(ctx).requester
+
+	if fsys.isConflicted(ctx, afterPath) {
+		return os.ErrPermission
+	}
+	_, err = fsys.syncService.DeleteFile(ctx, actor, afterPath)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// remove files in directory, and then empty directories
+	files, err := fsys.getFilesInDir(ctx, rootDir, afterPath)
+	if err != nil {
+		return err
+	}
+	localPath := fsys.localPath(afterPath)
+	if _, err := os.Stat(localPath); os.IsNotExist(err) && len(files) == 0 {
+		return os.ErrNotExist
+	}
+	for _, file := range files {
+		if !reflect.ValueOf(file.Conflict).IsZero() {
+			return os.ErrPermission
+		}
+	}
+	for _, file := range files {
+		_, err = fsys.syncService.DeleteFile(ctx, actor, file.AfterPath)
+		if err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(localPath)
+}
+
+func (fsys *FileSystem) Rename(ctx context.Context, oldName string, newName string) error {
+	if isRoot(oldName) || isRoot(newName) {
+		return os.ErrPermission
+	}
+	oldPath, oldRootDir, err := fsys.resolve(ctx, oldName, true)
+	if err != nil {
+		return err
+	}
+	newPath, newRootDir, err := fsys.resolve(ctx, newName, true)
+	if err != nil {
+		return err
+	}
+	if oldPath == oldRootDir.AfterPath || newPath == newRootDir.AfterPath {
+		return os.ErrPermission
+	}
+	if strings.HasPrefix(newPath, oldPath+"/") {
+		return os.ErrInvalid
+	}
+	actor := getSession(ctx).requester
+
+	if fsys.isConflicted(ctx, oldPath) || fsys.isConflicted(ctx, newPath) {
+		return os.ErrPermission
+	}
+	_, err = fsys.syncService.MoveFile(ctx, actor, oldPath, newPath)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// move files in directory, conflicted files are checked first so that directory is not moved partially
+	files, err := fsys.getFilesInDir(ctx, oldRootDir, oldPath)
+	if err != nil {
+		return err
+	}
+	oldLocalPath := fsys.localPath(oldPath)
+	if _, err := os.Stat(oldLocalPath); os.IsNotExist(err) && len(files) == 0 {
+		return os.ErrNotExist
+	}
+	for _, file := range files {
+		if !reflect.ValueOf(file.Conflict).IsZero() {
+			return os.ErrPermission
+		}
+	}
+	for _, file := range files {
+		_, err = fsys.syncService.MoveFile(ctx, actor, file.AfterPath, newPath+strings.TrimPrefix(file.AfterPath, oldPath))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.MkdirAll(fsys.localPath(newPath), 0755)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(oldLocalPath)
+}
+
+func (fsys *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if isRoot(name) {
+		return newDirInfo("/"), nil
+	}
+	afterPath, rootDir, err := fsys.resolve(ctx, name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fsys.localPath(afterPath))
+	if os.IsNotExist(err) && afterPath == rootDir.AfterPath {
+		return newDirInfo(path.Base(afterPath)), nil
+	}
+	return info, err
+}
+
+// resolve cleans name and returns it with its root directory which requester can read (or write if write is true).
+// Root directory which requester can not read is not shown as if it does not exist.
+func (fsys *FileSystem) resolve(ctx context.Context, name string, write bool) (string, *types.RootDirectory, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", nil, os.ErrInvalid
+	}
+	afterPath := path.Clean("/" + name)
+	rootDirName, _ := utils.GetNamesByAfterPath(afterPath)
+
+	rootDir, role, err := fsys.getRootDir(ctx, "/"+rootDirName)
+	if err != nil {
+		return "", nil, err
+	}
+	if role == "" {
+		return "", nil, os.ErrNotExist
+	}
+	if write && !types.CanWrite(role) {
+		return "", nil, os.ErrPermission
+	}
+	return afterPath, rootDir, nil
+}
+
+// getRootDir returns root directory and the role of requester in it, role is empty if requester can not access it
+func (fsys *FileSystem) getRootDir(ctx context.Context, rootDirPath string) (*types.RootDirectory, string, error) {
+	rootDir, err := fsys.syncService.GetRootDirByPath(ctx, rootDirPath)
+	if err != nil {
+		return nil, "", os.ErrNotExist
+	}
+
+	s := getSession(ctx)
+	switch {
+	case s.requester == "":
+		return rootDir, "", nil
+	case s.requester == types.AdminMember:
+		return rootDir, types.RootRoleOwner, nil
+	case s.rootDir != "":
+		if s.rootDir != rootDir.AfterPath {
+			return rootDir, "", nil
+		}
+		// password of root directory gives the same role as joining it with the password
+		return rootDir, rootDir.PasswordRole(), nil
+	default:
+		_, user := types.ParseMember(s.requester)
+		return rootDir, rootDir.GetRole("", user), nil
+	}
+}
+
+// getFilesInDir returns existing files under directory
+func (fsys *FileSystem) getFilesInDir(ctx context.Context, rootDir *types.RootDirectory, afterPath string) ([]types.File, error) {
+	files := []types.File{}
+	for _, file := range fsys.syncService.GetFilesByRootDir(ctx, rootDir.AfterPath) {
+		if file.LatestHash != "" && strings.HasPrefix(file.AfterPath, afterPath+"/") {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// openRoot returns directory which lists root directories requester can read
+func (fsys *FileSystem) openRoot(ctx context.Context) (webdav.File, error) {
+	rootDirList, err := fsys.syncService.GetRootDirList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []os.FileInfo{}
+	for _, rootDirPath := range rootDirList.RootDirList {
+		_, role, err := fsys.getRootDir(ctx, rootDirPath)
+		if err != nil || role == "" {
+			continue
+		}
+		info, err := os.Stat(fsys.localPath(rootDirPath))
+		if err != nil {
+			info = newDirInfo(path.Base(rootDirPath))
+		}
+		entries = append(entries, info)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return &dirFile{info: newDirInfo("/"), entries: entries}, nil
+}
+
+// openUpload returns file which is committed with sync service when it is closed
+func (fsys *FileSystem) openUpload(ctx context.Context, afterPath string, flag int) (webdav.File, error) {
+	parent, err := fsys.Stat(ctx, path.Dir(afterPath))
+	if err != nil {
+		return nil, err
+	}
+	if !parent.IsDir() {
+		return nil, os.ErrInvalid
+	}
+
+	// conflicted file is resolved by clients, it is not overwritten
+	if fsys.isConflicted(ctx, afterPath) {
+		return nil, os.ErrPermission
+	}
+	if info, err := os.Stat(fsys.localPath(afterPath)); err == nil && info.IsDir() {
+		return nil, os.ErrInvalid
+	}
+
+	tempFile, err := os.CreateTemp(utils.GetQuicsDirPath(), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	// file opened without O_TRUNC (e.g., SFTP resume) is written over its latest contents
+	if flag&os.O_TRUNC == 0 {
+		err = copyFile(tempFile, fsys.localPath(afterPath))
+		if err != nil && !os.IsNotExist(err) {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+			return nil, err
+		}
+	}
+	return &uploadFile{
+		File:        tempFile,
+		ctx:         ctx,
+		syncService: fsys.syncService,
+		afterPath:   afterPath,
+	}, nil
+}
+
+// isConflicted checks the file has conflict which is not resolved yet
+func (fsys *FileSystem) isConflicted(ctx context.Context, afterPath string) bool {
+	file, err := fsys.syncService.GetFileByPath(ctx, afterPath)
+	return err == nil && !reflect.ValueOf(file.Conflict).IsZero()
+}
+
+func (fsys *FileSystem) localPath(afterPath string) string {
+	return filepath.Join(fsys.syncDir, filepath.FromSlash(afterPath))
+}
+
+func copyFile(dst *os.File, srcPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Seek(0, io.SeekStart)
+	return err
+}
+
+func isRoot(name string) bool {
+	return path.Clean("/"+name) == "/"
+}
+
+// uploadFile spools written contents to temporary file and commits it as new version when it is closed.
+// Upload which failed to be written or received is not committed.
+type uploadFile struct {
+	*os.File
+	ctx         context.Context
+	syncService sync.Service
+	afterPath   string
+	failed      error
+}
+
+func (f *uploadFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.fail(err)
+	return n, err
+}
+
+func (f *uploadFile) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.File.WriteAt(p, off)
+	f.fail(err)
+	return n, err
+}
+
+// ReadFrom is used by io.Copy (e.g., WebDAV PUT), so error of reading request body is also recorded
+func (f *uploadFile) ReadFrom(r io.Reader) (int64, error) {
+	n, err := f.File.ReadFrom(r)
+	f.fail(err)
+	return n, err
+}
+
+// TransferError is called by sftp server when connection is closed while file is still open
+func (f *uploadFile) TransferError(err error) {
+	f.fail(err)
+}
+
+func (f *uploadFile) fail(err error) {
+	if err != nil && f.failed == nil {
+		f.failed = err
+	}
+}
+
+func (f *uploadFile) Stat() (os.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(f.afterPath), size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+func (f *uploadFile) Close() error {
+	defer os.Remove(f.File.Name())
+	defer f.File.Close()
+
+	if f.failed != nil {
+		return errors.New("[vfs.uploadFile.Close] upload is not completed: " + f.failed.Error())
+	}
+	info, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	if size, ok := getUploadSize(f.ctx); ok && info.Size() != size {
+		return errors.New("[vfs.uploadFile.Close] upload is not completed: " + fmt.Sprint(info.Size(), " of ", size, " bytes"))
+	}
+	_, err = f.File.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	fileMetadata := &types.FileMetadata{
+		Name:    path.Base(f.afterPath),
+		Size:    info.Size(),
+		Mode:    0644,
+		ModTime: time.Now(),
+	}
+	_, err = f.syncService.CommitFile(f.ctx, getSession(f.ctx).requester, f.afterPath, fileMetadata, f.File)
+	return err
+}
+
+// dirFile is directory which does not exist in sync directory (list of root directories, or empty root directory)
+type dirFile struct {
+	info    os.FileInfo
+	entries []os.FileInfo
+	offset  int
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
+
+func (d *dirFile) Read(p []byte) (int, error) {
+	return 0, os.ErrInvalid
+}
+
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, os.ErrInvalid
+}
+
+func (d *dirFile) Write(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if d.offset >= len(d.entries) && count > 0 {
+		return nil, io.EOF
+	}
+	end := len(d.entries)
+	if count > 0 && d.offset+count < end {
+		end = d.offset + count
+	}
+	entries := d.entries[d.offset:end]
+	d.offset = end
+	return entries, nil
+}
+
+func (d *dirFile) Stat() (os.FileInfo, error) {
+	return d.info, nil
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func newDirInfo(name string) *fileInfo {
+	return &fileInfo{name: name, isDir: true}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.isDir }
+func (fi *fileInfo) Sys() any           { return nil }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
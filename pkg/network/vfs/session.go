@@ -0,0 +1,73 @@
+package vfs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+)
+
+type sessionKey struct{}
+
+type uploadSizeKey struct{}
+
+// session is the requester of file system, rootDir is set when requester logged in with password of root directory
+type session struct {
+	requester string
+	rootDir   string
+}
+
+// Authenticate checks user account first, and then password of root directory whose name is user name.
+// Failed attempts of source IP and user name are locked out in the same way as passwords of clients,
+// protocol (webdav or sftp) is recorded with them.
+// It returns context which has the session, which is used by FileSystem to check permission.
+func Authenticate(ctx context.Context, syncService sync.Service, userService user.Service, lockoutService lockout.Service, protocol string, ip string, username string, password string) (context.Context, error) {
+	err := lockoutService.CheckUser(ctx, ip, username)
+	if err != nil {
+		return nil, errors.New("[vfs.Authenticate] " + err.Error())
+	}
+
+	account, err := userService.Authenticate(ctx, username, password)
+	if err == nil {
+		lockoutService.RecordUserSuccess(ctx, username)
+		if account.Role == types.RoleAdmin {
+			return withSession(ctx, &session{requester: types.AdminMember}), nil
+		}
+		return withSession(ctx, &session{requester: types.UserMember(account.Name)}), nil
+	}
+
+	rootDir, err := syncService.GetRootDirByPath(ctx, "/"+username)
+	if err != nil || rootDir.Password == "" || !utils.ComparePassword(rootDir.Password, password) {
+		lockoutService.RecordUserFailure(ctx, protocol, ip, username)
+		return nil, errors.New("[vfs.Authenticate] user name or password is not correct")
+	}
+	lockoutService.RecordUserSuccess(ctx, username)
+	return withSession(ctx, &session{requester: "rootdir:" + rootDir.AfterPath, rootDir: rootDir.AfterPath}), nil
+}
+
+func withSession(ctx context.Context, s *session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func getSession(ctx context.Context) *session {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		return &session{}
+	}
+	return s
+}
+
+// WithUploadSize returns context which has expected size of uploaded file (e.g., Content-Length of WebDAV PUT),
+// file of other size is not committed
+func WithUploadSize(ctx context.Context, size int64) context.Context {
+	return context.WithValue(ctx, uploadSizeKey{}, size)
+}
+
+func getUploadSize(ctx context.Context) (int64, bool) {
+	size, ok := ctx.Value(uploadSizeKey{}).(int64)
+	return size, ok
+}
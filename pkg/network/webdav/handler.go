@@ -0,0 +1,84 @@
+package webdav
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/quic-s/quics/pkg/core/audit"
+	"github.com/quic-s/quics/pkg/core/lockout"
+	"github.com/quic-s/quics/pkg/core/sync"
+	"github.com/quic-s/quics/pkg/core/user"
+	"github.com/quic-s/quics/pkg/network/vfs"
+	"github.com/quic-s/quics/pkg/utils"
+	"golang.org/x/net/webdav"
+)
+
+// Prefix is the path of WebDAV endpoint on rest server, root directories are listed under it
+const Prefix = "/webdav"
+
+// Handler serves latest files of root directories with WebDAV.
+// Users log in with their user account, or with the name and password of root directory
+// to access only the root directory.
+type Handler struct {
+	syncService    sync.Service
+	userService    user.Service
+	lockoutService lockout.Service
+	handler        *webdav.Handler
+}
+
+func NewHandler(syncService sync.Service, userService user.Service, lockoutService lockout.Service, syncDir string) *Handler {
+	return &Handler{
+		syncService:    syncService,
+		userService:    userService,
+		lockoutService: lockoutService,
+		handler: &webdav.Handler{
+			Prefix:     Prefix,
+			FileSystem: vfs.NewFileSystem(syncService, syncDir),
+			LockSystem: webdav.NewMemLS(),
+			Logger: func(r *http.Request, err error) {
+				if err != nil {
+					log.Println("quics err: [WebDAV] ", r.Method, " ", r.URL.Path, ": ", err)
+				}
+			},
+		},
+	}
+}
+
+func (h *Handler) SetupRoutes(mux *http.ServeMux) {
+	mux.Handle(Prefix, h)
+	mux.Handle(Prefix+"/", h)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"quics webdav\"")
+		http.Error(w, "authorization is required", http.StatusUnauthorized)
+		return
+	}
+
+	ip := utils.GetIPFromHostPort(r.RemoteAddr)
+	ctx, err := vfs.Authenticate(r.Context(), h.syncService, h.userService, h.lockoutService, "webdav", ip, username, password)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"quics webdav\"")
+		http.Error(w, "user name or password is not correct", http.StatusUnauthorized)
+		return
+	}
+
+	ctx = audit.WithSourceIP(ctx, ip)
+	if r.Method == http.MethodPut {
+		// body of chunked request (e.g., macOS Finder) has expected size in X-Expected-Entity-Length
+		size := r.ContentLength
+		if size < 0 {
+			size, err = strconv.ParseInt(r.Header.Get("X-Expected-Entity-Length"), 10, 64)
+			if err != nil {
+				size = -1
+			}
+		}
+		if size >= 0 {
+			ctx = vfs.WithUploadSize(ctx, size)
+		}
+	}
+	h.handler.ServeHTTP(w, r.WithContext(ctx))
+}
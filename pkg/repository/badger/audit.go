@@ -0,0 +1,110 @@
+package badger
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	PrefixAudit string = "audit_"
+)
+
+type AuditRepository struct {
+	db *badger.DB
+}
+
+// SaveEvent saves audit event, the key is padded sequence so that events are iterated in order
+func (ar *AuditRepository) SaveEvent(ctx context.Context, auditEvent *types.AuditEvent) error {
+	_, span := tracing.Start(ctx, "AuditRepository.SaveEvent")
+	defer span.End()
+
+	key := []byte(PrefixAudit + fmt.Sprintf("%020d", auditEvent.Seq))
+
+	err := ar.db.Update(func(txn *badger.Txn) error {
+		err := txn.Set(key, auditEvent.Encode())
+		return err
+	})
+	if err != nil {
+		log.Println("quics: (SaveEvent) ", err)
+		return err
+	}
+	return nil
+}
+
+// GetLastEvent gets the latest audit event
+func (ar *AuditRepository) GetLastEvent(ctx context.Context) (*types.AuditEvent, error) {
+	_, span := tracing.Start(ctx, "AuditRepository.GetLastEvent")
+	defer span.End()
+
+	auditEvent := &types.AuditEvent{}
+	err := ar.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixAudit)
+		it.Seek(append([]byte(PrefixAudit), 0xff))
+		if !it.ValidForPrefix(prefix) {
+			return badger.ErrKeyNotFound
+		}
+
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		return auditEvent.Decode(val)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return auditEvent, nil
+}
+
+// GetAllEvents gets all audit events in order
+func (ar *AuditRepository) GetAllEvents(ctx context.Context) ([]types.AuditEvent, error) {
+	_, span := tracing.Start(ctx, "AuditRepository.GetAllEvents")
+	defer span.End()
+
+	auditEvents := []types.AuditEvent{}
+
+	err := ar.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixAudit)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			auditEvent := types.AuditEvent{}
+			if err := auditEvent.Decode(val); err != nil {
+				return err
+			}
+
+			auditEvents = append(auditEvents, auditEvent)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return auditEvents, nil
+}
+
+func (ar *AuditRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
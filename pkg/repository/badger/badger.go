@@ -71,3 +71,32 @@ func (b *Badger) NewSyncRepository() *SyncRepository {
 		db: b.db,
 	}
 }
+
+func (b *Badger) NewUserRepository() *UserRepository {
+	return &UserRepository{
+		db: b.db,
+	}
+}
+
+func (b *Badger) NewLockoutRepository() *LockoutRepository {
+	return &LockoutRepository{
+		db: b.db,
+	}
+}
+
+func (b *Badger) NewTokenRepository() *TokenRepository {
+	return &TokenRepository{
+		db: b.db,
+	}
+}
+
+func (b *Badger) NewAuditRepository() *AuditRepository {
+	return &AuditRepository{
+		db: b.db,
+	}
+}
+
+// IsOpen checks whether database is open
+func (b *Badger) IsOpen() bool {
+	return !b.db.IsClosed()
+}
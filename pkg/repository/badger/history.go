@@ -1,9 +1,11 @@
 package badger
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -16,7 +18,10 @@ type HistoryRepository struct {
 }
 
 // SaveNewFileHistory creates the history with file metadata
-func (hr *HistoryRepository) SaveNewFileHistory(afterPath string, fileHistory *types.FileHistory) error {
+func (hr *HistoryRepository) SaveNewFileHistory(ctx context.Context, afterPath string, fileHistory *types.FileHistory) error {
+	_, span := tracing.Start(ctx, "HistoryRepository.SaveNewFileHistory")
+	defer span.End()
+
 	key := []byte(PrefixHistory + afterPath + "_" + strconv.FormatUint(fileHistory.Timestamp, 10))
 
 	err := hr.db.Update(func(txn *badger.Txn) error {
@@ -31,7 +36,10 @@ func (hr *HistoryRepository) SaveNewFileHistory(afterPath string, fileHistory *t
 }
 
 // GetFileHistory returns the history of the file
-func (hr *HistoryRepository) GetFileHistory(afterPath string, timestamp uint64) (*types.FileHistory, error) {
+func (hr *HistoryRepository) GetFileHistory(ctx context.Context, afterPath string, timestamp uint64) (*types.FileHistory, error) {
+	_, span := tracing.Start(ctx, "HistoryRepository.GetFileHistory")
+	defer span.End()
+
 	key := []byte(PrefixHistory + afterPath + "_" + strconv.FormatUint(timestamp, 10))
 	fileHistory := &types.FileHistory{}
 
@@ -60,7 +68,10 @@ func (hr *HistoryRepository) GetFileHistory(afterPath string, timestamp uint64)
 	return fileHistory, nil
 }
 
-func (hr *HistoryRepository) GetFileHistoriesForClient(afterPath string, cntFromHead uint64) ([]types.FileHistory, error) {
+func (hr *HistoryRepository) GetFileHistoriesForClient(ctx context.Context, afterPath string, cntFromHead uint64) ([]types.FileHistory, error) {
+	_, span := tracing.Start(ctx, "HistoryRepository.GetFileHistoriesForClient")
+	defer span.End()
+
 	var fileHistories []types.FileHistory
 
 	err := hr.db.View(func(txn *badger.Txn) error {
@@ -95,3 +106,7 @@ func (hr *HistoryRepository) GetFileHistoriesForClient(afterPath string, cntFrom
 
 	return fileHistories, nil
 }
+
+func (hr *HistoryRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
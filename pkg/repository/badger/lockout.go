@@ -0,0 +1,118 @@
+package badger
+
+import (
+	"context"
+	"log"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	PrefixLockout string = "lockout_"
+)
+
+type LockoutRepository struct {
+	db *badger.DB
+}
+
+// SaveLockout saves (creates or updates) failed attempts of lockout key
+func (lr *LockoutRepository) SaveLockout(ctx context.Context, lockout *types.Lockout) error {
+	_, span := tracing.Start(ctx, "LockoutRepository.SaveLockout")
+	defer span.End()
+
+	key := []byte(PrefixLockout + lockout.Key)
+
+	err := lr.db.Update(func(txn *badger.Txn) error {
+		err := txn.Set(key, lockout.Encode())
+		return err
+	})
+	if err != nil {
+		log.Println("quics: (SaveLockout) ", err)
+		return err
+	}
+	return nil
+}
+
+// GetLockout gets failed attempts by lockout key
+func (lr *LockoutRepository) GetLockout(ctx context.Context, key string) (*types.Lockout, error) {
+	_, span := tracing.Start(ctx, "LockoutRepository.GetLockout")
+	defer span.End()
+
+	lockout := &types.Lockout{}
+	err := lr.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(PrefixLockout + key))
+		if err != nil {
+			return err
+		}
+
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		return lockout.Decode(val)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lockout, nil
+}
+
+// GetAllLockouts gets failed attempts of all lockout keys
+func (lr *LockoutRepository) GetAllLockouts(ctx context.Context) ([]types.Lockout, error) {
+	_, span := tracing.Start(ctx, "LockoutRepository.GetAllLockouts")
+	defer span.End()
+
+	lockouts := []types.Lockout{}
+
+	err := lr.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixLockout)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			lockout := types.Lockout{}
+			if err := lockout.Decode(val); err != nil {
+				return err
+			}
+
+			lockouts = append(lockouts, lockout)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lockouts, nil
+}
+
+// DeleteLockout deletes failed attempts of lockout key
+func (lr *LockoutRepository) DeleteLockout(ctx context.Context, key string) error {
+	_, span := tracing.Start(ctx, "LockoutRepository.DeleteLockout")
+	defer span.End()
+
+	err := lr.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(PrefixLockout + key))
+	})
+	if err != nil {
+		log.Println("quics: (DeleteLockout) ", err)
+		return err
+	}
+	return nil
+}
+
+func (lr *LockoutRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
@@ -0,0 +1,134 @@
+package badger
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+type pageEntry[T any] struct {
+	key    string
+	time   time.Time
+	record T
+}
+
+// getPage returns page of records whose keys start with prefix, key of record is its badger key without base (e.g., PrefixFile).
+// Records sorted by key are read from the cursor with iterator, so only records of the page are loaded.
+// Records sorted by time are read all, but only a page of them is kept in memory.
+func getPage[T any](db *badger.DB, base string, prefix string, options *types.ListOptions, decode func(val []byte) (*T, error), match func(record *T) bool, timeOf func(record *T) time.Time) (*types.Page[T], error) {
+	sortBy, descending := strings.CutPrefix(options.Sort, "-")
+	byTime := sortBy == types.SortByTime
+
+	cursorTime, cursorKey := time.Time{}, options.After
+	if byTime && options.After != "" {
+		var err error
+		cursorTime, cursorKey, err = types.ParseTimeCursor(options.After)
+		if err != nil {
+			return nil, err
+		}
+	}
+	less := func(a *pageEntry[T], b *pageEntry[T]) bool {
+		if byTime && !a.time.Equal(b.time) {
+			return a.time.Before(b.time) != descending
+		}
+		return a.key != b.key && (a.key < b.key) != descending
+	}
+	cursor := &pageEntry[T]{key: cursorKey, time: cursorTime}
+
+	entries := []*pageEntry[T]{}
+	next := ""
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		opts.Reverse = descending && !byTime
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		start := prefix
+		if opts.Reverse {
+			start = prefix + "\xff"
+		}
+		if !byTime && options.After != "" && (base+options.After > start) != opts.Reverse {
+			start = base + options.After
+		}
+
+		for it.Seek([]byte(start)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			item := it.Item()
+			key := string(item.Key()[len(base):])
+			if !byTime && options.After != "" && key == options.After {
+				continue
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			record, err := decode(val)
+			if err != nil {
+				return err
+			}
+			if !match(record) {
+				continue
+			}
+
+			entry := &pageEntry[T]{key: key, record: *record}
+			if !byTime {
+				if options.Limit > 0 && len(entries) == options.Limit {
+					next = entries[len(entries)-1].key
+					return nil
+				}
+				entries = append(entries, entry)
+				continue
+			}
+
+			entry.time = timeOf(record)
+			if options.After != "" && !less(cursor, entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			// keep only the records which can be in the page
+			if options.Limit > 0 && len(entries) >= 2*(options.Limit+1) {
+				sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+				entries = entries[:options.Limit+1]
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if byTime {
+		sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+		if options.Limit > 0 && len(entries) > options.Limit {
+			entries = entries[:options.Limit]
+			last := entries[len(entries)-1]
+			next = types.TimeCursor(last.time, last.key)
+		}
+	}
+
+	page := &types.Page[T]{Items: []T{}, Next: next}
+	for _, entry := range entries {
+		page.Items = append(page.Items, entry.record)
+	}
+	return page, nil
+}
+
+// pathPrefix returns prefix of paths which are under root directory and start with prefix, false if there is no such path
+func pathPrefix(root string, prefix string) (string, bool) {
+	if root == "" {
+		return prefix, true
+	}
+	rootPrefix := root + "/"
+	if strings.HasPrefix(prefix, rootPrefix) {
+		return prefix, true
+	}
+	if strings.HasPrefix(rootPrefix, prefix) {
+		return rootPrefix, true
+	}
+	return "", false
+}
@@ -1,9 +1,11 @@
 package badger
 
 import (
+	"context"
 	"log"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -17,7 +19,10 @@ type RegistrationRepository struct {
 }
 
 // SaveClient saves new client to badger and this system
-func (rr *RegistrationRepository) SaveClient(uuid string, client *types.Client) error {
+func (rr *RegistrationRepository) SaveClient(ctx context.Context, uuid string, client *types.Client) error {
+	_, span := tracing.Start(ctx, "RegistrationRepository.SaveClient")
+	defer span.End()
+
 	key := []byte(PrefixClient + uuid)
 
 	err := rr.db.Update(func(txn *badger.Txn) error {
@@ -32,7 +37,10 @@ func (rr *RegistrationRepository) SaveClient(uuid string, client *types.Client)
 }
 
 // GetClientByUUID gets client by client uuid
-func (rr *RegistrationRepository) GetClientByUUID(uuid string) (*types.Client, error) {
+func (rr *RegistrationRepository) GetClientByUUID(ctx context.Context, uuid string) (*types.Client, error) {
+	_, span := tracing.Start(ctx, "RegistrationRepository.GetClientByUUID")
+	defer span.End()
+
 	key := []byte(PrefixClient + uuid)
 
 	client := &types.Client{}
@@ -61,7 +69,10 @@ func (rr *RegistrationRepository) GetClientByUUID(uuid string) (*types.Client, e
 	return client, nil
 }
 
-func (rr *RegistrationRepository) DeleteClient(uuid string) error {
+func (rr *RegistrationRepository) DeleteClient(ctx context.Context, uuid string) error {
+	_, span := tracing.Start(ctx, "RegistrationRepository.DeleteClient")
+	defer span.End()
+
 	key := []byte(PrefixClient + uuid)
 
 	err := rr.db.DropPrefix(key)
@@ -71,7 +82,10 @@ func (rr *RegistrationRepository) DeleteClient(uuid string) error {
 	return nil
 }
 
-func (rr *RegistrationRepository) SaveRootDir(afterPath string, rootDir *types.RootDirectory) error {
+func (rr *RegistrationRepository) SaveRootDir(ctx context.Context, afterPath string, rootDir *types.RootDirectory) error {
+	_, span := tracing.Start(ctx, "RegistrationRepository.SaveRootDir")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 
 	err := rr.db.Update(func(txn *badger.Txn) error {
@@ -85,7 +99,10 @@ func (rr *RegistrationRepository) SaveRootDir(afterPath string, rootDir *types.R
 	return nil
 }
 
-func (rr *RegistrationRepository) GetRootDirByPath(afterPath string) (*types.RootDirectory, error) {
+func (rr *RegistrationRepository) GetRootDirByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error) {
+	_, span := tracing.Start(ctx, "RegistrationRepository.GetRootDirByPath")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 
 	var rootDir *types.RootDirectory
@@ -116,7 +133,10 @@ func (rr *RegistrationRepository) GetRootDirByPath(afterPath string) (*types.Roo
 }
 
 // GetAllClients gets all clients
-func (rr *RegistrationRepository) GetAllClients() ([]types.Client, error) {
+func (rr *RegistrationRepository) GetAllClients(ctx context.Context) ([]types.Client, error) {
+	_, span := tracing.Start(ctx, "RegistrationRepository.GetAllClients")
+	defer span.End()
+
 	clients := []types.Client{}
 
 	err := rr.db.View(func(txn *badger.Txn) error {
@@ -150,7 +170,10 @@ func (rr *RegistrationRepository) GetAllClients() ([]types.Client, error) {
 }
 
 // GetSequence returns badger sequence by key
-func (rr *RegistrationRepository) GetSequence(key []byte, increment uint64) (uint64, error) {
+func (rr *RegistrationRepository) GetSequence(ctx context.Context, key []byte, increment uint64) (uint64, error) {
+	_, span := tracing.Start(ctx, "RegistrationRepository.GetSequence")
+	defer span.End()
+
 	seq, err := rr.db.GetSequence(key, increment)
 	if err != nil {
 		log.Panicln("quics: (GetSequence) ", err)
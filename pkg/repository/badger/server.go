@@ -1,9 +1,13 @@
 package badger
 
 import (
+	"context"
 	"log"
+	"reflect"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -15,7 +19,10 @@ type ServerRepository struct {
 	db *badger.DB
 }
 
-func (sr *ServerRepository) UpdatePassword(server *types.Server) error {
+func (sr *ServerRepository) UpdatePassword(ctx context.Context, server *types.Server) error {
+	_, span := tracing.Start(ctx, "ServerRepository.UpdatePassword")
+	defer span.End()
+
 	key := []byte(PrefixServerPassword)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -34,7 +41,10 @@ func (sr *ServerRepository) UpdatePassword(server *types.Server) error {
 	return nil
 }
 
-func (sr *ServerRepository) DeletePassword() error {
+func (sr *ServerRepository) DeletePassword(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeletePassword")
+	defer span.End()
+
 	key := []byte(PrefixServerPassword)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -53,7 +63,10 @@ func (sr *ServerRepository) DeletePassword() error {
 	return nil
 }
 
-func (sr *ServerRepository) GetPassword() (*types.Server, error) {
+func (sr *ServerRepository) GetPassword(ctx context.Context) (*types.Server, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetPassword")
+	defer span.End()
+
 	key := []byte(PrefixServerPassword)
 	server := &types.Server{}
 
@@ -81,7 +94,10 @@ func (sr *ServerRepository) GetPassword() (*types.Server, error) {
 	return server, nil
 }
 
-func (sr *ServerRepository) GetAllClients() ([]types.Client, error) {
+func (sr *ServerRepository) GetAllClients(ctx context.Context) ([]types.Client, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetAllClients")
+	defer span.End()
+
 	clients := []types.Client{}
 
 	err := sr.db.View(func(txn *badger.Txn) error {
@@ -117,7 +133,10 @@ func (sr *ServerRepository) GetAllClients() ([]types.Client, error) {
 	return clients, nil
 }
 
-func (sr *ServerRepository) GetAllRootDirectories() ([]types.RootDirectory, error) {
+func (sr *ServerRepository) GetAllRootDirectories(ctx context.Context) ([]types.RootDirectory, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetAllRootDirectories")
+	defer span.End()
+
 	rootDirs := []types.RootDirectory{}
 
 	err := sr.db.View(func(txn *badger.Txn) error {
@@ -153,7 +172,10 @@ func (sr *ServerRepository) GetAllRootDirectories() ([]types.RootDirectory, erro
 	return rootDirs, nil
 }
 
-func (sr *ServerRepository) GetAllFiles() ([]types.File, error) {
+func (sr *ServerRepository) GetAllFiles(ctx context.Context) ([]types.File, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetAllFiles")
+	defer span.End()
+
 	files := []types.File{}
 
 	err := sr.db.View(func(txn *badger.Txn) error {
@@ -189,7 +211,10 @@ func (sr *ServerRepository) GetAllFiles() ([]types.File, error) {
 	return files, nil
 }
 
-func (sr *ServerRepository) GetClientByUUID(uuid string) (*types.Client, error) {
+func (sr *ServerRepository) GetClientByUUID(ctx context.Context, uuid string) (*types.Client, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetClientByUUID")
+	defer span.End()
+
 	key := []byte(PrefixClient + uuid)
 	client := &types.Client{}
 
@@ -221,7 +246,10 @@ func (sr *ServerRepository) GetClientByUUID(uuid string) (*types.Client, error)
 	return client, nil
 }
 
-func (sr *ServerRepository) GetRootDirectoryByPath(afterPath string) (*types.RootDirectory, error) {
+func (sr *ServerRepository) GetRootDirectoryByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetRootDirectoryByPath")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 	rootDir := &types.RootDirectory{}
 
@@ -253,7 +281,10 @@ func (sr *ServerRepository) GetRootDirectoryByPath(afterPath string) (*types.Roo
 	return rootDir, nil
 }
 
-func (sr *ServerRepository) GetFileByAfterPath(afterPath string) (*types.File, error) {
+func (sr *ServerRepository) GetFileByAfterPath(ctx context.Context, afterPath string) (*types.File, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetFileByAfterPath")
+	defer span.End()
+
 	key := []byte(PrefixFile + afterPath)
 	file := &types.File{}
 
@@ -285,7 +316,10 @@ func (sr *ServerRepository) GetFileByAfterPath(afterPath string) (*types.File, e
 	return file, nil
 }
 
-func (sr *ServerRepository) DeleteAllClients() error {
+func (sr *ServerRepository) DeleteAllClients(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteAllClients")
+	defer span.End()
+
 	key := []byte(PrefixClient)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -312,7 +346,10 @@ func (sr *ServerRepository) DeleteAllClients() error {
 	return nil
 }
 
-func (sr *ServerRepository) DeleteAllRootDirectories() error {
+func (sr *ServerRepository) DeleteAllRootDirectories(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteAllRootDirectories")
+	defer span.End()
+
 	key := []byte(PrefixRootDir)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -339,7 +376,10 @@ func (sr *ServerRepository) DeleteAllRootDirectories() error {
 	return nil
 }
 
-func (sr *ServerRepository) DeleteAllFiles() error {
+func (sr *ServerRepository) DeleteAllFiles(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteAllFiles")
+	defer span.End()
+
 	key := []byte(PrefixFile)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -366,7 +406,10 @@ func (sr *ServerRepository) DeleteAllFiles() error {
 	return nil
 }
 
-func (sr *ServerRepository) DeleteClientByUUID(uuid string) error {
+func (sr *ServerRepository) DeleteClientByUUID(ctx context.Context, uuid string) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteClientByUUID")
+	defer span.End()
+
 	key := []byte(PrefixClient + uuid)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -385,7 +428,10 @@ func (sr *ServerRepository) DeleteClientByUUID(uuid string) error {
 	return nil
 }
 
-func (sr *ServerRepository) DeleteRootDirectoryByAfterPath(afterPath string) error {
+func (sr *ServerRepository) DeleteRootDirectoryByAfterPath(ctx context.Context, afterPath string) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteRootDirectoryByAfterPath")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -404,7 +450,10 @@ func (sr *ServerRepository) DeleteRootDirectoryByAfterPath(afterPath string) err
 	return nil
 }
 
-func (sr *ServerRepository) DeleteFileByAfterPath(afterPath string) error {
+func (sr *ServerRepository) DeleteFileByAfterPath(ctx context.Context, afterPath string) error {
+	_, span := tracing.Start(ctx, "ServerRepository.DeleteFileByAfterPath")
+	defer span.End()
+
 	key := []byte(PrefixFile + afterPath)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -423,7 +472,10 @@ func (sr *ServerRepository) DeleteFileByAfterPath(afterPath string) error {
 	return nil
 }
 
-func (sr *ServerRepository) GetAllHistories() ([]types.FileHistory, error) {
+func (sr *ServerRepository) GetAllHistories(ctx context.Context) ([]types.FileHistory, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetAllHistories")
+	defer span.End()
+
 	histories := []types.FileHistory{}
 
 	err := sr.db.View(func(txn *badger.Txn) error {
@@ -459,7 +511,10 @@ func (sr *ServerRepository) GetAllHistories() ([]types.FileHistory, error) {
 	return histories, nil
 }
 
-func (sr *ServerRepository) GetHistoryByAfterPath(afterPath string) (*types.FileHistory, error) {
+func (sr *ServerRepository) GetHistoryByAfterPath(ctx context.Context, afterPath string) (*types.FileHistory, error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetHistoryByAfterPath")
+	defer span.End()
+
 	key := []byte(PrefixHistory + afterPath)
 	history := &types.FileHistory{}
 
@@ -490,3 +545,148 @@ func (sr *ServerRepository) GetHistoryByAfterPath(afterPath string) (*types.File
 
 	return history, nil
 }
+
+// GetClientPage returns page of clients, Root selects clients which joined the root directory
+func (sr *ServerRepository) GetClientPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.Client], error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetClientPage")
+	defer span.End()
+
+	decode := func(val []byte) (*types.Client, error) {
+		client := &types.Client{}
+		return client, client.Decode(val)
+	}
+	match := func(client *types.Client) bool {
+		if options.UUID != "" && client.UUID != options.UUID {
+			return false
+		}
+		if options.Root == "" {
+			return true
+		}
+		for _, rootDir := range client.Root {
+			if rootDir.AfterPath == options.Root {
+				return true
+			}
+		}
+		return false
+	}
+
+	page, err := getPage(sr.db, PrefixClient, PrefixClient+options.Prefix, options, decode, match, nil)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetRootDirectoryPage returns page of root directories, UUID selects root directories which the client joined
+func (sr *ServerRepository) GetRootDirectoryPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.RootDirectory], error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetRootDirectoryPage")
+	defer span.End()
+
+	decode := func(val []byte) (*types.RootDirectory, error) {
+		rootDir := &types.RootDirectory{}
+		return rootDir, rootDir.Decode(val)
+	}
+	match := func(rootDir *types.RootDirectory) bool {
+		if options.Root != "" && rootDir.AfterPath != options.Root {
+			return false
+		}
+		if options.UUID == "" {
+			return true
+		}
+		for _, uuid := range rootDir.UUIDs {
+			if uuid == options.UUID {
+				return true
+			}
+		}
+		return false
+	}
+
+	page, err := getPage(sr.db, PrefixRootDir, PrefixRootDir+options.Prefix, options, decode, match, nil)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetFilePage returns page of files, UUID selects files which are edited by the client at last and Since is compared with ModTime
+func (sr *ServerRepository) GetFilePage(ctx context.Context, options *types.ListOptions) (*types.Page[types.File], error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetFilePage")
+	defer span.End()
+
+	prefix, ok := pathPrefix(options.Root, options.Prefix)
+	if !ok {
+		return &types.Page[types.File]{Items: []types.File{}}, nil
+	}
+
+	decode := func(val []byte) (*types.File, error) {
+		file := &types.File{}
+		return file, file.Decode(val)
+	}
+	match := func(file *types.File) bool {
+		if options.UUID != "" && file.LatestEditClient != options.UUID {
+			return false
+		}
+		if !options.Since.IsZero() && file.Metadata.ModTime.Before(options.Since) {
+			return false
+		}
+		if options.Conflicted && reflect.ValueOf(file.Conflict).IsZero() {
+			return false
+		}
+		return true
+	}
+	timeOf := func(file *types.File) time.Time {
+		return file.Metadata.ModTime
+	}
+
+	page, err := getPage(sr.db, PrefixFile, PrefixFile+prefix, options, decode, match, timeOf)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetHistoryPage returns page of histories, UUID selects histories which are made by the client and Since is compared with ModTime
+func (sr *ServerRepository) GetHistoryPage(ctx context.Context, options *types.ListOptions) (*types.Page[types.FileHistory], error) {
+	_, span := tracing.Start(ctx, "ServerRepository.GetHistoryPage")
+	defer span.End()
+
+	prefix, ok := pathPrefix(options.Root, options.Prefix)
+	if !ok {
+		return &types.Page[types.FileHistory]{Items: []types.FileHistory{}}, nil
+	}
+
+	decode := func(val []byte) (*types.FileHistory, error) {
+		history := &types.FileHistory{}
+		return history, history.Decode(val)
+	}
+	match := func(history *types.FileHistory) bool {
+		if options.UUID != "" && history.UUID != options.UUID {
+			return false
+		}
+		if !options.Since.IsZero() && history.File.ModTime.Before(options.Since) {
+			return false
+		}
+		return true
+	}
+	timeOf := func(history *types.FileHistory) time.Time {
+		return history.File.ModTime
+	}
+
+	page, err := getPage(sr.db, PrefixHistory, PrefixHistory+prefix, options, decode, match, timeOf)
+	if err != nil {
+		log.Println("quics err: ", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (sr *ServerRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
@@ -1,7 +1,10 @@
 package badger
 
 import (
+	"context"
+
 	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -13,8 +16,11 @@ type SharingRepository struct {
 	db *badger.DB
 }
 
-func (sr *SharingRepository) SaveLink(sharing *types.Sharing) error {
-	key := []byte(PrefixSharing + sharing.Link)
+func (sr *SharingRepository) SaveLink(ctx context.Context, sharing *types.Sharing) error {
+	_, span := tracing.Start(ctx, "SharingRepository.SaveLink")
+	defer span.End()
+
+	key := []byte(PrefixSharing + sharing.ID)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
 		err := txn.Set(key, sharing.Encode())
@@ -27,8 +33,11 @@ func (sr *SharingRepository) SaveLink(sharing *types.Sharing) error {
 	return nil
 }
 
-func (sr *SharingRepository) GetLink(link string) (*types.Sharing, error) {
-	key := []byte(PrefixSharing + link)
+func (sr *SharingRepository) GetLink(ctx context.Context, id string) (*types.Sharing, error) {
+	_, span := tracing.Start(ctx, "SharingRepository.GetLink")
+	defer span.End()
+
+	key := []byte(PrefixSharing + id)
 
 	sharing := &types.Sharing{}
 
@@ -56,8 +65,11 @@ func (sr *SharingRepository) GetLink(link string) (*types.Sharing, error) {
 	return sharing, nil
 }
 
-func (sr *SharingRepository) DeleteLink(link string) error {
-	key := []byte(PrefixSharing + link)
+func (sr *SharingRepository) DeleteLink(ctx context.Context, id string) error {
+	_, span := tracing.Start(ctx, "SharingRepository.DeleteLink")
+	defer span.End()
+
+	key := []byte(PrefixSharing + id)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
 		err := txn.Delete(key)
@@ -74,8 +86,11 @@ func (sr *SharingRepository) DeleteLink(link string) error {
 	return nil
 }
 
-func (sr *SharingRepository) UpdateLink(sharing *types.Sharing) error {
-	key := []byte(PrefixSharing + sharing.Link)
+func (sr *SharingRepository) UpdateLink(ctx context.Context, sharing *types.Sharing) error {
+	_, span := tracing.Start(ctx, "SharingRepository.UpdateLink")
+	defer span.End()
+
+	key := []byte(PrefixSharing + sharing.ID)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
 		err := txn.Set(key, sharing.Encode())
@@ -87,3 +102,43 @@ func (sr *SharingRepository) UpdateLink(sharing *types.Sharing) error {
 
 	return nil
 }
+
+func (sr *SharingRepository) GetAllLinks(ctx context.Context) ([]types.Sharing, error) {
+	_, span := tracing.Start(ctx, "SharingRepository.GetAllLinks")
+	defer span.End()
+
+	sharings := []types.Sharing{}
+
+	err := sr.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(PrefixSharing)); it.ValidForPrefix([]byte(PrefixSharing)); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			sharing := types.Sharing{}
+			if err := sharing.Decode(val); err != nil {
+				return err
+			}
+
+			sharings = append(sharings, sharing)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sharings, nil
+}
+
+func (sr *SharingRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
@@ -1,9 +1,11 @@
 package badger
 
 import (
+	"context"
 	"log"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
 	"github.com/quic-s/quics/pkg/types"
 )
 
@@ -16,7 +18,10 @@ type SyncRepository struct {
 	db *badger.DB
 }
 
-func (sr *SyncRepository) SaveRootDir(afterPath string, rootDir *types.RootDirectory) error {
+func (sr *SyncRepository) SaveRootDir(ctx context.Context, afterPath string, rootDir *types.RootDirectory) error {
+	_, span := tracing.Start(ctx, "SyncRepository.SaveRootDir")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -30,7 +35,10 @@ func (sr *SyncRepository) SaveRootDir(afterPath string, rootDir *types.RootDirec
 	return nil
 }
 
-func (sr *SyncRepository) GetRootDirByPath(afterPath string) (*types.RootDirectory, error) {
+func (sr *SyncRepository) GetRootDirByPath(ctx context.Context, afterPath string) (*types.RootDirectory, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetRootDirByPath")
+	defer span.End()
+
 	key := []byte(PrefixRootDir + afterPath)
 
 	rootDir := &types.RootDirectory{}
@@ -59,7 +67,10 @@ func (sr *SyncRepository) GetRootDirByPath(afterPath string) (*types.RootDirecto
 	return rootDir, nil
 }
 
-func (sr *SyncRepository) GetAllRootDir() ([]types.RootDirectory, error) {
+func (sr *SyncRepository) GetAllRootDir(ctx context.Context) ([]types.RootDirectory, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetAllRootDir")
+	defer span.End()
+
 	rootDirs := []types.RootDirectory{}
 	err := sr.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
@@ -92,7 +103,10 @@ func (sr *SyncRepository) GetAllRootDir() ([]types.RootDirectory, error) {
 }
 
 // IsExistFileByPath checks if file exists by file path
-func (sr *SyncRepository) IsExistFileByPath(afterPath string) (bool, error) {
+func (sr *SyncRepository) IsExistFileByPath(ctx context.Context, afterPath string) (bool, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.IsExistFileByPath")
+	defer span.End()
+
 	key := []byte(PrefixFile + afterPath)
 
 	err := sr.db.View(func(txn *badger.Txn) error {
@@ -107,7 +121,10 @@ func (sr *SyncRepository) IsExistFileByPath(afterPath string) (bool, error) {
 }
 
 // GetFileByPath gets file by file path
-func (sr *SyncRepository) GetFileByPath(path string) (*types.File, error) {
+func (sr *SyncRepository) GetFileByPath(ctx context.Context, path string) (*types.File, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetFileByPath")
+	defer span.End()
+
 	key := []byte(PrefixFile + path)
 	file := &types.File{}
 
@@ -137,7 +154,10 @@ func (sr *SyncRepository) GetFileByPath(path string) (*types.File, error) {
 }
 
 // SaveFileByPath saves new file to badger
-func (sr *SyncRepository) SaveFileByPath(path string, file *types.File) error {
+func (sr *SyncRepository) SaveFileByPath(ctx context.Context, path string, file *types.File) error {
+	_, span := tracing.Start(ctx, "SyncRepository.SaveFileByPath")
+	defer span.End()
+
 	key := []byte(PrefixFile + path)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -152,7 +172,10 @@ func (sr *SyncRepository) SaveFileByPath(path string, file *types.File) error {
 }
 
 // GetAllFiles gets all files
-func (sr *SyncRepository) GetAllFiles(prefix string) ([]types.File, error) {
+func (sr *SyncRepository) GetAllFiles(ctx context.Context, prefix string) ([]types.File, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetAllFiles")
+	defer span.End()
+
 	key := []byte(PrefixFile + prefix)
 	files := []types.File{}
 
@@ -188,7 +211,10 @@ func (sr *SyncRepository) GetAllFiles(prefix string) ([]types.File, error) {
 }
 
 // UpdateContentsExisted updates contents existed flag (if exist then true, or not then false)
-func (sr *SyncRepository) UpdateContentsExisted(path string, contentsExisted bool) error {
+func (sr *SyncRepository) UpdateContentsExisted(ctx context.Context, path string, contentsExisted bool) error {
+	_, span := tracing.Start(ctx, "SyncRepository.UpdateContentsExisted")
+	defer span.End()
+
 	key := []byte(PrefixFile + path)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -222,7 +248,10 @@ func (sr *SyncRepository) UpdateContentsExisted(path string, contentsExisted boo
 	return nil
 }
 
-func (sr *SyncRepository) UpdateFile(file *types.File) error {
+func (sr *SyncRepository) UpdateFile(ctx context.Context, file *types.File) error {
+	_, span := tracing.Start(ctx, "SyncRepository.UpdateFile")
+	defer span.End()
+
 	key := []byte(PrefixFile + file.AfterPath)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -239,7 +268,10 @@ func (sr *SyncRepository) UpdateFile(file *types.File) error {
 	return nil
 }
 
-func (sr *SyncRepository) UpdateConflict(afterpath string, conflict *types.Conflict) error {
+func (sr *SyncRepository) UpdateConflict(ctx context.Context, afterpath string, conflict *types.Conflict) error {
+	_, span := tracing.Start(ctx, "SyncRepository.UpdateConflict")
+	defer span.End()
+
 	key := []byte(PrefixConflict + afterpath)
 
 	err := sr.db.Update(func(txn *badger.Txn) error {
@@ -253,7 +285,10 @@ func (sr *SyncRepository) UpdateConflict(afterpath string, conflict *types.Confl
 	return nil
 }
 
-func (sr *SyncRepository) GetConflict(afterpath string) (*types.Conflict, error) {
+func (sr *SyncRepository) GetConflict(ctx context.Context, afterpath string) (*types.Conflict, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetConflict")
+	defer span.End()
+
 	key := []byte(PrefixConflict + afterpath)
 	conflict := &types.Conflict{}
 
@@ -281,7 +316,10 @@ func (sr *SyncRepository) GetConflict(afterpath string) (*types.Conflict, error)
 	return conflict, nil
 }
 
-func (sr *SyncRepository) GetConflictList(rootDirs []string) ([]types.Conflict, error) {
+func (sr *SyncRepository) GetConflictList(ctx context.Context, rootDirs []string) ([]types.Conflict, error) {
+	_, span := tracing.Start(ctx, "SyncRepository.GetConflictList")
+	defer span.End()
+
 	conflictMetadataList := []types.Conflict{}
 	for _, rootDir := range rootDirs {
 		key := []byte(PrefixConflict + rootDir)
@@ -313,7 +351,10 @@ func (sr *SyncRepository) GetConflictList(rootDirs []string) ([]types.Conflict,
 	return conflictMetadataList, nil
 }
 
-func (sr *SyncRepository) DeleteConflict(afterpath string) error {
+func (sr *SyncRepository) DeleteConflict(ctx context.Context, afterpath string) error {
+	_, span := tracing.Start(ctx, "SyncRepository.DeleteConflict")
+	defer span.End()
+
 	key := []byte(PrefixConflict + afterpath)
 
 	err := sr.db.DropPrefix(key)
@@ -0,0 +1,103 @@
+package badger
+
+import (
+	"context"
+	"log"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	PrefixToken string = "token_"
+)
+
+type TokenRepository struct {
+	db *badger.DB
+}
+
+// SaveToken saves (creates or updates) API token
+func (tr *TokenRepository) SaveToken(ctx context.Context, apiToken *types.APIToken) error {
+	_, span := tracing.Start(ctx, "TokenRepository.SaveToken")
+	defer span.End()
+
+	key := []byte(PrefixToken + apiToken.ID)
+
+	err := tr.db.Update(func(txn *badger.Txn) error {
+		err := txn.Set(key, apiToken.Encode())
+		return err
+	})
+	if err != nil {
+		log.Println("quics: (SaveToken) ", err)
+		return err
+	}
+	return nil
+}
+
+// GetToken gets API token by token ID
+func (tr *TokenRepository) GetToken(ctx context.Context, id string) (*types.APIToken, error) {
+	_, span := tracing.Start(ctx, "TokenRepository.GetToken")
+	defer span.End()
+
+	apiToken := &types.APIToken{}
+	err := tr.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(PrefixToken + id))
+		if err != nil {
+			return err
+		}
+
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		return apiToken.Decode(val)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiToken, nil
+}
+
+// GetAllTokens gets all API tokens
+func (tr *TokenRepository) GetAllTokens(ctx context.Context) ([]types.APIToken, error) {
+	_, span := tracing.Start(ctx, "TokenRepository.GetAllTokens")
+	defer span.End()
+
+	apiTokens := []types.APIToken{}
+
+	err := tr.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixToken)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			apiToken := types.APIToken{}
+			if err := apiToken.Decode(val); err != nil {
+				return err
+			}
+
+			apiTokens = append(apiTokens, apiToken)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiTokens, nil
+}
+
+func (tr *TokenRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
@@ -0,0 +1,187 @@
+package badger
+
+import (
+	"context"
+	"log"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/quic-s/quics/pkg/tracing"
+	"github.com/quic-s/quics/pkg/types"
+)
+
+const (
+	PrefixUser      string = "user_"
+	PrefixAccessKey string = "access_key_"
+)
+
+type UserRepository struct {
+	db *badger.DB
+}
+
+// SaveUser saves (creates or updates) user account
+func (ur *UserRepository) SaveUser(ctx context.Context, user *types.User) error {
+	_, span := tracing.Start(ctx, "UserRepository.SaveUser")
+	defer span.End()
+
+	key := []byte(PrefixUser + user.Name)
+
+	err := ur.db.Update(func(txn *badger.Txn) error {
+		err := txn.Set(key, user.Encode())
+		return err
+	})
+	if err != nil {
+		log.Println("quics: (SaveUser) ", err)
+		return err
+	}
+	return nil
+}
+
+// GetUserByName gets user account by user name
+func (ur *UserRepository) GetUserByName(ctx context.Context, name string) (*types.User, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetUserByName")
+	defer span.End()
+
+	key := []byte(PrefixUser + name)
+
+	user := &types.User{}
+	err := ur.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		return user.Decode(val)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetAllUsers gets all user accounts
+func (ur *UserRepository) GetAllUsers(ctx context.Context) ([]types.User, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetAllUsers")
+	defer span.End()
+
+	users := []types.User{}
+
+	err := ur.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixUser)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			user := types.User{}
+			if err := user.Decode(val); err != nil {
+				return err
+			}
+
+			users = append(users, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// SaveAccessKey saves (creates or updates) S3 access key
+func (ur *UserRepository) SaveAccessKey(ctx context.Context, accessKey *types.AccessKey) error {
+	_, span := tracing.Start(ctx, "UserRepository.SaveAccessKey")
+	defer span.End()
+
+	key := []byte(PrefixAccessKey + accessKey.ID)
+
+	err := ur.db.Update(func(txn *badger.Txn) error {
+		err := txn.Set(key, accessKey.Encode())
+		return err
+	})
+	if err != nil {
+		log.Println("quics: (SaveAccessKey) ", err)
+		return err
+	}
+	return nil
+}
+
+// GetAccessKey gets S3 access key by access key ID
+func (ur *UserRepository) GetAccessKey(ctx context.Context, id string) (*types.AccessKey, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetAccessKey")
+	defer span.End()
+
+	accessKey := &types.AccessKey{}
+	err := ur.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(PrefixAccessKey + id))
+		if err != nil {
+			return err
+		}
+
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		return accessKey.Decode(val)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accessKey, nil
+}
+
+// GetAllAccessKeys gets all S3 access keys
+func (ur *UserRepository) GetAllAccessKeys(ctx context.Context) ([]types.AccessKey, error) {
+	_, span := tracing.Start(ctx, "UserRepository.GetAllAccessKeys")
+	defer span.End()
+
+	accessKeys := []types.AccessKey{}
+
+	err := ur.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixAccessKey)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			accessKey := types.AccessKey{}
+			if err := accessKey.Decode(val); err != nil {
+				return err
+			}
+
+			accessKeys = append(accessKeys, accessKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accessKeys, nil
+}
+
+func (ur *UserRepository) ErrKeyNotFound() error {
+	return badger.ErrKeyNotFound
+}
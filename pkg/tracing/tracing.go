@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/quic-s/quics/pkg/config"
+	"github.com/quic-s/quics/pkg/types"
+	"github.com/quic-s/quics/pkg/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracerName = "github.com/quic-s/quics"
+
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+
+	AttrTransactionName = attribute.Key("quics.transaction.name")
+	AttrTransactionID   = attribute.Key("quics.transaction.id")
+	AttrClientUUID      = attribute.Key("quics.client.uuid")
+	AttrAfterPath       = attribute.Key("quics.file.after_path")
+)
+
+// Init sets the global tracer provider with the exporter configured in qis.env
+// and returns the function to flush and stop it
+func Init() (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporterName := config.GetViperEnvVariables("TRACING_EXPORTER")
+	if exporterName == "" {
+		exporterName = config.DefaultTracingExporter
+	}
+
+	var exporter sdktrace.SpanExporter
+	closeFuncs := []func() error{}
+	switch exporterName {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		endpoint := config.GetViperEnvVariables("TRACING_OTLP_ENDPOINT")
+		if endpoint == "" {
+			endpoint = config.DefaultTracingOTLPEndpoint
+		}
+
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if config.GetViperEnvVariables("TRACING_OTLP_INSECURE") != "false" {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		otlpExporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			err = errors.New("[tracing.Init] create otlp exporter: " + err.Error())
+			return nil, err
+		}
+		exporter = otlpExporter
+	case ExporterFile:
+		filePath := config.GetViperEnvVariables("TRACING_FILE_PATH")
+		if filePath == "" {
+			filePath = filepath.Join(utils.GetQuicsDirPath(), config.DefaultTracingFileName)
+		}
+
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			err = errors.New("[tracing.Init] open trace file: " + err.Error())
+			return nil, err
+		}
+		closeFuncs = append(closeFuncs, file.Close)
+
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			err = errors.New("[tracing.Init] create file exporter: " + err.Error())
+			return nil, err
+		}
+		exporter = fileExporter
+	default:
+		return nil, errors.New("[tracing.Init] unknown exporter: " + exporterName)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("quics"),
+	))
+	if err != nil {
+		err = errors.New("[tracing.Init] create resource: " + err.Error())
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	log.Println("quics: tracing enabled with ", exporterName, " exporter")
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		for _, closeFunc := range closeFuncs {
+			closeFunc()
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span as a child of the span in ctx
+func Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, spanName, opts...)
+}
+
+// StartTransaction starts a span for a quics-protocol transaction.
+// If carrier has the trace context of the peer, the span joins that trace.
+func StartTransaction(ctx context.Context, transactionName string, transactionID []byte, carrier types.TraceContext, kind trace.SpanKind) (context.Context, trace.Span) {
+	if carrier != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	}
+
+	return Start(ctx, transactionName,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			AttrTransactionName.String(transactionName),
+			AttrTransactionID.String(hex.EncodeToString(transactionID)),
+		),
+	)
+}
+
+// Inject returns the trace context of ctx to send it to the peer with a message
+func Inject(ctx context.Context) types.TraceContext {
+	carrier := types.TraceContext{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Detach returns a new background context that only keeps the span of ctx.
+// It is used for work which outlives the request, such as MUSTSYNC goroutines.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// EndWithError records err to span (if exists) and ends span
+func EndWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,131 @@
+package types
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// roles of root directory member
+const (
+	RootRoleOwner  = "owner"
+	RootRoleWriter = "writer"
+	RootRoleReader = "reader"
+)
+
+const (
+	memberPrefixClient = "client:"
+	memberPrefixUser   = "user:"
+	memberPrefixToken  = "token:"
+
+	// AdminMember is used as requester when server administrator manages members
+	AdminMember = "admin"
+)
+
+var rootRoleRanks = map[string]int{
+	RootRoleReader: 1,
+	RootRoleWriter: 2,
+	RootRoleOwner:  3,
+}
+
+// ClientMember makes member key of root directory ACL by client UUID
+func ClientMember(uuid string) string {
+	return memberPrefixClient + uuid
+}
+
+// UserMember makes member key of root directory ACL by user name
+func UserMember(name string) string {
+	return memberPrefixUser + name
+}
+
+// TokenMember makes requester of admin API token, it is not a member of ACL but acts as AdminMember
+func TokenMember(id string) string {
+	return memberPrefixToken + id
+}
+
+// IsAdminRequester checks requester is server administrator (AdminMember or admin API token)
+func IsAdminRequester(requester string) bool {
+	return requester == AdminMember || strings.HasPrefix(requester, memberPrefixToken)
+}
+
+// ParseMember splits member key into client UUID or user name
+func ParseMember(member string) (uuid string, user string) {
+	if strings.HasPrefix(member, memberPrefixClient) {
+		return strings.TrimPrefix(member, memberPrefixClient), ""
+	}
+	if strings.HasPrefix(member, memberPrefixUser) {
+		return "", strings.TrimPrefix(member, memberPrefixUser)
+	}
+	return "", ""
+}
+
+// IsValidRootRole checks role is one of owner, writer and reader
+func IsValidRootRole(role string) bool {
+	_, exists := rootRoleRanks[role]
+	return exists
+}
+
+// CanWrite checks role is allowed to change files of root directory
+func CanWrite(role string) bool {
+	return rootRoleRanks[role] >= rootRoleRanks[RootRoleWriter]
+}
+
+// GetRole gets the role of client (and the user which client is registered under) in root directory.
+// Empty string means the client can not access the root directory.
+// User account without client needs ownership or its own ACL entry, password of root directory is not applied to it.
+func (rootDirectory *RootDirectory) GetRole(uuid string, user string) string {
+	if (uuid != "" && rootDirectory.Owner == uuid) || (user != "" && rootDirectory.OwnerUser == user) {
+		return RootRoleOwner
+	}
+
+	role := ""
+	if uuid != "" {
+		role = higherRole(role, rootDirectory.ACL[ClientMember(uuid)])
+	}
+	if user != "" {
+		role = higherRole(role, rootDirectory.ACL[UserMember(user)])
+	}
+	if role != "" {
+		return role
+	}
+
+	// client which joined with password keeps the role given by password
+	if uuid != "" && slices.Contains(rootDirectory.UUIDs, uuid) {
+		return rootDirectory.PasswordRole()
+	}
+	return ""
+}
+
+// PasswordRole gets the role given by password of root directory.
+// Root directory which owner never managed members is shared as writer to anyone who knows password.
+func (rootDirectory *RootDirectory) PasswordRole() string {
+	if !rootDirectory.Restricted {
+		return RootRoleWriter
+	}
+	return ""
+}
+
+// Restrict makes root directory accessible only by owner and members.
+// Clients which joined with password before are added to ACL as writers, so that they keep their access.
+func (rootDirectory *RootDirectory) Restrict() {
+	if rootDirectory.Restricted {
+		return
+	}
+	if rootDirectory.ACL == nil {
+		rootDirectory.ACL = map[string]string{}
+	}
+	for _, uuid := range rootDirectory.UUIDs {
+		member := ClientMember(uuid)
+		if _, exists := rootDirectory.ACL[member]; !exists && uuid != rootDirectory.Owner {
+			rootDirectory.ACL[member] = RootRoleWriter
+		}
+	}
+	rootDirectory.Restricted = true
+}
+
+func higherRole(a string, b string) string {
+	if rootRoleRanks[b] > rootRoleRanks[a] {
+		return b
+	}
+	return a
+}
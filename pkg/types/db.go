@@ -17,7 +17,7 @@ import (
 )
 
 type DatabaseDataTypes interface {
-	Client | RootDirectory | File | FileHistory | FileMetadata | Sharing
+	Client | RootDirectory | File | FileHistory | FileMetadata | Sharing | User | AccessKey | Lockout | APIToken | AuditEvent
 }
 
 type DatabaseData[T DatabaseDataTypes] interface {
@@ -25,16 +25,127 @@ type DatabaseData[T DatabaseDataTypes] interface {
 	Decode(data []byte) error
 }
 
+// Server is used to store the hashed server password
 type Server struct {
-	Password string
+	Password          string
+	HashedCredentials bool // true after stored credentials are migrated to hashes
+}
+
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// User is used to store user account which clients are registered under
+type User struct {
+	Name         string // key
+	PasswordHash string `json:"-"`
+	Role         string
+	Disabled     bool
+}
+
+// AccessKey is used to store S3 access key of user account.
+// Secret key is stored as it is, because S3 signature is verified with the key itself.
+type AccessKey struct {
+	ID         string // key, access key ID
+	SecretKey  string `json:"-"`
+	User       string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	Revoked    bool
+}
+
+const (
+	TokenScopeRead  = "read"
+	TokenScopeAdmin = "admin"
+)
+
+// APIToken is used to store API token of admin rest api, only the hash of token is stored
+type APIToken struct {
+	ID         string // key
+	Name       string
+	Scope      string
+	TokenHash  string `json:"-"`
+	CreatedBy  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time // zero if token does not expire
+	Session    bool      // token of login session, which can not live longer than session lifetime
+	Revoked    bool
+	Usage      map[string]EndpointUsage // key is "<method> <path>" of called endpoint
+}
+
+// EndpointUsage is used to audit which endpoint is called with API token
+type EndpointUsage struct {
+	Count        uint64
+	LastCalledAt time.Time
+}
+
+const (
+	AuditRegisterClient    = "client.register"
+	AuditRegisterRootDir   = "rootdir.register"
+	AuditSyncRootDir       = "rootdir.join"
+	AuditDisconnectRootDir = "rootdir.disconnect"
+	AuditWriteFile         = "file.write"
+	AuditDeleteFile        = "file.delete"
+	AuditRollbackFile      = "file.rollback"
+	AuditChooseOne         = "conflict.choose"
+	AuditCreateShare       = "share.create"
+	AuditUseShare          = "share.use"
+	AuditStopShare         = "share.stop"
+	AuditRevokeShare       = "share.revoke"
+	AuditAdminCall         = "admin.call"
+)
+
+// AuditEvent is used to store append-only audit log.
+// Each event has the hash of previous event, so that modified or deleted event breaks the chain.
+type AuditEvent struct {
+	Seq       uint64 // key
+	Timestamp time.Time
+	Action    string
+	Actor     string // client UUID, user, API token or anonymous
+	IP        string
+	Path      string
+	Detail    string
+	PrevHash  string
+	Hash      string
+}
+
+const (
+	LockoutKindIP   = "ip"
+	LockoutKindUUID = "uuid"
+	LockoutKindUser = "user"
+)
+
+// Lockout is used to store failed authentication attempts of source IP, client UUID or login name
+type Lockout struct {
+	Key         string // key ("ip:<address>", "uuid:<UUID>" or "user:<name>")
+	Failures    uint
+	LastFailure time.Time
+	LockedUntil time.Time
 }
 
 // Client is used to save connected client information
 type Client struct {
-	UUID string // key
-	Id   uint64
-	Ip   string
-	Root []RootDirectory
+	UUID      string // key
+	Id        uint64
+	Ip        string
+	OwnerUser string
+	Root      []RootDirectory
+
+	CertFingerprint string // SHA-256 fingerprint of client certificate issued by server CA
+	CertRevoked     bool
+
+	Presence ClientPresence // saved when connection ends, live presence is shown while client is connected
+}
+
+// ClientPresence is connection state of client
+type ClientPresence struct {
+	Online      bool
+	ConnectedAt time.Time
+	LastSeen    time.Time // last successful transaction or PING
+	Address     string    // remote address of connection
+	Version     string    // version of quics client, empty if client does not send it
 }
 
 // RootDirectory is used when registering root directory to client
@@ -42,8 +153,11 @@ type RootDirectory struct {
 	AfterPath  string // key
 	BeforePath string
 	Owner      string
-	Password   string
+	OwnerUser  string
+	Password   string `json:"-"` // bcrypt hash
 	UUIDs      []string
+	ACL        map[string]string // member ("user:<name>" or "client:<uuid>") -> role
+	Restricted bool              // true after owner manages members, then only members can join
 }
 
 // File is used to store the file's information
@@ -81,11 +195,34 @@ type Conflict struct {
 
 // Sharing is used to store the file download information
 type Sharing struct {
-	Link     string // key
+	ID       string // key, random token of link
+	Link     string
 	Count    uint
 	MaxCount uint
-	Owner    string
-	File     File
+	Owner    string // UUID of client (or requester of rest api) which created the link
+
+	AfterPath string
+	Timestamp uint64 // pinned version, it is not used if Latest is true
+	Latest    bool   // link always serves the latest version of file
+	IsDir     bool   // link shares directory, files in it are always latest
+	Drop      bool   // upload-only link, files are uploaded into AfterPath and Count is the number of uploaded files
+	MaxSize   uint64 // max size of each uploaded file of drop link (0 if size is not limited)
+
+	PasswordHash string `json:"-"` // bcrypt hash, empty if download password is not set
+	Note         string // note of creator
+	CreatedAt    time.Time
+	ExpiresAt    time.Time // zero if link does not expire
+
+	LastUsedAt time.Time
+	Usages     []ShareUsage // recent downloads (or uploads of drop link), oldest first
+}
+
+// ShareUsage is a download of sharing link (or upload of drop link)
+type ShareUsage struct {
+	Time      time.Time
+	IP        string
+	UserAgent string
+	Path      string // downloaded file (or uploaded file of drop link)
 }
 
 func (server *Server) Encode() []byte {
@@ -104,6 +241,86 @@ func (server *Server) Decode(data []byte) error {
 	return decoder.Decode(server)
 }
 
+func (user *User) Encode() []byte {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(user); err != nil {
+		log.Println("quics: (User.Encode) ", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (user *User) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(user)
+}
+
+func (accessKey *AccessKey) Encode() []byte {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(accessKey); err != nil {
+		log.Println("quics: (AccessKey.Encode) ", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (accessKey *AccessKey) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(accessKey)
+}
+
+func (apiToken *APIToken) Encode() []byte {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(apiToken); err != nil {
+		log.Println("quics: (APIToken.Encode) ", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (apiToken *APIToken) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(apiToken)
+}
+
+func (auditEvent *AuditEvent) Encode() []byte {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(auditEvent); err != nil {
+		log.Println("quics: (AuditEvent.Encode) ", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (auditEvent *AuditEvent) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(auditEvent)
+}
+
+func (lockout *Lockout) Encode() []byte {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(lockout); err != nil {
+		log.Println("quics: (Lockout.Encode) ", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func (lockout *Lockout) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(lockout)
+}
+
 func (client *Client) Encode() []byte {
 	buffer := bytes.Buffer{}
 	encoder := gob.NewEncoder(&buffer)
@@ -30,6 +30,11 @@ const (
 	DOWNLOAD          = "DOWNLOAD"
 	STARTSHARING      = "STARTSHARING"
 	STOPSHARING       = "STOPSHARING"
+	STARTDROP         = "STARTDROP"
+	LISTSHARING       = "LISTSHARING"
+	REVOKESHARING     = "REVOKESHARING"
+	INVITEMEMBER      = "INVITEMEMBER"
+	REVOKEMEMBER      = "REVOKEMEMBER"
 )
 
 type MessageData interface {
@@ -37,20 +42,48 @@ type MessageData interface {
 	Decode([]byte) error
 }
 
+// TraceContext carries the trace context (e.g., traceparent) between server and client.
+// It implements propagation.TextMapCarrier of opentelemetry.
+type TraceContext map[string]string
+
+func (tc TraceContext) Get(key string) string {
+	return tc[key]
+}
+
+func (tc TraceContext) Set(key string, value string) {
+	tc[key] = value
+}
+
+func (tc TraceContext) Keys() []string {
+	keys := make([]string, 0, len(tc))
+	for key := range tc {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 // ClientRegisterReq is used when registering client from client to server
 type ClientRegisterReq struct {
 	UUID           string // client
-	ClientPassword string // client
+	Username       string // user account which client is registered under
+	ClientPassword string // client (or password of user account)
+	CSR            []byte // optional PEM certificate request, server generates key pair if empty
+	Version        string // optional version of quics client
+	Trace          TraceContext
 }
 
 type ClientRegisterRes struct {
-	UUID string // client
+	UUID       string // client
+	ClientCert []byte // PEM client certificate, only issued at first registration
+	ClientKey  []byte // PEM client key, only when certificate request is not sent
+	CACert     []byte // PEM CA certificate to verify server
 }
 
 // ClientDisconnectorReq is used when disconnecting client with server from client to server
 type DisconnectClientReq struct {
 	UUID           string // client
 	ServerPassword string // server
+	Trace          TraceContext
 }
 
 type DisconnectClientRes struct {
@@ -58,7 +91,8 @@ type DisconnectClientRes struct {
 }
 
 type AskRootDirReq struct {
-	UUID string
+	UUID  string
+	Trace TraceContext
 }
 
 type AskRootDirRes struct {
@@ -66,7 +100,8 @@ type AskRootDirRes struct {
 }
 
 type AskConflictListReq struct {
-	UUID string
+	UUID  string
+	Trace TraceContext
 }
 
 type AskConflictListRes struct {
@@ -80,6 +115,7 @@ type RootDirRegisterReq struct {
 	RootDirPassword string
 	BeforePath      string
 	AfterPath       string
+	Trace           TraceContext
 }
 
 type RootDirRegisterRes struct {
@@ -92,12 +128,14 @@ type SyncRootDirReq struct {
 	RootDirPassword string
 	BeforePath      string
 	AfterPath       string
+	Trace           TraceContext
 }
 
 // PleaseFileMetaReq is used when client request file's metadata to server
 type PleaseFileMetaReq struct {
 	UUID      string
 	AfterPath string
+	Trace     TraceContext
 }
 
 // PleaseFileMetaRes is used when server response the latest file's metadata to client
@@ -118,6 +156,7 @@ type PleaseSyncReq struct {
 	LastUpdateHash      string
 	LastSyncHash        string
 	Metadata            FileMetadata
+	Trace               TraceContext
 }
 
 // PleaseSyncRes is used to response to client of whether file is updated or not
@@ -131,6 +170,7 @@ type PleaseSyncRes struct {
 type PleaseTakeReq struct {
 	UUID      string
 	AfterPath string
+	Trace     TraceContext
 }
 
 // PleaseTakeRes is used to response to client of whether file is synchronized or not
@@ -145,6 +185,7 @@ type MustSyncReq struct {
 	LatestSyncTimestamp uint64
 	BeforePath          string
 	AfterPath           string
+	Trace               TraceContext
 }
 
 // MustSyncRes is used to response to server that client will synchronize file
@@ -159,6 +200,7 @@ type MustSyncRes struct {
 type GiveYouReq struct {
 	UUID      string
 	AfterPath string
+	Trace     TraceContext
 }
 
 // GiveYouRes is used to response to server that client received file
@@ -174,6 +216,7 @@ type PleaseFileReq struct {
 	UUID      string
 	AfterPath string
 	Side      string
+	Trace     TraceContext
 }
 
 // PleaseFileRes is used when server response file to client (metadata)
@@ -183,7 +226,8 @@ type PleaseFileRes struct {
 }
 
 type AskAllMetaReq struct {
-	UUID string
+	UUID  string
+	Trace TraceContext
 }
 
 type AskAllMetaRes struct {
@@ -203,6 +247,7 @@ type SyncMetadata struct { // Per file
 type RescanReq struct {
 	UUID          string
 	RootAfterPath []string
+	Trace         TraceContext
 }
 
 type RescanRes struct {
@@ -212,6 +257,7 @@ type RescanRes struct {
 type NeedSyncReq struct {
 	UUID        string
 	FileNeedPSs []FileNeedPS
+	Trace       TraceContext
 }
 
 type FileNeedPS struct {
@@ -228,6 +274,7 @@ type NeedContentReq struct {
 	AfterPath           string
 	LastUpdateTimestamp uint64
 	LastUpdateHash      string
+	Trace               TraceContext
 }
 
 type NeedContentRes struct {
@@ -238,13 +285,15 @@ type NeedContentRes struct {
 }
 
 type Ping struct {
-	UUID string
+	UUID  string
+	Trace TraceContext
 }
 
 type RollBackReq struct {
 	UUID      string
 	AfterPath string
 	Version   uint64
+	Trace     TraceContext
 }
 
 type RollBackRes struct {
@@ -255,6 +304,7 @@ type ShowHistoryReq struct {
 	UUID        string
 	AfterPath   string
 	CntFromHead uint64
+	Trace       TraceContext
 }
 
 type ShowHistoryRes struct {
@@ -265,6 +315,7 @@ type DownloadHistoryReq struct {
 	UUID      string
 	AfterPath string
 	Version   uint64
+	Trace     TraceContext
 }
 
 type DownloadHistoryRes struct {
@@ -275,24 +326,72 @@ type ShareReq struct {
 	UUID      string
 	AfterPath string
 	MaxCnt    uint64
+	Version   uint64 // pinned version (timestamp of file history), 0 pins the version at the time of sharing
+	Latest    bool   // if true, link always serves the latest version of file and Version is ignored
+	ExpireSec uint64 // optional, link expires after seconds (0 if link does not expire)
+	Password  string // optional download password
+	Note      string // optional
+	Trace     TraceContext
 }
 
 type ShareRes struct {
+	ID   string
 	Link string
 }
 
+// DropReq is used to create upload-only link which accepts files into the folder of root directory
+type DropReq struct {
+	UUID      string
+	AfterPath string // folder to receive files, it is created when first file is uploaded
+	MaxSize   uint64 // optional, max size of each file in bytes (0 if size is not limited)
+	MaxCnt    uint64 // max number of files
+	ExpireSec uint64 // optional, link expires after seconds (0 if link does not expire)
+	Password  string // optional upload password
+	Note      string // optional
+	Trace     TraceContext
+}
+
 type StopShareReq struct {
-	UUID string
-	Link string
+	UUID  string
+	Link  string
+	Trace TraceContext
 }
 
 type StopShareRes struct {
 	UUID string
 }
 
+// ListShareReq is used to list sharing links, client can only list its own links
+type ListShareReq struct {
+	UUID      string
+	Owner     string // optional, owner of links (UUID of client or requester of rest api)
+	AfterPath string // optional, links of the path or paths under it
+	Trace     TraceContext
+}
+
+type ListShareRes struct {
+	Links []ShareInfo
+}
+
+// RevokeShareReq is used to revoke sharing links matching all of the given conditions at once.
+// At least one condition is required, and client can only revoke its own links.
+type RevokeShareReq struct {
+	UUID      string
+	IDs       []string // optional, link IDs (or links)
+	Owner     string   // optional, owner of links
+	AfterPath string   // optional, links of the path or paths under it
+	RootDir   string   // optional, links in the root directory (e.g., /rootDir)
+	Trace     TraceContext
+}
+
+type RevokeShareRes struct {
+	IDs []string // revoked link IDs
+}
+
 type AskStagingNumReq struct {
 	UUID      string
 	AfterPath string
+	Trace     TraceContext
 }
 
 type AskStagingNumRes struct {
@@ -304,11 +403,13 @@ type ConflictDownloadReq struct {
 	UUID      string // client UUID who want to download
 	Candidate string // coflict file's UUID from FileHistory (stagingFile)
 	AfterPath string // conflict file's AfterPath
+	Trace     TraceContext
 }
 
 type DisconnectRootDirReq struct {
 	UUID      string
 	AfterPath string
+	Trace     TraceContext
 }
 
 type DisconnectRootDirRes struct {
@@ -316,6 +417,23 @@ type DisconnectRootDirRes struct {
 	AfterPath string
 }
 
+// RootMemberReq is used when owner of root directory invites or revokes member
+// Member is "user:<name>" or "client:<uuid>"
+type RootMemberReq struct {
+	UUID      string
+	AfterPath string
+	Member    string
+	Role      string
+	Trace     TraceContext
+}
+
+type RootMemberRes struct {
+	UUID      string
+	AfterPath string
+	Member    string
+	Role      string
+}
+
 func (clientRegisterReq *ClientRegisterReq) Encode() ([]byte, error) {
 	buffer := bytes.Buffer{}
 	encoder := gob.NewEncoder(&buffer)
@@ -980,6 +1098,22 @@ func (shareRes *ShareRes) Decode(data []byte) error {
 	return decoder.Decode(shareRes)
 }
 
+func (dropReq *DropReq) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(dropReq); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (dropReq *DropReq) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(dropReq)
+}
+
 func (stopShareReq *StopShareReq) Encode() ([]byte, error) {
 	buffer := bytes.Buffer{}
 	encoder := gob.NewEncoder(&buffer)
@@ -1012,6 +1146,70 @@ func (stopShareRes *StopShareRes) Decode(data []byte) error {
 	return decoder.Decode(stopShareRes)
 }
 
+func (listShareReq *ListShareReq) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(listShareReq); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (listShareReq *ListShareReq) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(listShareReq)
+}
+
+func (listShareRes *ListShareRes) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(listShareRes); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (listShareRes *ListShareRes) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(listShareRes)
+}
+
+func (revokeShareReq *RevokeShareReq) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(revokeShareReq); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (revokeShareReq *RevokeShareReq) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(revokeShareReq)
+}
+
+func (revokeShareRes *RevokeShareRes) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(revokeShareRes); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (revokeShareRes *RevokeShareRes) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(revokeShareRes)
+}
+
 func (askStagingNumReq *AskStagingNumReq) Encode() ([]byte, error) {
 	buffer := bytes.Buffer{}
 	encoder := gob.NewEncoder(&buffer)
@@ -1091,3 +1289,35 @@ func (disconnectRootDirRes *DisconnectRootDirRes) Decode(data []byte) error {
 	decoder := gob.NewDecoder(buffer)
 	return decoder.Decode(disconnectRootDirRes)
 }
+
+func (rootMemberReq *RootMemberReq) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(rootMemberReq); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (rootMemberReq *RootMemberReq) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(rootMemberReq)
+}
+
+func (rootMemberRes *RootMemberRes) Encode() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode(rootMemberRes); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (rootMemberRes *RootMemberRes) Decode(data []byte) error {
+	buffer := bytes.NewBuffer(data)
+	decoder := gob.NewDecoder(buffer)
+	return decoder.Decode(rootMemberRes)
+}
@@ -0,0 +1,211 @@
+package types
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorRes is error object returned by /api/v2, Code is snake case name of Status (e.g., not_found)
+type ErrorRes struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// PasswordReq is used to set server password with /api/v2
+type PasswordReq struct {
+	Password string
+}
+
+// ListOptions is used to page, filter and sort records of show endpoints.
+// After is the cursor which is returned as Next of the previous page.
+// Filters which are not supported by the record are rejected by server service.
+type ListOptions struct {
+	Limit      int       // max number of records, 0 means no limit
+	After      string    // cursor of the previous page
+	Root       string    // root directory path (e.g., /rootDir)
+	Prefix     string    // prefix of key (UUID of client, path of others)
+	UUID       string    // client UUID, or UUID of editor client of files and histories
+	Since      time.Time // modified since (files and histories)
+	Conflicted bool      // files which have open conflict only
+	Sort       string    // key (default) or time (files and histories), descending with "-" prefix (e.g., -time)
+}
+
+const (
+	SortByKey  = "key"
+	SortByTime = "time"
+)
+
+// Page is a page of records, Next is empty at the last page
+type Page[T any] struct {
+	Items []T
+	Next  string
+}
+
+// TimeCursor returns cursor of record sorted by time, which is "<unix nano>:<key>"
+func TimeCursor(t time.Time, key string) string {
+	return strconv.FormatInt(t.UnixNano(), 10) + ":" + key
+}
+
+// ParseTimeCursor splits cursor of record sorted by time into time and key
+func ParseTimeCursor(cursor string) (time.Time, string, error) {
+	unixNano, key, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return time.Time{}, "", errors.New("cursor must be <unix nano>:<key>")
+	}
+	n, err := strconv.ParseInt(unixNano, 10, 64)
+	if err != nil {
+		return time.Time{}, "", errors.New("cursor must be <unix nano>:<key>")
+	}
+	return time.Unix(0, n), key, nil
+}
+
+// UserReq is used to add user account with rest api
+type UserReq struct {
+	Name     string
+	Password string
+	Role     string
+}
+
+// APITokenReq is used to create API token with rest api
+type APITokenReq struct {
+	Name      string
+	Scope     string // read or admin
+	ExpireSec uint64 // optional, token expires after seconds (0 if token does not expire)
+	Session   bool   // token of login session (e.g., dashboard), it expires after 12 hours at most
+}
+
+// APITokenRes is used to return created API token, the token is not shown again
+type APITokenRes struct {
+	ID        string
+	Name      string
+	Scope     string
+	Token     string
+	ExpiresAt time.Time // zero if token does not expire
+}
+
+// AccessKeyRes is used to return created S3 access key, the secret key is not shown again
+type AccessKeyRes struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	User            string
+}
+
+// AuditVerifyRes is used to return the result of audit log verification
+type AuditVerifyRes struct {
+	Valid     bool
+	Events    uint64
+	BrokenSeq uint64 // sequence of the first event which breaks the chain
+	Message   string
+}
+
+// SharedDir is used to show index of shared directory to recipients of link
+type SharedDir struct {
+	ID        string
+	Name      string
+	Note      string
+	ExpiresAt time.Time
+	AfterPath string `json:"-"` // path of directory is not shown to recipients
+	Files     []SharedFile
+}
+
+// SharedFile is file in shared directory, path is relative to the directory
+type SharedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// DropInfo is used to show upload form of drop link to uploaders
+type DropInfo struct {
+	ID           string
+	Name         string
+	Note         string
+	MaxSize      uint64
+	RemainingCnt uint
+	ExpiresAt    time.Time
+}
+
+// FileUploadReq is used to upload file with rest api, ExpectedVersion is optional timestamp
+// which the file must be at before the upload (0 if the file must not exist)
+type FileUploadReq struct {
+	AfterPath       string
+	ExpectedVersion *uint64
+}
+
+// FileUploadRes is used to return new version of uploaded file
+type FileUploadRes struct {
+	AfterPath string
+	Timestamp uint64
+	Hash      string
+	Size      int64
+}
+
+// ConflictInfo is conflicted file shown to admin with its candidates
+type ConflictInfo struct {
+	AfterPath           string
+	RootDir             string
+	LatestHash          string
+	LatestSyncTimestamp uint64
+	Candidates          []ConflictCandidate
+}
+
+// ConflictCandidate is staging file of conflicted file, Side is "server" or UUID of client which uploaded it.
+// Uploaded is false while contents of the candidate are not uploaded to conflict directory yet.
+type ConflictCandidate struct {
+	Side      string
+	UUID      string
+	Timestamp uint64
+	Hash      string
+	Size      int64
+	ModTime   time.Time
+	Uploaded  bool
+}
+
+// ConflictResolveReq is used to resolve conflicted file with one of its candidates
+type ConflictResolveReq struct {
+	AfterPath string
+	Side      string
+}
+
+// DropUploadRes is used to return names of files which are uploaded with drop link
+type DropUploadRes struct {
+	Files []string
+}
+
+// CertificateInfo is used to show TLS certificate of server
+type CertificateInfo struct {
+	Subject     string
+	Issuer      string
+	DNSNames    []string
+	IPAddresses []string
+	NotBefore   time.Time
+	NotAfter    time.Time
+	Fingerprint string
+	CertPath    string
+	KeyPath     string
+	Generated   bool // false if certificate is provided by operator
+}
+
+// ShareInfo is sharing link shown to its owner or admin, password hash is not included
+type ShareInfo struct {
+	ID          string
+	Link        string
+	Owner       string
+	AfterPath   string
+	Timestamp   uint64
+	Latest      bool
+	IsDir       bool
+	Drop        bool
+	Count       uint
+	MaxCount    uint
+	MaxSize     uint64
+	HasPassword bool
+	Note        string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LastUsedAt  time.Time
+	Usages      []ShareUsage
+}
@@ -0,0 +1,38 @@
+package types
+
+import "time"
+
+// ServerStatus is used to show the running status of quics server
+type ServerStatus struct {
+	Version          string
+	StartTime        time.Time
+	Uptime           string
+	ConnectedClients []string
+	PendingSyncNum   int64
+	LastFullScans    []FullScanResult
+}
+
+// FullScanResult is the result of the last fullscan to a client
+type FullScanResult struct {
+	UUID           string
+	StartTime      time.Time
+	EndTime        time.Time
+	ScannedFiles   int
+	MustSyncFiles  int
+	ForceSyncFiles int
+	NeedContents   int
+	Error          string
+}
+
+// Readiness is used to check whether quics server is ready to serve
+type Readiness struct {
+	Ready  bool
+	Checks []ReadinessCheck
+}
+
+// ReadinessCheck is the result of each readiness check
+type ReadinessCheck struct {
+	Name    string
+	Ready   bool
+	Message string
+}
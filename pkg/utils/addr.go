@@ -0,0 +1,21 @@
+package utils
+
+import "net"
+
+// GetIPFromAddr gets IP address (without port) of network address
+func GetIPFromAddr(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+
+	return GetIPFromHostPort(addr.String())
+}
+
+// GetIPFromHostPort gets IP address (without port) of "host:port" address (e.g., RemoteAddr of http request)
+func GetIPFromHostPort(hostPort string) string {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort
+	}
+	return host
+}